@@ -0,0 +1,16 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+import "os"
+
+// The CheckoutFile struct is a file or directory of a checked out commit.
+// The checkout is walked once per commit, the conditions, the languages and the regular expressions of the
+// templates all use the files of this walk.
+type CheckoutFile struct {
+	// Path of the file, including the root directory of the checkout
+	Path string
+	// Info of the file as returned by the walk
+	Info os.FileInfo
+	// Language of the file detected from its name, extension or shebang line, empty if it is unknown
+	Language string
+}
@@ -5,18 +5,60 @@ package Analyzer
 type Config struct {
 	// Path of the csv file which contains URLs to GitHub repositories
 	UrlFilePath string
+	// If FromDB is set, the tasks are selected from the database of the crawler instead of the url file
+	FromDB bool
+	// The RepoFilter is used to select the tasks from the database of the crawler
+	RepoFilter RepoFilter
+	// Name of a GitHub organization, whose repositories are scanned instead of the url file
+	GitHubOrg string
+	// Name of a GitHub user, whose repositories are scanned instead of the url file
+	GitHubUser string
+	// ID or path of a GitLab group, whose projects are scanned instead of the url file
+	GitLabGroup string
+	// Base URL of the GitLab instance e.g. https://gitlab.com
+	GitLabURL string
+	// The OwnerFilter is used to filter the repositories of the organization, user or group
+	OwnerFilter OwnerFilter
 	// Tags used to filter the loaded templates
 	Tags string
 	// Path to the directory which contains the template YAML files
 	TemplatesPath string
 	// Path to the directory where the results will be stored
 	ResultsDir string
+	// Names of the sinks the results are written into (comma separated) e.g. csv,jsonl
+	ResultSinks string
+	// Path of the database file which stores the state of all tasks
+	TaskDBPath string
+	// Order in which the tasks are scanned, can be one of: file (default), stars, size, updated, random
+	Order string
+	// Seed used to shuffle the tasks if the random order is used
+	Seed int64
+	// If RetryFailed is set to true, the failed tasks of a previous scan are queued again
+	RetryFailed bool
+	// MaxAttempts is the number of times a task is started before it isn't queued again
+	MaxAttempts int
+	// If Rescan is set to true, the tasks already scanned by an earlier scan session are scanned again
+	Rescan bool
+	// InvalidTemplates defines how invalid templates are handled, can be: fail (default) to refuse the run
+	// or skip to run only the valid templates
+	InvalidTemplates string
 	// Excluded template names use to filter all loaded templates
 	Excluded string
-	// The WorkerCount is used to adjust the number of workers in the worker-pool
+	// The WorkerCount is used to adjust the number of workers in the worker-pool.
+	// It is used as default for CloneWorkers and ScanWorkers if these are not set.
 	WorkerCount int
+	// The CloneWorkers is the number of workers which clone repositories concurrently
+	CloneWorkers int
+	// The ScanWorkers is the number of workers which run templates on cloned repositories concurrently
+	ScanWorkers int
+	// Maximal size of the scanned files in MB, larger files are skipped. 0 disables the limit.
+	MaxFileSize int
+	// If DefaultExcludes is set, vendored and generated directories like node_modules are skipped
+	DefaultExcludes bool
 	// If KeepData is set to true, the repositories will not be deleted after the scan
 	KeepData bool
+	// Address of the coordinator, if the gitAnalyzer runs as worker of a distributed scan
+	CoordinatorAddress string
 	// Verbose can be used to get a more detailed output
 	Verbose bool
 }
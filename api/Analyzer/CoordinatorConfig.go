@@ -0,0 +1,26 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+import "time"
+
+// The CoordinatorConfig struct is used to adjust the settings for the coordinator of a distributed scan.
+type CoordinatorConfig struct {
+	// The address the coordinator listens on for workers e.g. :9090
+	ListenAddress string
+	// Path of the csv file which contains URLs to GitHub repositories
+	UrlFilePath string
+	// Path to the directory where the results will be stored
+	ResultsDir string
+	// Names of the sinks the results are written into (comma separated) e.g. csv,jsonl
+	ResultSinks string
+	// Path to the directory which contains the template YAML files (optional).
+	// If set, the templates are used to post process the results at the end of the scan.
+	TemplatesPath string
+	// The LeaseTimeout is the time a worker has to finish or renew a leased task,
+	// before the task is queued again.
+	LeaseTimeout time.Duration
+	// MaxAttempts is the number of times a task is leased before it is marked as failed
+	MaxAttempts int
+	// Verbose can be used to get a more detailed output
+	Verbose bool
+}
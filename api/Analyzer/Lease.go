@@ -0,0 +1,21 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The Lease struct is used to hand out a task from the coordinator to a worker
+// for a limited amount of time.
+type Lease struct {
+	// ID of the lease, used by the worker to renew or complete the lease
+	ID string `json:"id"`
+	// The leased task
+	Task Task `json:"task"`
+	// The number of seconds the lease is valid without being renewed
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// The LeaseUpdate struct is sent by a worker to renew or complete a lease.
+type LeaseUpdate struct {
+	// ID of the lease
+	ID string `json:"id"`
+	// The task of the lease containing the state and results (only needed to complete a lease)
+	Task Task `json:"task,omitempty"`
+}
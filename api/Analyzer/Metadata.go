@@ -0,0 +1,26 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+import "encoding/json"
+
+// Metadata contains arbitrary columns of a task source e.g. the owner or the team of a repository.
+// The metadata is carried from the task through to the results of the task.
+type Metadata map[string]string
+
+// MarshalCSV stores the metadata as json object inside a single csv column
+func (m Metadata) MarshalCSV() (string, error) {
+	if len(m) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(map[string]string(m))
+	return string(encoded), err
+}
+
+// UnmarshalCSV loads the metadata from the json object of a csv column
+func (m *Metadata) UnmarshalCSV(value string) error {
+	if value == "" {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal([]byte(value), (*map[string]string)(m))
+}
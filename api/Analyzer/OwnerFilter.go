@@ -0,0 +1,12 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The OwnerFilter struct is used to filter the repositories of a GitHub organization/user or a GitLab group
+type OwnerFilter struct {
+	// Include forked repositories
+	Forks bool
+	// Include archived repositories
+	Archived bool
+	// Visibility of the repositories, can be one of: all (default), public, private, internal
+	Visibility string
+}
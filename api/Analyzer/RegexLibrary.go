@@ -0,0 +1,12 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The RegexLibrary struct defines a file of shared regular expressions, which can be included by templates
+type RegexLibrary struct {
+	// Include is a list of further regex library files, relative to the library file
+	Include []string `yaml:"include"`
+	// Variables are the defaults of the variables used by the regular expressions, the template overrides them
+	Variables map[string]string `yaml:"variables"`
+	// The shared regular expressions
+	Regex []Regex `yaml:"regex"`
+}
@@ -0,0 +1,16 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The RepoFilter struct is used to select repositories from the database of the crawler
+type RepoFilter struct {
+	// Languages of the repositories (comma seperated), all languages if empty
+	Language string
+	// Minimum number of stars
+	MinStars int
+	// Only repositories created on or after this date, formatted as 2006-01-02
+	CreatedAfter string
+	// Maximum size of the repositories, no limit if 0
+	MaxSize int
+	// Maximum number of repositories, no limit if 0
+	Limit int
+}
@@ -0,0 +1,20 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The ReportConfig struct is used to adjust the settings for the report module.
+type ReportConfig struct {
+	// Format of the report, can be: html, markdown (one issue per repository)
+	Format string
+	// Path to the directory where the results are stored
+	ResultsDir string
+	// Source the results are loaded from, can be: csv (default), sqlite, mysql
+	Source string
+	// ID of the scan session the report is generated for. If 0 the latest scan session is used
+	ScanID int
+	// Path to the directory which contains the template YAML files, used to add the meta information of the templates
+	TemplatesPath string
+	// Path of the generated report file, or of the directory of the issues if the markdown format is used
+	OutputPath string
+	// Path of a text/template file used to render the issue of a repository. If empty the default issue template is used
+	MarkdownTemplate string
+}
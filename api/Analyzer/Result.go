@@ -4,17 +4,31 @@ package Analyzer
 // The Result struct is used to store the result of a template
 type Result struct {
 	// The name of the template
-	TemplateName string `csv:"-"`
+	TemplateName string `csv:"-" json:"template"`
 	// The GitHub URL of the repository
-	URL string `csv:"url"`
+	URL string `csv:"url" json:"url"`
 	// The commit hash where the result was found
-	CommitHash string `csv:"commit_hash"`
+	CommitHash string `csv:"commit_hash" json:"commitHash"`
 	// The Timestamp displays when the result was found
-	Timestamp string `csv:"timestamp"`
+	Timestamp string `csv:"timestamp" json:"timestamp"`
 	// The Path of the file inside the repository where the result was found
-	Path string `csv:"file_path"`
+	Path string `csv:"file_path" json:"path"`
+	// Line of the file where the match starts, 0 if the result has no position
+	Line int `csv:"line" json:"line,omitempty"`
 	// The Description of the found result
-	Description string `csv:"description"`
+	Description string `csv:"description" json:"description"`
+	// Position of the regular expression inside the template which found the result, starting at 1.
+	// It is 0 for the results of scripts.
+	Regex int `csv:"-" json:"regex,omitempty"`
 	// The output of the template command or regular expression
-	Output string `csv:"output"`
+	Output string `csv:"output" json:"output"`
+	// Salted hash of the original output, set if the output was redacted.
+	// It identifies the finding, so redacted results can still be compared.
+	OutputHash string `csv:"output_hash" json:"outputHash,omitempty"`
+	// Version of the template which found the result
+	TemplateVersion string `csv:"template_version" json:"templateVersion,omitempty"`
+	// The Metadata of the task the result was found for
+	Metadata Metadata `csv:"metadata" json:"metadata,omitempty"`
+	// ID of the scan session which found the result
+	ScanID int `csv:"scan_id" json:"scanId"`
 }
@@ -0,0 +1,17 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The ScanSession struct describes a single run of a scan.
+// The ID of the session is stored with every result, so the results of different runs can be compared.
+type ScanSession struct {
+	// ID of the session, incremented for every run inside the same results directory
+	ID int `csv:"id" json:"id"`
+	// Time the scan was started, formatted as RFC3339
+	StartedAt string `csv:"started_at" json:"startedAt"`
+	// Time the scan was finished, formatted as RFC3339. Empty if the scan is running or was interrupted
+	FinishedAt string `csv:"finished_at" json:"finishedAt"`
+	// The settings of the scan as json
+	Config string `csv:"config" json:"config"`
+	// The used templates and their versions, formatted as name@hash (comma separated)
+	Templates string `csv:"templates" json:"templates"`
+}
@@ -0,0 +1,31 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The ScriptContext contains the values of the placeholders of the template scripts e.g. {{RepoURL}}.
+// The values are also exported to the environment of the scripts and passed as JSON on their stdin.
+type ScriptContext struct {
+	// Name of the template the script belongs to
+	Template string `json:"template"`
+	// URL of the scanned repository
+	RepoURL string `json:"repoURL"`
+	// Path of the checked out repository
+	RepoPath string `json:"repoPath"`
+	// Name of the checked out branch of the repository
+	Branch string `json:"branch"`
+	// Hash of the scanned commit
+	Hash string `json:"hash"`
+	// Date of the scanned commit, formatted as RFC 3339
+	CommitDate string `json:"commitDate"`
+	// Name and email of the author of the scanned commit
+	Author string `json:"author"`
+	// Language of the repository
+	Language string `json:"language"`
+	// Directory the script is run in
+	Dir string `json:"dir"`
+	// Absolute path of the results directory
+	ResultsDir string `json:"resultsDir"`
+	// Path of the matched file relative to Dir e.g. ./package.json
+	File string `json:"file"`
+	// Paths of all matched files inside Dir, relative to Dir
+	Files []string `json:"files"`
+}
@@ -8,4 +8,6 @@ type Stat struct {
 	URL string `csv:"url"`
 	// Time it took to scan the repository
 	ElapsedTime string `csv:"elapsed_time"`
+	// ID of the scan session which scanned the repository
+	ScanID int `csv:"scan_id"`
 }
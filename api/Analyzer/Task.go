@@ -3,14 +3,32 @@ package Analyzer
 
 // The Task struct is used to define a working task for the gitAnalyzer
 type Task struct {
+	// ID of the task inside the task store
+	ID int64 `csv:"-"`
 	// Git URL of the repository to scan
 	URL string `csv:"url"`
-	// Language of the git repository (optional)
+	// Language of the git repository (optional), detected from the files of the repository if it is missing
 	Language string `csv:"language"`
+	// Further languages detected in the repository, ordered by their share of the files
+	SecondaryLanguages []string `csv:"-"`
+	// Priority of the task, tasks with a higher priority are scanned first (optional)
+	Priority int `csv:"priority"`
+	// The number of Stars of the repository (optional)
+	Stars int `csv:"stars"`
+	// The Size of the repository (optional)
+	Size int `csv:"size"`
+	// The last time the repository was updated, formatted as 2006-01-02 (optional)
+	UpdateDate string `csv:"update_date"`
+	// Additional columns of the task source, which are carried through to the results (optional)
+	Metadata Metadata `csv:"-"`
 	// Current State of the task, can be one of: Queued, Cloning, Failed, Running, Finished
 	State string `csv:"-"`
 	// The Results found for the repository
 	Results []Result `csv:"-"`
 	// The time it took to run the task
 	ElapsedTime string `csv:"-"`
+	// The number of times the scan of the task was started
+	Attempts int `csv:"-"`
+	// The last time the state of the task changed
+	UpdatedAt string `csv:"-"`
 }
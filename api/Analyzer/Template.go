@@ -1,6 +1,8 @@
 // Package Analyzer contains all structural components of the application.
 package Analyzer
 
+import "regexp"
+
 // The Meta struct is used to provide some meta information for a template
 type Meta struct {
 	// References like links to websites etc.
@@ -12,6 +14,8 @@ type Meta struct {
 	CVSS       string `yaml:"CVSS"`
 	CVE        string `yaml:"CVE"`
 	Mitigation string `yaml:"mitigation"`
+	// The Severity of the result/vulnerability, can be: critical, high, medium, low, info
+	Severity string `yaml:"severity"`
 }
 
 // The Script struct describes the command which can be executed
@@ -34,6 +38,16 @@ type Match struct {
 	Exclude []string `yaml:"exclude"`
 }
 
+// The Files struct overrides the file classification of the run for a single template
+type Files struct {
+	// Maximal size of the scanned files in MB, overrides the limit of the run
+	MaxFileSize int `yaml:"max_file_size"`
+	// If Binary is set, binary files are scanned too
+	Binary bool `yaml:"binary"`
+	// DefaultExcludes overrides the run option skipping vendored and generated directories like node_modules
+	DefaultExcludes *bool `yaml:"default_excludes"`
+}
+
 // The Test struct is used within a Validation to unit-test a regular expression
 type Test struct {
 	// The input string for the test
@@ -50,25 +64,47 @@ type Validation struct {
 	Tests []Test `yaml:"tests"`
 }
 
+// The Entropy struct defines the minimal Shannon entropy of the matches of a regular expression.
+// The threshold is selected by the charset of the match, matches of other charsets are dropped.
+type Entropy struct {
+	// Minimal entropy of matches consisting only of hex characters (max. 4.0)
+	Hex float64 `yaml:"hex"`
+	// Minimal entropy of matches consisting of base64 characters (max. 6.0)
+	Base64 float64 `yaml:"base64"`
+}
+
 // The Regex struct defines a regular expression and can be used to identify vulnerabilities
 // or misconfigurations in files.
 type Regex struct {
 	// The Description of the regular expression
 	Description string `yaml:"description"`
+	// Type of the rule, can be: regex (default) or entropy to find high entropy strings assigned to secret-looking keys.
+	// Entropy rules use a built-in expression and entropy thresholds, unless they are provided.
+	Type string `yaml:"type"`
+	// Mode defines what the expression is matched against, can be: line (default) to match every line on its own,
+	// multiline to match the whole file with ^ and $ matching at line boundaries or file to match the whole file
+	// and report all matches of the file as a single result.
+	Mode string `yaml:"mode"`
 	// The Expression used as a regular expression
 	Expression string `yaml:"expression"`
+	// Compiled contains the Expression compiled while loading the template
+	Compiled *regexp.Regexp `yaml:"-" json:"-"`
 	// The Group is used to select one of the matches from the results of the Expression
 	Group int `yaml:"group"`
 	// FileEndings to search for
 	FileEndings []string `yaml:"file_endings"`
 	// FalsePositives can be used to filter the result for known non-valid outputs.
 	FalsePositives []string `yaml:"false_positives"`
+	// Entropy thresholds the selected match has to reach
+	Entropy Entropy `yaml:"entropy"`
 	// Filenames to search for
 	Filename []string `yaml:"filename"`
 	// If an Exclude is found in a matched, the file/path will not be returned
 	Exclude []string `yaml:"exclude"`
 	// References like links to websites etc.
 	References []string `yaml:"references"`
+	// Redact overrides the redaction policy of the template for the outputs of the regular expression
+	Redact string `yaml:"redact"`
 	// A Validation struct to verify that the regular expression is working correctly
 	Validation `yaml:"validation"`
 }
@@ -78,6 +114,34 @@ type Output struct {
 	// If Unique is set, a new file will be generated for the given template,
 	// which will contain only unique values for the results.
 	Unique bool `yaml:"uniq"`
+	// Redact defines how the outputs are stored, can be: none (default), partial to keep only the beginning
+	// of every value or hash to replace the output by its salted hash
+	Redact string `yaml:"redact"`
+}
+
+// The When struct defines the conditions under which a template is run, all given conditions have to be met.
+// The conditions of the repository are checked once per task, the conditions of the files per checked out commit.
+type When struct {
+	// Files of which at least one has to exist in the commit. Patterns without a slash are matched against the file
+	// names, patterns with a slash against the paths relative to the repository. Glob patterns like *.tf are supported.
+	Files []string `yaml:"files"`
+	// Languages of which one has to be the language of the repository or be detected from the file extensions
+	// of the commit e.g. JavaScript, Python
+	Languages []string `yaml:"languages"`
+	// Minimal and maximal number of stars of the repository from the crawl data, 0 disables the bound.
+	// The bounds are not checked for tasks without stars, as their stars are unknown.
+	MinStars int `yaml:"min_stars"`
+	MaxStars int `yaml:"max_stars"`
+	// Minimal and maximal size of the repository from the crawl data (KB for GitHub), 0 disables the bound.
+	// The bounds are not checked for tasks without size, as their size is unknown.
+	MinSize int `yaml:"min_size"`
+	MaxSize int `yaml:"max_size"`
+	// Metadata columns of the task source and the values they have to equal e.g. team: "payments"
+	Metadata map[string]string `yaml:"metadata"`
+	// Only commits committed on or after the date are scanned, formatted as 2006-01-02
+	CommitAfter string `yaml:"commit_after"`
+	// Only commits committed before the date are scanned, formatted as 2006-01-02
+	CommitBefore string `yaml:"commit_before"`
 }
 
 // The Requirements struct is used to define which tools/packages are needed by a template
@@ -95,6 +159,22 @@ type Requirements struct {
 type Template struct {
 	// The Name of the Template
 	Name string `yaml:"name"`
+	// Hash of the template file, used to identify the version of the template a scan was run with
+	Hash string `yaml:"-"`
+	// Version of the template stored with its results, the Hash prefixed with the name and commit of the template
+	// pack for installed packs e.g. community@3f2a1b9c0d4e/5d41402abc4b
+	Version string `yaml:"-"`
+	// Extends is the name of a base template, all fields not set in the file of this template are taken from the
+	// base template.
+	// The regular expressions of the base template are added before the own ones.
+	Extends string `yaml:"extends"`
+	// If Abstract is set, the template is only used as base template of other templates and not run itself
+	Abstract bool `yaml:"abstract"`
+	// Include is a list of regex library files (*.lib.yaml), relative to the template file, whose regular
+	// expressions are added to the template
+	Include []string `yaml:"include"`
+	// Variables are substituted for ${name} in all fields of the template except the code of the scripts while loading it
+	Variables map[string]string `yaml:"variables"`
 	// The Description of what the Template checks
 	Description string `yaml:"description"`
 	// The Requirements struct to define needed tools/packages etc.
@@ -116,9 +196,16 @@ type Template struct {
 	Output `yaml:"output"`
 	// The Match struct is used to provide information to find and filter files inside the repository
 	Match `yaml:"match"`
+	// The When struct defines the conditions under which the template is run for a repository and commit
+	When When `yaml:"when"`
+	// The Files struct overrides which files are skipped because they are binary, too large or vendored
+	Files Files `yaml:"files"`
 	// The Meta struct is used to provide some meta information for the template
 	Meta `yaml:"meta"`
 	// The PreScript can contain a Script which will be executed once before the scans starts.
 	// This can be used to set up docker container etc.
 	PreScript Script `yaml:"pre_script"`
+	// The PostScript can contain a Script which will be executed once after all scans are finished.
+	// It is not executed yet.
+	PostScript Script `yaml:"post_script"`
 }
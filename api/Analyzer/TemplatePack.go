@@ -0,0 +1,25 @@
+// Package Analyzer contains all structural components of the application.
+package Analyzer
+
+// The TemplatePack struct describes a pack of templates installed from a git repository
+type TemplatePack struct {
+	// Name of the pack, the templates are installed into the directory packs/<name> of the templates directory
+	Name string `yaml:"name"`
+	// URL of the git repository of the pack
+	URL string `yaml:"url"`
+	// The branch, tag or commit followed by the pack
+	Ref string `yaml:"ref"`
+	// Path is the directory of the repository containing the templates, the whole repository if it is empty
+	Path string `yaml:"path,omitempty"`
+	// Hash of the installed commit
+	Commit string `yaml:"commit"`
+	// If Pinned is set, the pack stays at the installed commit when it is updated
+	Pinned bool `yaml:"pinned,omitempty"`
+}
+
+// The TemplateLock struct is the lockfile of the templates directory, recording the installed template packs
+// and their commits
+type TemplateLock struct {
+	// The installed template packs
+	Packs []TemplatePack `yaml:"packs"`
+}
@@ -7,4 +7,6 @@ type TemplateTask struct {
 	CommitHash string
 	// The templates which will be run for the commit hash
 	Templates []Template
+	// The Context of the commit passed to the scripts of the templates
+	Context ScriptContext
 }
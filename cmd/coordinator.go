@@ -0,0 +1,73 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/internal/Modules"
+	"github.com/spf13/cobra"
+	"log"
+	"time"
+)
+
+// defaultLeaseTimeout is the default time a worker has to renew a leased task
+const defaultLeaseTimeout = 2 * time.Minute
+
+// coordinatorCmd represents the coordinator command which hands out tasks to remote workers
+var coordinatorCmd = &cobra.Command{
+	Use:   "coordinator",
+	Short: "Coordinate a distributed scan",
+	Long: `The coordinator owns the task list of a distributed scan.
+Workers lease tasks from the coordinator and send their results back,
+which are then written into the results directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			log.Fatalln("Error parsing listen flag:", err.Error())
+		}
+		urlFilePath, errURLFile := cmd.Flags().GetString("url-file")
+		if errURLFile != nil {
+			log.Fatalln("Error parsing urlFilePath flag:", errURLFile.Error())
+		}
+		results, errResults := cmd.Flags().GetString("results")
+		if errResults != nil {
+			log.Fatalln("Error parsing results flag:", errResults.Error())
+		}
+		sinks, errSinks := cmd.Flags().GetString("sink")
+		if errSinks != nil {
+			log.Fatalln("Error parsing sink flag:", errSinks.Error())
+		}
+		templatesPath, errTemplates := cmd.Flags().GetString("templates")
+		if errTemplates != nil {
+			log.Fatalln("Error parsing templates flag:", errTemplates.Error())
+		}
+		leaseTimeout, errLeaseTimeout := cmd.Flags().GetDuration("lease-timeout")
+		if errLeaseTimeout != nil {
+			log.Fatalln("Error parsing lease-timeout flag:", errLeaseTimeout.Error())
+		}
+		maxAttempts, errMaxAttempts := cmd.Flags().GetInt("max-attempts")
+		if errMaxAttempts != nil {
+			log.Fatalln("Error parsing max-attempts flag:", errMaxAttempts.Error())
+		}
+		verbose, errVerbose := cmd.Flags().GetBool("verbose")
+		if errVerbose != nil {
+			log.Fatalln("Error parsing verbose flag:", errVerbose.Error())
+		}
+
+		config := Analyzer.CoordinatorConfig{ListenAddress: listen, UrlFilePath: urlFilePath, ResultsDir: results,
+			ResultSinks: sinks, TemplatesPath: templatesPath, LeaseTimeout: leaseTimeout, MaxAttempts: maxAttempts, Verbose: verbose}
+		Modules.RunCoordinator(config)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(coordinatorCmd)
+
+	coordinatorCmd.Flags().StringP("listen", "l", ":9090", "Address the coordinator listens on for workers.")
+	coordinatorCmd.Flags().StringP("url-file", "u", "./urls.csv", "Source of the repository URLs: a CSV, JSONL or URL list file, a glob pattern, - for stdin or github:org|user/<name>.")
+	coordinatorCmd.Flags().StringP("results", "r", "./results", "Path of the results directory.")
+	coordinatorCmd.Flags().String("sink", "csv", "Sinks the results are written into: csv, jsonl, sqlite, mysql.(comma seperated)")
+	coordinatorCmd.Flags().StringP("templates", "t", "./templates", "Path of the template directory, used to post process the results.")
+	coordinatorCmd.Flags().Duration("lease-timeout", defaultLeaseTimeout, "Time after which a task of an unresponsive worker is queued again.")
+	coordinatorCmd.Flags().Int("max-attempts", 3, "Number of times a task is leased before it is marked as failed.")
+	coordinatorCmd.Flags().Bool("verbose", false, "Show verbose output.")
+}
@@ -0,0 +1,49 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/internal/Modules"
+	"github.com/spf13/cobra"
+	"log"
+	"strconv"
+)
+
+// diffCmd represents the diff command which compares the findings of two scans
+var diffCmd = &cobra.Command{
+	Use:   "diff <scanA> <scanB>",
+	Short: "Compare the findings of two scans",
+	Long: `Compares the findings of two scan sessions of the results directory.
+For every repository scanned by both scans the new, fixed and unchanged findings are reported.
+The IDs of the scan sessions are listed in <results>/scans.csv.
+The results are read from the CSV files by default, use --source for scans written to a database.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := cmd.Flags().GetString("results")
+		if err != nil {
+			log.Fatalln("Error parsing results flag:", err.Error())
+		}
+		source, errSource := cmd.Flags().GetString("source")
+		if errSource != nil {
+			log.Fatalln("Error parsing source flag:", errSource.Error())
+		}
+		scanA, errScanA := strconv.Atoi(args[0])
+		if errScanA != nil {
+			log.Fatalln("Error parsing scanA argument:", errScanA.Error())
+		}
+		scanB, errScanB := strconv.Atoi(args[1])
+		if errScanB != nil {
+			log.Fatalln("Error parsing scanB argument:", errScanB.Error())
+		}
+
+		if err = Modules.PrintScanDiff(results, source, scanA, scanB); err != nil {
+			log.Fatalln("Error comparing scans:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringP("results", "r", "./results", "Path of the results directory.")
+	diffCmd.Flags().String("source", "csv", "Source of the results, can be: csv, sqlite (<results>/results.db), mysql (config.env)")
+}
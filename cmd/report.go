@@ -0,0 +1,68 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/internal/Modules"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+// reportCmd represents the report command which generates a report of the results of a scan
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a report of the results of a scan",
+	Long: `Generates a self-contained report of the results of a scan session.
+The report contains a summary by template and severity, the findings per repository
+with links to the commit and file on the forge, the meta information of the templates and the timing stats.
+The markdown format writes one issue per affected repository, ready for responsible disclosure.
+The issues are rendered with a Go text/template file, secret values are redacted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			log.Fatalln("Error parsing format flag:", err.Error())
+		}
+		results, errResults := cmd.Flags().GetString("results")
+		if errResults != nil {
+			log.Fatalln("Error parsing results flag:", errResults.Error())
+		}
+		source, errSource := cmd.Flags().GetString("source")
+		if errSource != nil {
+			log.Fatalln("Error parsing source flag:", errSource.Error())
+		}
+		scanID, errScanID := cmd.Flags().GetInt("scan")
+		if errScanID != nil {
+			log.Fatalln("Error parsing scan flag:", errScanID.Error())
+		}
+		templatesPath, errTemplates := cmd.Flags().GetString("templates")
+		if errTemplates != nil {
+			log.Fatalln("Error parsing templates flag:", errTemplates.Error())
+		}
+		output, errOutput := cmd.Flags().GetString("output")
+		if errOutput != nil {
+			log.Fatalln("Error parsing output flag:", errOutput.Error())
+		}
+		issueTemplate, errIssueTemplate := cmd.Flags().GetString("issue-template")
+		if errIssueTemplate != nil {
+			log.Fatalln("Error parsing issue-template flag:", errIssueTemplate.Error())
+		}
+
+		config := Analyzer.ReportConfig{Format: format, ResultsDir: results, Source: source, ScanID: scanID,
+			TemplatesPath: templatesPath, OutputPath: output, MarkdownTemplate: issueTemplate}
+		if err = Modules.GenerateReport(config); err != nil {
+			log.Fatalln("Error generating report:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringP("format", "f", "html", "Format of the report, can be: html, markdown")
+	reportCmd.Flags().StringP("results", "r", "./results", "Path of the results directory.")
+	reportCmd.Flags().String("source", "csv", "Source of the results, can be: csv, sqlite (<results>/results.db), mysql (config.env)")
+	reportCmd.Flags().Int("scan", 0, "ID of the scan session. (default: latest scan)")
+	reportCmd.Flags().StringP("templates", "t", "./templates", "Path of the template directory, used to add the meta information of the templates.")
+	reportCmd.Flags().StringP("output", "o", "", "Path of the report file or issue directory. (default: <results>/report.html, <results>/issues)")
+	reportCmd.Flags().String("issue-template", "", "Path of a Go text/template file used to render the markdown issues. (default: built-in template)")
+}
@@ -4,8 +4,11 @@ package cmd
 import (
 	"GitAnalyzer/api/Analyzer"
 	"GitAnalyzer/internal/Modules"
+	"fmt"
 	"github.com/spf13/cobra"
 	"log"
+	"path/filepath"
+	"time"
 )
 
 // runCmd represents the run command which start the gitAnalyzer process
@@ -22,6 +25,11 @@ var runCmd = &cobra.Command{
 		if errTags != nil {
 			log.Fatalln("Error parsing filter flag:", errTags.Error())
 		}
+		if tags != "" {
+			if errFilter := Modules.ValidateTemplateFilter(tags); errFilter != nil {
+				log.Fatalln("Invalid template filter:", errFilter)
+			}
+		}
 		templatesPath, errTemplates := cmd.Flags().GetString("templates")
 		if errTemplates != nil {
 			log.Fatalln("Error parsing templates flag:", errTemplates.Error())
@@ -30,10 +38,40 @@ var runCmd = &cobra.Command{
 		if errWorkerCount != nil {
 			log.Fatalln("Error parsing workerCount flag:", errWorkerCount.Error())
 		}
+		cloneWorkers, errCloneWorkers := cmd.Flags().GetInt("clone-workers")
+		if errCloneWorkers != nil {
+			log.Fatalln("Error parsing clone-workers flag:", errCloneWorkers.Error())
+		}
+		scanWorkers, errScanWorkers := cmd.Flags().GetInt("scan-workers")
+		if errScanWorkers != nil {
+			log.Fatalln("Error parsing scan-workers flag:", errScanWorkers.Error())
+		}
+		// Fall back to the shared worker count if no dedicated count was provided
+		if cloneWorkers <= 0 {
+			cloneWorkers = workerCount
+		}
+		if scanWorkers <= 0 {
+			scanWorkers = workerCount
+		}
 		keepData, errKeepData := cmd.Flags().GetBool("keep-data")
 		if errKeepData != nil {
 			log.Fatalln("Error parsing keep-data flag:", errKeepData.Error())
 		}
+		maxFileSize, errMaxFileSize := cmd.Flags().GetInt("max-file-size")
+		if errMaxFileSize != nil {
+			log.Fatalln("Error parsing max-file-size flag:", errMaxFileSize.Error())
+		}
+		defaultExcludes, errDefaultExcludes := cmd.Flags().GetBool("default-excludes")
+		if errDefaultExcludes != nil {
+			log.Fatalln("Error parsing default-excludes flag:", errDefaultExcludes.Error())
+		}
+		invalidTemplates, errInvalidTemplates := cmd.Flags().GetString("invalid-templates")
+		if errInvalidTemplates != nil {
+			log.Fatalln("Error parsing invalid-templates flag:", errInvalidTemplates.Error())
+		}
+		if !Modules.IsValidInvalidTemplatesOption(invalidTemplates) {
+			log.Fatalln("Unknown handling of invalid templates:", invalidTemplates, "(valid: fail, skip)")
+		}
 		verbose, errVerbose := cmd.Flags().GetBool("verbose")
 		if errVerbose != nil {
 			log.Fatalln("Error parsing verbose flag:", errVerbose.Error())
@@ -43,13 +81,133 @@ var runCmd = &cobra.Command{
 			log.Fatalln("Error parsing excluded templates names flag:", errExcluded.Error())
 		}
 		results, errResults := cmd.Flags().GetString("results")
-		if errTemplates != nil {
+		if errResults != nil {
 			log.Fatalln("Error parsing results flag:", errResults.Error())
 		}
+		sinks, errSinks := cmd.Flags().GetString("sink")
+		if errSinks != nil {
+			log.Fatalln("Error parsing sink flag:", errSinks.Error())
+		}
 
-		config := Analyzer.Config{UrlFilePath: urlFilePath, Tags: tags,
-			TemplatesPath: templatesPath, WorkerCount: workerCount, KeepData: keepData, Excluded: excluded,
-			ResultsDir: results, Verbose: verbose}
+		taskDB, errTaskDB := cmd.Flags().GetString("task-db")
+		if errTaskDB != nil {
+			log.Fatalln("Error parsing task-db flag:", errTaskDB.Error())
+		}
+		// Store the task database inside the results directory by default
+		if taskDB == "" {
+			taskDB = filepath.Join(results, "tasks.db")
+		}
+
+		order, errOrder := cmd.Flags().GetString("order")
+		if errOrder != nil {
+			log.Fatalln("Error parsing order flag:", errOrder.Error())
+		}
+		if !Modules.IsValidTaskOrder(order) {
+			log.Fatalln("Unknown order:", order, "(valid: file, stars, size, updated, random)")
+		}
+		retryFailed, errRetryFailed := cmd.Flags().GetBool("retry-failed")
+		if errRetryFailed != nil {
+			log.Fatalln("Error parsing retry-failed flag:", errRetryFailed.Error())
+		}
+		maxAttempts, errMaxAttempts := cmd.Flags().GetInt("max-attempts")
+		if errMaxAttempts != nil {
+			log.Fatalln("Error parsing max-attempts flag:", errMaxAttempts.Error())
+		}
+		rescan, errRescan := cmd.Flags().GetBool("rescan")
+		if errRescan != nil {
+			log.Fatalln("Error parsing rescan flag:", errRescan.Error())
+		}
+		seed, errSeed := cmd.Flags().GetInt64("seed")
+		if errSeed != nil {
+			log.Fatalln("Error parsing seed flag:", errSeed.Error())
+		}
+		// Use a new seed for every run, if no seed was provided
+		if order == "random" && !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+			fmt.Println("Using random seed:", seed)
+		}
+
+		fromDB, errFromDB := cmd.Flags().GetBool("from-db")
+		if errFromDB != nil {
+			log.Fatalln("Error parsing from-db flag:", errFromDB.Error())
+		}
+		language, errLanguage := cmd.Flags().GetString("language")
+		if errLanguage != nil {
+			log.Fatalln("Error parsing language flag:", errLanguage.Error())
+		}
+		minStars, errMinStars := cmd.Flags().GetInt("min-stars")
+		if errMinStars != nil {
+			log.Fatalln("Error parsing min-stars flag:", errMinStars.Error())
+		}
+		createdAfter, errCreatedAfter := cmd.Flags().GetString("created-after")
+		if errCreatedAfter != nil {
+			log.Fatalln("Error parsing created-after flag:", errCreatedAfter.Error())
+		}
+		if createdAfter != "" {
+			if _, errDate := time.Parse("2006-01-02", createdAfter); errDate != nil {
+				log.Fatalln("Error parsing created-after flag, expected format 2006-01-02:", errDate.Error())
+			}
+		}
+		maxSize, errMaxSize := cmd.Flags().GetInt("max-size")
+		if errMaxSize != nil {
+			log.Fatalln("Error parsing max-size flag:", errMaxSize.Error())
+		}
+		limit, errLimit := cmd.Flags().GetInt("limit")
+		if errLimit != nil {
+			log.Fatalln("Error parsing limit flag:", errLimit.Error())
+		}
+		githubOrg, errGitHubOrg := cmd.Flags().GetString("github-org")
+		if errGitHubOrg != nil {
+			log.Fatalln("Error parsing github-org flag:", errGitHubOrg.Error())
+		}
+		githubUser, errGitHubUser := cmd.Flags().GetString("github-user")
+		if errGitHubUser != nil {
+			log.Fatalln("Error parsing github-user flag:", errGitHubUser.Error())
+		}
+		gitlabGroup, errGitLabGroup := cmd.Flags().GetString("gitlab-group")
+		if errGitLabGroup != nil {
+			log.Fatalln("Error parsing gitlab-group flag:", errGitLabGroup.Error())
+		}
+		gitlabURL, errGitLabURL := cmd.Flags().GetString("gitlab-url")
+		if errGitLabURL != nil {
+			log.Fatalln("Error parsing gitlab-url flag:", errGitLabURL.Error())
+		}
+		forks, errForks := cmd.Flags().GetBool("forks")
+		if errForks != nil {
+			log.Fatalln("Error parsing forks flag:", errForks.Error())
+		}
+		archived, errArchived := cmd.Flags().GetBool("archived")
+		if errArchived != nil {
+			log.Fatalln("Error parsing archived flag:", errArchived.Error())
+		}
+		visibility, errVisibility := cmd.Flags().GetString("visibility")
+		if errVisibility != nil {
+			log.Fatalln("Error parsing visibility flag:", errVisibility.Error())
+		}
+		if !Modules.IsValidVisibility(visibility) {
+			log.Fatalln("Unknown visibility:", visibility, "(valid: all, public, private, internal)")
+		}
+		listTemplates, errListTemplates := cmd.Flags().GetBool("list-templates")
+		if errListTemplates != nil {
+			log.Fatalln("Error parsing list-templates flag:", errListTemplates.Error())
+		}
+		ownerFilter := Analyzer.OwnerFilter{Forks: forks, Archived: archived, Visibility: visibility}
+
+		repoFilter := Analyzer.RepoFilter{Language: language, MinStars: minStars, CreatedAfter: createdAfter,
+			MaxSize: maxSize, Limit: limit}
+
+		config := Analyzer.Config{UrlFilePath: urlFilePath, FromDB: fromDB, RepoFilter: repoFilter, GitHubOrg: githubOrg,
+			GitHubUser: githubUser, GitLabGroup: gitlabGroup, GitLabURL: gitlabURL, OwnerFilter: ownerFilter, Tags: tags,
+			TemplatesPath: templatesPath, WorkerCount: workerCount, CloneWorkers: cloneWorkers, ScanWorkers: scanWorkers,
+			KeepData: keepData, Excluded: excluded, ResultsDir: results, ResultSinks: sinks, TaskDBPath: taskDB, Order: order, Seed: seed,
+			RetryFailed: retryFailed, MaxAttempts: maxAttempts, Rescan: rescan,
+			MaxFileSize: maxFileSize, DefaultExcludes: defaultExcludes,
+			InvalidTemplates: invalidTemplates, Verbose: verbose}
+		// Only print the templates, which would run for the given languages
+		if listTemplates {
+			Modules.ListTemplates(config, language)
+			return
+		}
 		Modules.Run(config)
 	},
 }
@@ -57,12 +215,38 @@ var runCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(runCmd)
 
-	runCmd.Flags().StringP("filter", "f", "", "Tags to filter templates.")
-	runCmd.Flags().StringP("url-file", "u", "./urls.csv", "Path of the file containing repository URLs.")
+	runCmd.Flags().StringP("filter", "f", "", "Filter expression to select templates e.g. 'credentials && !noisy', 'name:npm-*' or 'severity>=high'. (default: misc and language tags)")
+	runCmd.Flags().Bool("list-templates", false, "Only list the templates selected by the filter for the repositories of --language, without scanning.")
+	runCmd.Flags().StringP("url-file", "u", "./urls.csv", "Source of the repository URLs: a CSV, JSONL or URL list file, a glob pattern, - for stdin or github:org|user/<name>. (CSV columns without header: url, language, priority, stars, size, update_date)")
+	runCmd.Flags().Bool("from-db", false, "Select the repositories from the database of the crawler instead of the url file.")
+	runCmd.Flags().String("language", "", "Languages of the repositories selected from the database.(comma seperated)")
+	runCmd.Flags().Int("min-stars", 0, "Minimum number of stars of the repositories selected from the database.")
+	runCmd.Flags().String("created-after", "", "Creation date (2006-01-02) after which the repositories selected from the database were created.")
+	runCmd.Flags().Int("max-size", 0, "Maximum size of the repositories selected from the database.")
+	runCmd.Flags().Int("limit", 0, "Maximum number of repositories selected from the database.")
+	runCmd.Flags().String("github-org", "", "Scan all repositories of the GitHub organization instead of the url file.")
+	runCmd.Flags().String("github-user", "", "Scan all repositories of the GitHub user instead of the url file.")
+	runCmd.Flags().String("gitlab-group", "", "Scan all projects of the GitLab group (ID or path) including subgroups instead of the url file.")
+	runCmd.Flags().String("gitlab-url", "https://gitlab.com", "Base URL of the GitLab instance.")
+	runCmd.Flags().Bool("forks", false, "Include forked repositories of the organization, user or group.")
+	runCmd.Flags().Bool("archived", false, "Include archived repositories of the organization, user or group.")
+	runCmd.Flags().String("visibility", "all", "Visibility of the repositories of the organization, user or group: all, public, private or internal.")
 	runCmd.Flags().StringP("templates", "t", "./templates", "Path of the template directory.")
-	runCmd.Flags().StringP("excluded", "e", "", "Names of excluded templates.(comma seperated)")
+	runCmd.Flags().StringP("excluded", "e", "", "Names or glob patterns of excluded templates.(comma seperated)")
+	runCmd.Flags().String("invalid-templates", Modules.InvalidTemplatesFail, "Handling of invalid templates: fail to refuse the run or skip to run only the valid templates.")
 	runCmd.Flags().StringP("results", "r", "./results", "Path of the results directory.")
+	runCmd.Flags().String("sink", "csv", "Sinks the results are written into: csv, jsonl, sqlite, mysql.(comma seperated)")
+	runCmd.Flags().String("order", "file", "Order of the tasks: file, stars, size, updated or random. Tasks with a higher priority are always scanned first.")
+	runCmd.Flags().Int64("seed", 0, "Seed used to shuffle the tasks for the random order. (default: random)")
+	runCmd.Flags().String("task-db", "", "Path of the task database used to resume a scan. (default: <results>/tasks.db)")
+	runCmd.Flags().Bool("rescan", false, "Scan the repositories again, which were already scanned into the results directory by an earlier scan session.")
+	runCmd.Flags().Bool("retry-failed", false, "Queue the failed tasks of a previous scan again.")
+	runCmd.Flags().Int("max-attempts", 3, "Number of times a task is started, before it isn't resumed anymore.")
 	runCmd.Flags().IntP("worker-count", "c", 5, "Number of concurrent workers.")
+	runCmd.Flags().Int("clone-workers", 0, "Number of concurrent clone workers. (default: worker-count)")
+	runCmd.Flags().Int("scan-workers", 0, "Number of concurrent scan workers. (default: worker-count)")
 	runCmd.Flags().Bool("keep-data", false, "Don't delete the cloned repositories.")
+	runCmd.Flags().Int("max-file-size", Modules.DefaultMaxFileSize, "Maximum size of the scanned files in MB, larger files are skipped. (0: no limit)")
+	runCmd.Flags().Bool("default-excludes", false, "Skip vendored and generated directories like node_modules, vendor and dist.")
 	runCmd.Flags().Bool("verbose", false, "Show verbose output.")
 }
@@ -0,0 +1,87 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/internal/Modules"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// statusCmd represents the status command which shows the progress of a scan
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the progress of a scan",
+	Long: `Shows the number of tasks per state from the task database of a scan.
+The tasks with a given state can be listed with --state.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := cmd.Flags().GetString("results")
+		if err != nil {
+			log.Fatalln("Error parsing results flag:", err.Error())
+		}
+		taskDB, errTaskDB := cmd.Flags().GetString("task-db")
+		if errTaskDB != nil {
+			log.Fatalln("Error parsing task-db flag:", errTaskDB.Error())
+		}
+		state, errState := cmd.Flags().GetString("state")
+		if errState != nil {
+			log.Fatalln("Error parsing state flag:", errState.Error())
+		}
+		if taskDB == "" {
+			taskDB = filepath.Join(results, "tasks.db")
+		}
+
+		// Don't create an empty task store for a wrong path
+		if _, err = os.Stat(taskDB); err != nil {
+			log.Fatalln("Task database not found:", taskDB)
+		}
+
+		// Open the task store
+		taskStore, err := Modules.OpenTaskStore(taskDB)
+		if err != nil {
+			log.Fatalln("Error opening task store:", err)
+		}
+		defer taskStore.Close()
+
+		// Print the number of tasks per state
+		counts, err := taskStore.CountByState()
+		if err != nil {
+			log.Fatalln("Error counting tasks:", err)
+		}
+		states := make([]string, 0, len(counts))
+		total := 0
+		for s, count := range counts {
+			states = append(states, s)
+			total += count
+		}
+		sort.Strings(states)
+		for _, s := range states {
+			fmt.Printf("%-10s %d\n", s, counts[s])
+		}
+		fmt.Printf("%-10s %d\n", "total", total)
+
+		// List the tasks with the given state
+		if state == "" {
+			return
+		}
+		tasks, err := taskStore.GetTasksByState(state)
+		if err != nil {
+			log.Fatalln("Error loading tasks:", err)
+		}
+		fmt.Println()
+		for _, task := range tasks {
+			fmt.Printf("%s\tattempts: %d\tupdated: %s\n", task.URL, task.Attempts, task.UpdatedAt)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringP("results", "r", "./results", "Path of the results directory.")
+	statusCmd.Flags().String("task-db", "", "Path of the task database. (default: <results>/tasks.db)")
+	statusCmd.Flags().StringP("state", "s", "", "List all tasks with the given state e.g. failed.")
+}
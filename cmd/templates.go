@@ -0,0 +1,153 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/internal/Modules"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+// templatesCmd represents the templates command group which manages the template packs
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage template packs",
+	Long: `Installs and updates template packs from git repositories.
+The packs are cloned into <templates>/packs/<name> and loaded with the local templates.
+The installed commits are recorded in <templates>/templates.lock, the version of the template
+is stored with every result.`,
+}
+
+// templatesListCmd represents the templates list command which lists the installed packs and loaded templates
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the installed template packs and the loaded templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		templatesPath, err := cmd.Flags().GetString("templates")
+		if err != nil {
+			log.Fatalln("Error parsing templates flag:", err.Error())
+		}
+
+		packs, err := Modules.NewTemplatePackManager(&Modules.GitHelper{}, templatesPath).List()
+		if err != nil {
+			log.Fatalln("Error loading template lock:", err)
+		}
+		for _, pack := range packs {
+			pinned := ""
+			if pack.Pinned {
+				pinned = " (pinned)"
+			}
+			fmt.Printf("%s\t%s\t%s\t%s%s\n", pack.Name, pack.URL, pack.Ref, pack.Commit, pinned)
+		}
+		if len(packs) > 0 {
+			fmt.Println()
+		}
+
+		// List the templates with their versions, the problems of invalid templates are reported
+		templates, problems := Modules.ValidateTemplates(templatesPath)
+		for _, problem := range problems {
+			log.Println("Invalid template:", problem.Error())
+		}
+		for _, template := range templates {
+			fmt.Printf("%s\t%s\n", template.Name, template.Version)
+		}
+	},
+}
+
+// templatesInstallCmd represents the templates install command which installs a template pack
+var templatesInstallCmd = &cobra.Command{
+	Use:   "install <url>",
+	Short: "Install a template pack from a git repository",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		templatesPath, err := cmd.Flags().GetString("templates")
+		if err != nil {
+			log.Fatalln("Error parsing templates flag:", err.Error())
+		}
+		name, errName := cmd.Flags().GetString("name")
+		if errName != nil {
+			log.Fatalln("Error parsing name flag:", errName.Error())
+		}
+		ref, errRef := cmd.Flags().GetString("ref")
+		if errRef != nil {
+			log.Fatalln("Error parsing ref flag:", errRef.Error())
+		}
+		path, errPath := cmd.Flags().GetString("path")
+		if errPath != nil {
+			log.Fatalln("Error parsing path flag:", errPath.Error())
+		}
+
+		pack, err := Modules.NewTemplatePackManager(&Modules.GitHelper{}, templatesPath).Install(args[0], name, ref,
+			path)
+		if err != nil {
+			log.Fatalln("Error installing template pack:", err)
+		}
+		fmt.Printf("Installed %s %s at %s\n", pack.Name, pack.Ref, pack.Commit)
+	},
+}
+
+// templatesUpdateCmd represents the templates update command which updates the installed template packs
+var templatesUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Update the installed template packs",
+	Long: `Fetches the template packs and checks out the latest commit of their refs, all packs if no name is given.
+Pinned packs and packs missing in the templates directory are checked out at the commit of the lockfile.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templatesPath, err := cmd.Flags().GetString("templates")
+		if err != nil {
+			log.Fatalln("Error parsing templates flag:", err.Error())
+		}
+
+		packs, err := Modules.NewTemplatePackManager(&Modules.GitHelper{}, templatesPath).Update(args)
+		for _, pack := range packs {
+			fmt.Printf("Updated %s %s at %s\n", pack.Name, pack.Ref, pack.Commit)
+		}
+		if err != nil {
+			log.Fatalln("Error updating template packs:", err)
+		}
+	},
+}
+
+// templatesPinCmd represents the templates pin command which pins a template pack to a commit
+var templatesPinCmd = &cobra.Command{
+	Use:   "pin <name> [revision]",
+	Short: "Pin a template pack to a branch, tag or commit",
+	Long: `Checks out the template pack at the given branch, tag or commit, the installed commit if no revision is given.
+Updates keep the pack at this commit until it is unpinned with --unpin.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		templatesPath, err := cmd.Flags().GetString("templates")
+		if err != nil {
+			log.Fatalln("Error parsing templates flag:", err.Error())
+		}
+		unpin, errUnpin := cmd.Flags().GetBool("unpin")
+		if errUnpin != nil {
+			log.Fatalln("Error parsing unpin flag:", errUnpin.Error())
+		}
+		revision := ""
+		if len(args) > 1 {
+			revision = args[1]
+		}
+
+		pack, err := Modules.NewTemplatePackManager(&Modules.GitHelper{}, templatesPath).Pin(args[0], revision, unpin)
+		if err != nil {
+			log.Fatalln("Error pinning template pack:", err)
+		}
+		if unpin {
+			fmt.Printf("Unpinned %s, it follows %s\n", pack.Name, pack.Ref)
+			return
+		}
+		fmt.Printf("Pinned %s at %s\n", pack.Name, pack.Commit)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd, templatesInstallCmd, templatesUpdateCmd, templatesPinCmd)
+
+	templatesCmd.PersistentFlags().StringP("templates", "t", "./templates", "Path of the template directory.")
+	templatesInstallCmd.Flags().String("name", "", "Name of the pack. (default: name of the repository)")
+	templatesInstallCmd.Flags().String("ref", "", "Branch, tag or commit to install. (default: default branch)")
+	templatesInstallCmd.Flags().String("path", "", "Directory of the pack containing the templates. (default: whole pack)")
+	templatesPinCmd.Flags().Bool("unpin", false, "Unpin the pack, so it follows its ref again on updates.")
+}
@@ -0,0 +1,93 @@
+// Package cmd contains all code used by cobra for the cli.
+package cmd
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/internal/Modules"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+// workerCmd represents the worker command which scans the tasks of a coordinator
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Analyze repositories leased from a coordinator",
+	Long: `The worker leases tasks from the given coordinator, executes the selected templates
+on the repositories and sends the results back to the coordinator.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		coordinator, err := cmd.Flags().GetString("coordinator")
+		if err != nil {
+			log.Fatalln("Error parsing coordinator flag:", err.Error())
+		}
+		tags, errTags := cmd.Flags().GetString("filter")
+		if errTags != nil {
+			log.Fatalln("Error parsing filter flag:", errTags.Error())
+		}
+		if tags != "" {
+			if errFilter := Modules.ValidateTemplateFilter(tags); errFilter != nil {
+				log.Fatalln("Invalid template filter:", errFilter)
+			}
+		}
+		templatesPath, errTemplates := cmd.Flags().GetString("templates")
+		if errTemplates != nil {
+			log.Fatalln("Error parsing templates flag:", errTemplates.Error())
+		}
+		excluded, errExcluded := cmd.Flags().GetString("excluded")
+		if errExcluded != nil {
+			log.Fatalln("Error parsing excluded templates names flag:", errExcluded.Error())
+		}
+		cloneWorkers, errCloneWorkers := cmd.Flags().GetInt("clone-workers")
+		if errCloneWorkers != nil {
+			log.Fatalln("Error parsing clone-workers flag:", errCloneWorkers.Error())
+		}
+		scanWorkers, errScanWorkers := cmd.Flags().GetInt("scan-workers")
+		if errScanWorkers != nil {
+			log.Fatalln("Error parsing scan-workers flag:", errScanWorkers.Error())
+		}
+		keepData, errKeepData := cmd.Flags().GetBool("keep-data")
+		if errKeepData != nil {
+			log.Fatalln("Error parsing keep-data flag:", errKeepData.Error())
+		}
+		maxFileSize, errMaxFileSize := cmd.Flags().GetInt("max-file-size")
+		if errMaxFileSize != nil {
+			log.Fatalln("Error parsing max-file-size flag:", errMaxFileSize.Error())
+		}
+		defaultExcludes, errDefaultExcludes := cmd.Flags().GetBool("default-excludes")
+		if errDefaultExcludes != nil {
+			log.Fatalln("Error parsing default-excludes flag:", errDefaultExcludes.Error())
+		}
+		invalidTemplates, errInvalidTemplates := cmd.Flags().GetString("invalid-templates")
+		if errInvalidTemplates != nil {
+			log.Fatalln("Error parsing invalid-templates flag:", errInvalidTemplates.Error())
+		}
+		if !Modules.IsValidInvalidTemplatesOption(invalidTemplates) {
+			log.Fatalln("Unknown handling of invalid templates:", invalidTemplates, "(valid: fail, skip)")
+		}
+		verbose, errVerbose := cmd.Flags().GetBool("verbose")
+		if errVerbose != nil {
+			log.Fatalln("Error parsing verbose flag:", errVerbose.Error())
+		}
+
+		config := Analyzer.Config{CoordinatorAddress: coordinator, Tags: tags, TemplatesPath: templatesPath,
+			Excluded: excluded, CloneWorkers: cloneWorkers, ScanWorkers: scanWorkers, KeepData: keepData,
+			MaxFileSize: maxFileSize, DefaultExcludes: defaultExcludes,
+			InvalidTemplates: invalidTemplates, Verbose: verbose}
+		Modules.RunWorker(&Modules.GitHelper{}, config)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+
+	workerCmd.Flags().String("coordinator", "127.0.0.1:9090", "Address of the coordinator.")
+	workerCmd.Flags().StringP("filter", "f", "", "Filter expression to select templates e.g. 'credentials && !noisy', 'name:npm-*' or 'severity>=high'. (default: misc and language tags)")
+	workerCmd.Flags().StringP("templates", "t", "./templates", "Path of the template directory.")
+	workerCmd.Flags().StringP("excluded", "e", "", "Names or glob patterns of excluded templates.(comma seperated)")
+	workerCmd.Flags().String("invalid-templates", Modules.InvalidTemplatesFail, "Handling of invalid templates: fail to refuse the run or skip to run only the valid templates.")
+	workerCmd.Flags().Int("clone-workers", 5, "Number of concurrent clone workers.")
+	workerCmd.Flags().Int("scan-workers", 5, "Number of concurrent scan workers.")
+	workerCmd.Flags().Bool("keep-data", false, "Don't delete the cloned repositories.")
+	workerCmd.Flags().Int("max-file-size", Modules.DefaultMaxFileSize, "Maximum size of the scanned files in MB, larger files are skipped. (0: no limit)")
+	workerCmd.Flags().Bool("default-excludes", false, "Skip vendored and generated directories like node_modules, vendor and dist.")
+	workerCmd.Flags().Bool("verbose", false, "Show verbose output.")
+}
@@ -8,6 +8,7 @@ require (
 	github.com/gocarina/gocsv v0.0.0-20230123225133-763e25b40669
 	github.com/google/go-github/v50 v50.0.0
 	github.com/labstack/echo/v4 v4.10.2
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/ricochet2200/go-disk-usage/du v0.0.0-20210707232629-ac9918953285
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/viper v1.15.0
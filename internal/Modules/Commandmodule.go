@@ -2,10 +2,14 @@
 package Modules
 
 import (
+	"GitAnalyzer/api/Analyzer"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -16,7 +20,7 @@ type ICommandHelper interface {
 	CheckRequiredTools(tool string) bool
 	CheckRequiredPipPackage(packageName string) bool
 	CheckRequiredNPMPackage(packageName string) bool
-	RunCommand(command string, path string, language string) string
+	RunCommand(command string, path string, language string, context Analyzer.ScriptContext) string
 }
 
 // The CommandHandler struct is responsible to handle all actions
@@ -24,15 +28,19 @@ type ICommandHelper interface {
 type CommandHandler struct {
 }
 
-// runner is a function to execute a command at a given path and return the results
-type runner func(command string, path string) string
+// maxStderrLength is the number of characters of stderr reported if a script fails, the end of stderr is kept
+const maxStderrLength = 2000
+
+// runner is a function to execute a command at a given path with the given context and return the results
+type runner func(command string, path string, context Analyzer.ScriptContext) string
 
 // RunCommand is the facade function to run a command depending on the language at a given path and return the results.
-func (ch *CommandHandler) RunCommand(command, path, language string) string {
+// The values of the context are exported as GA_* environment variables, scripts get the context as JSON on stdin.
+func (ch *CommandHandler) RunCommand(command, path, language string, context Analyzer.ScriptContext) string {
 	// Get the runner of the language
 	run := ch.getRunnerForLanguage(language)
 	// Run command and return result
-	return run(command, path)
+	return run(command, path, context)
 }
 
 // CheckRequiredTools tests, if the provided tool was found inside the PATH of the local system
@@ -92,7 +100,7 @@ func (ch *CommandHandler) getRunnerForLanguage(language string) runner {
 	switch language {
 	// WIP: JavaScript runner
 	/*case "js", "JavaScript":
-	return func(command string, path string) string {
+	return func(command string, path string, context Analyzer.ScriptContext) string {
 		ctx := v8.NewContext()
 		val, err := ctx.RunScript("const add = (a, b) => a + b", "math.js")
 		if err != nil {
@@ -102,71 +110,101 @@ func (ch *CommandHandler) getRunnerForLanguage(language string) runner {
 		return val.String()
 	}*/
 	case "python":
-		return func(command string, path string) string {
+		return func(command string, path string, context Analyzer.ScriptContext) string {
 			// Set Python version
 			pythonVersion := "python3"
 			if !ch.CheckRequiredTools(pythonVersion) {
 				pythonVersion = "python"
 			}
-			// Write the temporary script to the provided path
-			ch.writeScriptFileWithCode(command, path+"/gitAnalyzerPythonScript.py")
-			// Execute the script
-			preparedCmd := exec.Command(pythonVersion, "./gitAnalyzerPythonScript.py")
-			preparedCmd.Dir = path
-			out, err := preparedCmd.Output()
-			if err != nil {
-				// If an error occurred output it
-				fmt.Print("Failed to execute Python script:", command, err.Error())
-				// Delete the temporary script
-				ch.deleteScriptFile(path + "/gitAnalyzerPythonScript.py")
-				return ""
-			}
-			// Delete the temporary script
-			ch.deleteScriptFile(path + "/gitAnalyzerPythonScript.py")
-			// Return result
-			return string(out)
+			return ch.runScriptFile(command, "script.py", pythonVersion, path, context)
 		}
 	case "bash":
-		return func(command string, path string) string {
-			// Write the temporary script to the provided path
-			ch.writeScriptFileWithCode(command, path+"/gitAnalyzerScriptFile.sh")
-			// Execute the script
-			preparedCmd := exec.Command("bash", "./gitAnalyzerScriptFile.sh")
-			preparedCmd.Dir = path
-			out, err := preparedCmd.Output()
-			if err != nil {
-				// If an error occurred output it
-				fmt.Print("Failed to execute shell script:", command, err.Error())
-				// Delete the temporary script
-				ch.deleteScriptFile(path + "/gitAnalyzerPythonScript.py")
-				return ""
-			}
-			// Delete the temporary script
-			ch.deleteScriptFile(path + "/gitAnalyzerScriptFile.sh")
-			// Return result
-			return string(out)
+		return func(command string, path string, context Analyzer.ScriptContext) string {
+			return ch.runScriptFile(command, "script.sh", "bash", path, context)
 		}
 	case "cli":
 		// Fallthrough, as cli is the default runner
 		fallthrough
 	default:
-		return func(command string, path string) string {
+		return func(command string, path string, context Analyzer.ScriptContext) string {
 			// Execute command via Bash
-			preparedCmd := exec.Command("bash", "-c", command)
-			preparedCmd.Dir = path
-			out, err := preparedCmd.Output()
+			out, err := ch.execute(exec.Command("bash", "-c", command), path, context)
 			if err != nil {
 				// If an error occurred but the output still contains some values, return these values
 				if len(out) > 0 {
-					return string(out)
+					return out
 				}
-				fmt.Print("Failed to execute cli command:", command, err.Error())
+				log.Println("Failed to execute cli command of template", context.Template+":", err)
 				return ""
 			}
 			// Return result
-			return string(out)
+			return out
+		}
+	}
+}
+
+// runScriptFile writes the code into a script file inside a private temporary directory and runs it at the given
+// path with the interpreter, the context is passed as JSON on stdin. The script doesn't end up inside the scanned
+// repository, so other templates can't match it and concurrent templates don't overwrite each other's scripts.
+// Python adds the directory of the script to sys.path, so modules of the scanned directory are not importable
+// unless the script adds the working directory itself.
+func (ch *CommandHandler) runScriptFile(code, fileName, interpreter, path string,
+	context Analyzer.ScriptContext) string {
+	// Create the private directory of the script
+	scriptDir, err := os.MkdirTemp("", "gitAnalyzer-script-")
+	if err != nil {
+		log.Println("Error creating script directory:", err.Error())
+		return ""
+	}
+	// Delete the directory with the script afterwards
+	defer ch.deleteScriptDir(scriptDir)
+	// Write the temporary script
+	scriptPath := filepath.Join(scriptDir, fileName)
+	ch.writeScriptFileWithCode(code, scriptPath)
+	// Pass the context with the matched files as JSON on stdin
+	input, err := json.Marshal(context)
+	if err != nil {
+		log.Println("Error encoding script context:", err.Error())
+		return ""
+	}
+	preparedCmd := exec.Command(interpreter, scriptPath)
+	preparedCmd.Stdin = bytes.NewReader(input)
+	// Execute the script
+	out, err := ch.execute(preparedCmd, path, context)
+	if err != nil {
+		// If an error occurred output it
+		log.Println("Failed to execute", interpreter, "script of template", context.Template+":", err)
+		return ""
+	}
+	// Return result
+	return out
+}
+
+// execute runs the prepared command at the given path with the context as GA_* environment variables.
+// The stdout of the command is returned, stderr is captured separately and added to the error if the command fails.
+func (ch *CommandHandler) execute(preparedCmd *exec.Cmd, path string, context Analyzer.ScriptContext) (string, error) {
+	var stderr bytes.Buffer
+	preparedCmd.Dir = path
+	preparedCmd.Env = append(os.Environ(), scriptEnvironment(context)...)
+	preparedCmd.Stderr = &stderr
+	out, err := preparedCmd.Output()
+	if err != nil {
+		if diagnostics := stderrDiagnostics(stderr.String()); diagnostics != "" {
+			return string(out), fmt.Errorf("%v, stderr: %s", err, diagnostics)
 		}
+		return string(out), err
+	}
+	return string(out), nil
+}
+
+// stderrDiagnostics returns the trimmed end of stderr, which usually contains the error of the script.
+// It is cut between characters, so multibyte characters stay valid UTF-8.
+func stderrDiagnostics(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if runes := []rune(stderr); len(runes) > maxStderrLength {
+		stderr = "..." + string(runes[len(runes)-maxStderrLength:])
 	}
+	return stderr
 }
 
 // writeScriptFileWithCode writes the given code into a script file at the provided path.
@@ -185,10 +223,10 @@ func (ch *CommandHandler) writeScriptFileWithCode(code string, path string) {
 	}
 }
 
-// deleteScriptFile removes the script file at the provided path
-func (ch *CommandHandler) deleteScriptFile(path string) {
-	err := os.Remove(path)
+// deleteScriptDir removes the private directory of a script with all its files
+func (ch *CommandHandler) deleteScriptDir(path string) {
+	err := os.RemoveAll(path)
 	if err != nil {
-		log.Println("Error deleting script file:", err.Error())
+		log.Println("Error deleting script directory:", err.Error())
 	}
 }
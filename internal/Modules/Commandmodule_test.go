@@ -2,9 +2,12 @@
 package Modules
 
 import (
+	"GitAnalyzer/api/Analyzer"
 	"github.com/stretchr/testify/suite"
 	"log"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -23,10 +26,14 @@ func (suite *CommandModuleTestSuite) SetupTest() {
 	suite.commandHandler = CommandHandler{}
 }
 
-// TestDeleteScriptFile test if a script file is deleted as expected
-func (suite *CommandModuleTestSuite) TestDeleteScriptFile() {
-	// Create script file
-	path := suite.tempDir + string(os.PathSeparator) + "script.sh"
+// TestDeleteScriptDir test if the directory of a script is deleted as expected
+func (suite *CommandModuleTestSuite) TestDeleteScriptDir() {
+	// Create script file inside the script directory
+	dir := suite.tempDir + string(os.PathSeparator) + "script"
+	path := dir + string(os.PathSeparator) + "script.sh"
+	if err := os.Mkdir(dir, 0700); err != nil {
+		log.Fatalln("Error creating test directory:", err)
+	}
 	file, err := os.Create(path)
 	if err != nil {
 		log.Fatalln("Error creating test files:", err)
@@ -36,11 +43,43 @@ func (suite *CommandModuleTestSuite) TestDeleteScriptFile() {
 		log.Fatalln("Error closing test file:", err)
 	}
 
-	// Call deleteScriptFile to delete teh file
-	suite.commandHandler.deleteScriptFile(path)
+	// Call deleteScriptDir to delete the directory
+	suite.commandHandler.deleteScriptDir(dir)
+
+	// Check if the directory is not existing anymore
+	suite.Assertions.NoDirExists(dir, "Script directory should be deleted.")
+}
+
+// TestRunScript checks that scripts run outside the repository and get the context via stdin and environment
+func (suite *CommandModuleTestSuite) TestRunScript() {
+	context := Analyzer.ScriptContext{Template: "Test", Hash: "abc", Dir: suite.tempDir,
+		Files: []string{"./package.json", "./lib/package.json"}}
+	scripts := map[string]string{
+		"bash": "cat - | grep -o '\"files\":\\[[^]]*\\]'\necho \"$GA_TEMPLATE $GA_HASH $(pwd)\"\nls -A\necho warning >&2",
+		"python": "import json, os, sys\ncontext = json.load(sys.stdin)\nprint(context['files'], os.environ['GA_HASH'])\n" +
+			"print(os.listdir('.'))\nprint('warning', file=sys.stderr)",
+	}
+	expected := map[string]string{
+		"bash":   "\"files\":[\"./package.json\",\"./lib/package.json\"]\nTest abc " + suite.tempDir + "\n",
+		"python": "['./package.json', './lib/package.json'] abc\n[]\n",
+	}
+	for language, script := range scripts {
+		if language == "python" && !suite.commandHandler.CheckRequiredTools("python3") {
+			continue
+		}
+		output := suite.commandHandler.RunCommand(script, suite.tempDir, language, context)
+		suite.Assertions.Equal(expected[language], output, "Output of the %s script should equal.", language)
+	}
 
-	// Check if the file is not existing anymore
-	suite.Assertions.NoFileExists(path, "Script file should be deleted.")
+	// The stderr of failing commands is reported, but not returned as output
+	_, err := suite.commandHandler.execute(exec.Command("bash", "-c", "echo broken >&2; exit 3"), suite.tempDir, context)
+	suite.Assertions.EqualError(err, "exit status 3, stderr: broken", "Error should contain stderr.")
+	suite.Assertions.Empty(suite.commandHandler.RunCommand("echo broken >&2; exit 3", suite.tempDir, "bash", context),
+		"Failing script should have no output.")
+	suite.Assertions.Equal("..."+strings.Repeat("b", maxStderrLength), stderrDiagnostics(strings.Repeat("a", 10)+
+		strings.Repeat("b", maxStderrLength)+"\n"), "Long stderr should be shortened.")
+	suite.Assertions.Equal("..."+strings.Repeat("ü", maxStderrLength), stderrDiagnostics(strings.Repeat("ö", 10)+
+		strings.Repeat("ü", maxStderrLength)), "Multibyte stderr should be shortened by characters.")
 }
 
 // TestWriteScriptFileWithCode test if a script file with code get created correctly
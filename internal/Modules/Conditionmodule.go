@@ -0,0 +1,152 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// whenDateFormat is the format of the commit dates of the when conditions
+const whenDateFormat = "2006-01-02"
+
+// The commitFacts struct contains the files and languages of a checked out commit
+type commitFacts struct {
+	// Paths of all files relative to the repository, separated by slashes
+	files []string
+	// Set of the lower case languages detected from the files
+	languages map[string]bool
+}
+
+// matchesRepository checks the conditions of the template, which depend on the repository of the task.
+// The returned template contains only the conditions left to check per commit, if the repository matches.
+// The language condition is met if it contains the language of the task, otherwise it is checked per commit.
+// The stars and size of tasks without crawl data are unknown (0), their conditions are not checked.
+func matchesRepository(template Analyzer.Template, task Analyzer.Task) (Analyzer.Template, bool) {
+	when := template.When
+	if task.Stars > 0 && !inBounds(task.Stars, when.MinStars, when.MaxStars) {
+		return template, false
+	}
+	if task.Size > 0 && !inBounds(task.Size, when.MinSize, when.MaxSize) {
+		return template, false
+	}
+	for column, value := range when.Metadata {
+		if !strings.EqualFold(task.Metadata[column], value) {
+			return template, false
+		}
+	}
+	if task.Language != "" && containsFold(when.Languages, task.Language) {
+		template.When.Languages = nil
+	}
+	return template, true
+}
+
+// inBounds returns true if the value is inside the bounds, bounds of 0 are not set
+func inBounds(value, min, max int) bool {
+	return (min <= 0 || value >= min) && (max <= 0 || value <= max)
+}
+
+// matchesCommit checks the file and language conditions of the template with the facts of the checked out commit
+func matchesCommit(when Analyzer.When, facts commitFacts) bool {
+	if len(when.Files) > 0 && !matchesAnyFile(when.Files, facts.files) {
+		return false
+	}
+	if len(when.Languages) > 0 {
+		for _, language := range when.Languages {
+			if facts.languages[strings.ToLower(language)] {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// matchesCommitDate returns true if the commit time is inside the date range of the conditions
+func matchesCommitDate(when Analyzer.When, commitTime time.Time) bool {
+	date := commitTime.UTC().Format(whenDateFormat)
+	if when.CommitAfter != "" && date < when.CommitAfter {
+		return false
+	}
+	if when.CommitBefore != "" && date >= when.CommitBefore {
+		return false
+	}
+	return true
+}
+
+// needsCommitFacts returns true if the conditions depend on the files of the commit
+func needsCommitFacts(when Analyzer.When) bool {
+	return len(when.Files) > 0 || len(when.Languages) > 0
+}
+
+// collectCommitFacts collects the files and languages of the checkout from the files of its walk.
+// Vendored directories are skipped, so dependencies don't count as files of the repository.
+func collectCommitFacts(rootDir string, files []Analyzer.CheckoutFile) commitFacts {
+	facts := commitFacts{languages: make(map[string]bool)}
+	for _, file := range files {
+		if file.Info.IsDir() {
+			continue
+		}
+		relativePath, err := filepath.Rel(rootDir, file.Path)
+		if err != nil || isVendoredPath(relativePath) {
+			continue
+		}
+		facts.files = append(facts.files, filepath.ToSlash(relativePath))
+		if file.Language != "" {
+			facts.languages[strings.ToLower(file.Language)] = true
+		}
+	}
+	return facts
+}
+
+// matchesAnyFile returns true if one of the files matches one of the patterns.
+// Patterns without a slash are matched against the file name, all other patterns against the whole path.
+func matchesAnyFile(patterns, files []string) bool {
+	for _, file := range files {
+		for _, pattern := range patterns {
+			name := file
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(file)
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsFold returns true if the slice contains the value, ignoring the case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWhen checks the patterns, bounds and dates of the conditions of a template, the problems are reported at
+// the given fields of the when clause
+func validateWhen(when Analyzer.When, report func(message string, field ...interface{})) {
+	for i, pattern := range when.Files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			report(fmt.Sprintf("when: invalid files pattern %q", pattern), "when", "files", i)
+		}
+	}
+	if when.MinStars > 0 && when.MaxStars > 0 && when.MinStars > when.MaxStars {
+		report("when: min_stars is larger than max_stars", "when", "min_stars")
+	}
+	if when.MinSize > 0 && when.MaxSize > 0 && when.MinSize > when.MaxSize {
+		report("when: min_size is larger than max_size", "when", "min_size")
+	}
+	dates := [][2]string{{"commit_after", when.CommitAfter}, {"commit_before", when.CommitBefore}}
+	for _, date := range dates {
+		if _, err := time.Parse(whenDateFormat, date[1]); date[1] != "" && err != nil {
+			report(fmt.Sprintf("when: invalid %s date %q (format: 2006-01-02)", date[0], date[1]), "when", date[0])
+		}
+	}
+}
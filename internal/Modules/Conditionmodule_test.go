@@ -0,0 +1,145 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Create test suite for the condition module
+type ConditionModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *ConditionModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+
+	// Create a repository with a JavaScript project, a vendored dependency and git objects
+	files := []string{"package-lock.json", "src/index.js", "infra/main.tf", "node_modules/lib/setup.py",
+		".git/config.py"}
+	for _, name := range files {
+		path := filepath.Join(suite.tempDir, name)
+		suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+		suite.Assertions.NoError(os.WriteFile(path, []byte("content"), 0644), "Creating the file should not fail.")
+	}
+}
+
+// TestMatchesRepository checks the conditions depending on the repository of the task
+func (suite *ConditionModuleTestSuite) TestMatchesRepository() {
+	task := Analyzer.Task{URL: "https://github.com/gitanalyzer/test", Language: "Go", Stars: 150, Size: 2048,
+		Metadata: Analyzer.Metadata{"team": "Payments"}}
+	tests := []struct {
+		when    Analyzer.When
+		matches bool
+	}{
+		{Analyzer.When{}, true},
+		{Analyzer.When{MinStars: 100, MaxStars: 200}, true},
+		{Analyzer.When{MinStars: 200}, false},
+		{Analyzer.When{MaxStars: 100}, false},
+		{Analyzer.When{MinSize: 4096}, false},
+		{Analyzer.When{MaxSize: 4096}, true},
+		{Analyzer.When{Metadata: map[string]string{"team": "payments"}}, true},
+		{Analyzer.When{Metadata: map[string]string{"team": "platform"}}, false},
+		{Analyzer.When{Metadata: map[string]string{"owner": "alice"}}, false},
+	}
+	for _, test := range tests {
+		_, matches := matchesRepository(Analyzer.Template{When: test.when}, task)
+		suite.Assertions.Equal(test.matches, matches, "Match of %+v should equal.", test.when)
+	}
+
+	// Tasks without crawl data have unknown stars and size, their conditions are not checked
+	unknown := Analyzer.Task{URL: "https://github.com/gitanalyzer/test"}
+	_, matches := matchesRepository(Analyzer.Template{When: Analyzer.When{MinStars: 100, MinSize: 4096}}, unknown)
+	suite.Assertions.True(matches, "Unknown stars and size should not skip the template.")
+
+	// The language of the task meets the language condition, other languages are checked per commit
+	template, _ := matchesRepository(Analyzer.Template{When: Analyzer.When{Languages: []string{"go"}}}, task)
+	suite.Assertions.Empty(template.When.Languages, "Language of the task should meet the condition.")
+	template, _ = matchesRepository(Analyzer.Template{When: Analyzer.When{Languages: []string{"Python"}}}, task)
+	suite.Assertions.Equal([]string{"Python"}, template.When.Languages, "Language should be checked per commit.")
+}
+
+// TestMatchesCommit checks the file and language conditions with the files of a checkout
+func (suite *ConditionModuleTestSuite) TestMatchesCommit() {
+	facts := collectCommitFacts(suite.tempDir, walkCheckout(suite.tempDir))
+	suite.Assertions.ElementsMatch([]string{"package-lock.json", "src/index.js", "infra/main.tf"}, facts.files,
+		"Git and vendored files should be skipped.")
+	suite.Assertions.Equal(map[string]bool{"javascript": true, "hcl": true}, facts.languages,
+		"Languages should be detected.")
+
+	tests := []struct {
+		when    Analyzer.When
+		matches bool
+	}{
+		{Analyzer.When{}, true},
+		{Analyzer.When{Files: []string{"package-lock.json"}}, true},
+		{Analyzer.When{Files: []string{"requirements.txt", "*.tf"}}, true},
+		{Analyzer.When{Files: []string{"src/*.js"}}, true},
+		{Analyzer.When{Files: []string{"lib/*.js"}}, false},
+		{Analyzer.When{Files: []string{"setup.py"}}, false},
+		{Analyzer.When{Languages: []string{"JavaScript"}}, true},
+		{Analyzer.When{Languages: []string{"Python"}}, false},
+		{Analyzer.When{Files: []string{"package-lock.json"}, Languages: []string{"Python"}}, false},
+	}
+	for _, test := range tests {
+		suite.Assertions.Equal(test.matches, matchesCommit(test.when, facts), "Match of %+v should equal.", test.when)
+	}
+}
+
+// TestMatchesCommitDate checks the commit date range of the conditions
+func (suite *ConditionModuleTestSuite) TestMatchesCommitDate() {
+	when := Analyzer.When{CommitAfter: "2023-01-01", CommitBefore: "2024-01-01"}
+	for date, matches := range map[string]bool{"2022-12-31": false, "2023-01-01": true, "2023-12-31": true,
+		"2024-01-01": false} {
+		commitTime, _ := time.Parse("2006-01-02", date)
+		suite.Assertions.Equal(matches, matchesCommitDate(when, commitTime.Add(12*time.Hour)),
+			"Match of %s should equal.", date)
+	}
+	suite.Assertions.True(matchesCommitDate(Analyzer.When{}, time.Now()), "Commits should match without dates.")
+}
+
+// TestFilterTemplatesForCommit checks that only templates matching the checkout are run
+func (suite *ConditionModuleTestSuite) TestFilterTemplatesForCommit() {
+	th := NewTemplateHandlerWithMocks(nil, &FileHandler{}, &CommandHandler{}, Analyzer.Config{})
+	templates := []Analyzer.Template{
+		{Name: "npm", When: Analyzer.When{Files: []string{"package-lock.json"}}},
+		{Name: "pip", When: Analyzer.When{Files: []string{"requirements.txt"}}},
+		{Name: "misc"},
+	}
+	suite.Assertions.Equal([]Analyzer.Template{templates[0], templates[2]},
+		th.filterTemplatesForCommit(templates, suite.tempDir, walkCheckout(suite.tempDir)),
+		"Templates should be filtered.")
+}
+
+// TestValidateWhen checks that invalid conditions are reported at their lines
+func (suite *ConditionModuleTestSuite) TestValidateWhen() {
+	path := filepath.Join(suite.tempDir, "templates", "when.yaml")
+	suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+	suite.Assertions.NoError(os.WriteFile(path, []byte(`name: "When"
+script:
+  code: "ls"
+when:
+  files: ["[a-"]
+  min_stars: 100
+  max_stars: 10
+  commit_after: "01.01.2023"
+`), 0644), "Writing the template should not fail.")
+
+	_, problems := ValidateTemplates(filepath.Dir(path))
+	suite.Assertions.Equal([]TemplateError{
+		{File: path, Line: 5, Message: `when: invalid files pattern "[a-"`},
+		{File: path, Line: 6, Message: "when: min_stars is larger than max_stars"},
+		{File: path, Line: 8, Message: `when: invalid commit_after date "01.01.2023" (format: 2006-01-02)`},
+	}, problems, "Problems should equal.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestConditionModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(ConditionModuleTestSuite))
+}
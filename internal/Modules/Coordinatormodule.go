@@ -0,0 +1,445 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// coordinatorShutdownDelay is the time the coordinator keeps answering requests after all tasks
+// are done, so the workers get notified that the scan is finished.
+const coordinatorShutdownDelay = 10 * time.Second
+
+// queuedTask is a task waiting inside the queue of the coordinator
+type queuedTask struct {
+	// The task to scan
+	task Analyzer.Task
+	// The number of times the task was already leased
+	attempts int
+}
+
+// taskLease is a task currently leased by a worker
+type taskLease struct {
+	// The leased task
+	queuedTask
+	// The time the lease expires if it is not renewed
+	expires time.Time
+}
+
+// The Coordinator struct owns the task list of a distributed scan.
+// Workers lease tasks from the coordinator and send the results back,
+// which are then written into the results directory.
+type Coordinator struct {
+	// The used Analyzer.CoordinatorConfig containing the settings of the current scan
+	config Analyzer.CoordinatorConfig
+	// Sink to persist the results sent by the workers
+	resultSink IResultSink
+	// mutex protects the queue, the leases and the stats
+	mutex sync.Mutex
+	// Tasks which are waiting to be leased
+	queue []queuedTask
+	// Map leaseID => leased task
+	leases map[string]*taskLease
+	// Map leaseID => task of the expired leases, the results of a slow worker are still accepted.
+	// The leases are removed once their task is finished, only the last attempt of a failed task is kept.
+	expired map[string]queuedTask
+	// Set of the URLs of the finished tasks
+	completed map[string]bool
+	// The number of total tasks to scan
+	numberOfTasks int32
+	// The number of finished tasks
+	finishedScans int32
+	// The number of failed tasks
+	failedScans int32
+	// The number of tasks with results
+	resultsFound int32
+	// done is closed once all tasks are finished or failed
+	done chan struct{}
+}
+
+// NewCoordinator is the constructor to create a new Coordinator for the given tasks.
+// The provided opened IResultSink is used to persist the results.
+func NewCoordinator(config Analyzer.CoordinatorConfig, resultSink IResultSink, tasks []Analyzer.Task) *Coordinator {
+	// Every task must be leased at least once
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	coordinator := &Coordinator{config: config, resultSink: resultSink,
+		leases: make(map[string]*taskLease), expired: make(map[string]queuedTask), completed: make(map[string]bool),
+		numberOfTasks: int32(len(tasks)), done: make(chan struct{})}
+	// Queue all tasks
+	for _, task := range tasks {
+		task.State = "queued"
+		coordinator.queue = append(coordinator.queue, queuedTask{task: task})
+	}
+	// Close done channel if there is nothing to do
+	coordinator.checkDone()
+	return coordinator
+}
+
+// Done returns a channel which is closed once all tasks are finished or failed
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// Stats returns the current stats of the scan
+func (c *Coordinator) Stats() Analyzer.MonitorStat {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return Analyzer.MonitorStat{NumberOfTasks: c.numberOfTasks, QueuedScans: int32(len(c.queue)),
+		RunningScans: int32(len(c.leases)), FinishedScans: c.finishedScans, FailedScans: c.failedScans,
+		ResultsFound: c.resultsFound}
+}
+
+// Handler returns the http.Handler providing the endpoints used by the workers
+func (c *Coordinator) Handler() http.Handler {
+	e := echo.New()
+	e.HideBanner = true
+
+	// Lease the next queued task
+	e.POST("/lease", func(ctx echo.Context) error {
+		lease, finished := c.lease(ctx.QueryParam("worker"))
+		if finished {
+			// All tasks are done, the worker can shut down
+			return ctx.NoContent(http.StatusGone)
+		}
+		if lease == nil {
+			// No task is queued at the moment, but some may be queued again later
+			return ctx.NoContent(http.StatusNoContent)
+		}
+		return ctx.JSON(http.StatusOK, lease)
+	})
+
+	// Renew a lease
+	e.POST("/heartbeat", func(ctx echo.Context) error {
+		var update Analyzer.LeaseUpdate
+		if err := ctx.Bind(&update); err != nil {
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		if !c.renew(update.ID) {
+			// The lease expired and the task was queued again
+			return ctx.NoContent(http.StatusConflict)
+		}
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	// Complete a lease with the state and results of the task
+	e.POST("/complete", func(ctx echo.Context) error {
+		var update Analyzer.LeaseUpdate
+		if err := ctx.Bind(&update); err != nil {
+			return ctx.NoContent(http.StatusBadRequest)
+		}
+		if !c.complete(update.ID, update.Task) {
+			// The lease expired and the task was queued again
+			return ctx.NoContent(http.StatusConflict)
+		}
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	// Current stats of the scan
+	e.GET("/status", func(ctx echo.Context) error {
+		return ctx.JSON(http.StatusOK, c.Stats())
+	})
+
+	return e
+}
+
+// lease removes the next task from the queue and returns a new lease for it.
+// If no task is queued, nil is returned. finished is true if all tasks are done.
+func (c *Coordinator) lease(worker string) (lease *Analyzer.Lease, finished bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Queue tasks of dead workers again
+	c.expireLeases(time.Now())
+
+	if c.isDone() {
+		return nil, true
+	}
+	if len(c.queue) == 0 {
+		return nil, false
+	}
+
+	// Take the first task of the queue
+	next := c.queue[0]
+	c.queue = c.queue[1:]
+	next.attempts++
+	next.task.State = "leased"
+
+	// Create the lease
+	id := newLeaseID()
+	c.leases[id] = &taskLease{queuedTask: next, expires: time.Now().Add(c.config.LeaseTimeout)}
+	if c.config.Verbose {
+		fmt.Println("Leased:", next.task.URL, "to worker:", worker)
+	}
+
+	return &Analyzer.Lease{ID: id, Task: next.task, TimeoutSeconds: int(c.config.LeaseTimeout.Seconds())}, false
+}
+
+// renew extends the lease with the given ID.
+// Returns false if the lease is unknown e.g. because it already expired.
+func (c *Coordinator) renew(id string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Don't accept leases which already expired
+	c.expireLeases(time.Now())
+
+	lease, found := c.leases[id]
+	if !found {
+		return false
+	}
+	lease.expires = time.Now().Add(c.config.LeaseTimeout)
+	return true
+}
+
+// complete ends the lease with the given ID and persists the results of the provided task.
+// The results of an expired lease are still accepted, as long as the task isn't finished by another worker.
+// Returns false if the lease is unknown or the task of the expired lease is already finished.
+func (c *Coordinator) complete(id string, task Analyzer.Task) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Expire the outdated leases, so the expired lease is found below
+	c.expireLeases(time.Now())
+
+	var lease queuedTask
+	if active, found := c.leases[id]; found {
+		lease = active.queuedTask
+		delete(c.leases, id)
+	} else if expired, found := c.expired[id]; found && task.State == "finished" && c.takeBack(expired.task.URL) {
+		// Keep the work of the slow worker instead of scanning the repository again
+		lease = expired
+	} else {
+		return false
+	}
+
+	switch task.State {
+	case "finished":
+		//write result and mark the task as checked
+		if err := c.resultSink.Write(task); err != nil {
+			fmt.Println("Error writing results:", err)
+		}
+		if task.Results != nil {
+			c.resultsFound++
+		}
+		c.finishedScans++
+		c.completed[task.URL] = true
+		// The results of the other expired leases of the task are rejected
+		c.pruneExpired(task.URL)
+	default:
+		// Queue the task again, as another worker may be able to clone and scan the repository
+		c.requeueOrFail(lease)
+	}
+	if c.config.Verbose {
+		fmt.Println("Completed:", task.URL, "with state:", task.State)
+	}
+
+	c.checkDone()
+	return true
+}
+
+// takeBack removes the task with the given URL from the queue, its current lease or the failed tasks and removes its
+// expired leases, so the results of an expired lease can be accepted. Returns false if the task is already finished.
+// The caller must hold the mutex.
+func (c *Coordinator) takeBack(url string) bool {
+	if c.completed[url] {
+		return false
+	}
+	c.pruneExpired(url)
+	for i, queued := range c.queue {
+		if queued.task.URL == url {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return true
+		}
+	}
+	for id, lease := range c.leases {
+		if lease.task.URL == url {
+			// The results of the current lease are rejected
+			delete(c.leases, id)
+			return true
+		}
+	}
+	// The task failed after its last attempt expired
+	c.failedScans--
+	return true
+}
+
+// pruneExpired removes the expired leases of the task with the given URL, their results are no longer accepted.
+// The caller must hold the mutex.
+func (c *Coordinator) pruneExpired(url string) {
+	for id, expired := range c.expired {
+		if expired.task.URL == url {
+			delete(c.expired, id)
+		}
+	}
+}
+
+// expireLeases queues the tasks of all leases which expired before the given time again.
+// The caller must hold the mutex.
+func (c *Coordinator) expireLeases(now time.Time) {
+	for id, lease := range c.leases {
+		if lease.expires.After(now) {
+			continue
+		}
+		delete(c.leases, id)
+		fmt.Println("Lease expired:", lease.task.URL)
+		c.requeueOrFail(lease.queuedTask)
+		// Added after a failed task was pruned, so the results of its last attempt are still accepted
+		c.expired[id] = lease.queuedTask
+	}
+	c.checkDone()
+}
+
+// requeueOrFail adds the given task to the queue again,
+// or marks it as failed if the maximum number of attempts is reached and removes its expired leases.
+// The caller must hold the mutex.
+func (c *Coordinator) requeueOrFail(task queuedTask) {
+	if task.attempts >= c.config.MaxAttempts {
+		c.failedScans++
+		c.pruneExpired(task.task.URL)
+		return
+	}
+	task.task.State = "queued"
+	c.queue = append(c.queue, task)
+}
+
+// isDone checks if all tasks are finished or failed.
+// The caller must hold the mutex.
+func (c *Coordinator) isDone() bool {
+	return c.numberOfTasks == c.finishedScans+c.failedScans
+}
+
+// checkDone closes the done channel once all tasks are finished or failed.
+// The caller must hold the mutex.
+func (c *Coordinator) checkDone() {
+	if !c.isDone() {
+		return
+	}
+	select {
+	case <-c.done:
+		// Already closed
+	default:
+		close(c.done)
+	}
+}
+
+// watchLeases checks every second for expired leases until all tasks are done.
+// The buffered results are written every resultSinkFlushInterval.
+func (c *Coordinator) watchLeases() {
+	lastFlush := time.Now()
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-time.After(time.Second):
+			c.mutex.Lock()
+			c.expireLeases(now)
+			if now.Sub(lastFlush) >= resultSinkFlushInterval {
+				if err := c.resultSink.Flush(); err != nil {
+					fmt.Println("Error flushing results:", err)
+				}
+				lastFlush = now
+			}
+			c.mutex.Unlock()
+		}
+	}
+}
+
+// closeResultSink writes the remaining results and closes the sink of the coordinator
+func (c *Coordinator) closeResultSink() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.resultSink.Close()
+}
+
+// newLeaseID generates a random ID for a lease
+func newLeaseID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Fatalln("Error generating lease id:", err)
+	}
+	return hex.EncodeToString(bytes)
+}
+
+// RunCoordinator is the main function to start the coordinator of a distributed scan.
+// The coordinator loads the tasks, hands them out to the workers and writes the results.
+func RunCoordinator(config Analyzer.CoordinatorConfig) {
+	// Initialize the fileHelper for the results directory
+	fileHelper := &FileHandler{Analyzer.Config{ResultsDir: config.ResultsDir, Verbose: config.Verbose}}
+	fileHelper.PrepareResultsFolder(config.ResultsDir)
+
+	// Load tasks (urls.csv)
+	fmt.Println("Loading tasks...")
+	tasks := fileHelper.GetTasks(config.UrlFilePath, fileHelper.GetResultCSVPath("checked"))
+	fmt.Println("Tasks loaded:", strconv.Itoa(len(tasks)))
+
+	// Load the templates to post process the results, to record their versions and to redact their results
+	templateHandler := &TemplateHandler{Config: fileHelper.Config, FileHelper: fileHelper}
+	if config.TemplatesPath != "" {
+		templateHandler.LoadTemplates(config.TemplatesPath)
+	}
+
+	// Open the sinks for the results, the results are redacted before they are written
+	sinks, err := NewResultSink(config.ResultSinks, config.ResultsDir, fileHelper)
+	if err != nil {
+		log.Fatalln("Error creating result sinks:", err)
+	}
+	redactor, err := NewTemplateRedactor(templateHandler.templates, config.ResultsDir)
+	if err != nil {
+		log.Fatalln("Error loading redaction policies:", err)
+	}
+	resultSink := NewRedactingResultSink(sinks, redactor)
+
+	// Start a new scan session, its ID is stored with every result
+	session, err := StartScanSession(config.ResultsDir, config, templateHandler.templates)
+	if err != nil {
+		log.Fatalln("Error starting scan session:", err)
+	}
+	fmt.Println("Scan session:", strconv.Itoa(session.ID))
+	if err = resultSink.Open(session); err != nil {
+		log.Fatalln("Error opening result sinks:", err)
+	}
+
+	// Start the coordinator
+	coordinator := NewCoordinator(config, resultSink, tasks)
+	go coordinator.watchLeases()
+
+	// Start the server for the workers
+	e := coordinator.Handler().(*echo.Echo)
+	go func() {
+		if err := e.Start(config.ListenAddress); err != nil && err != http.ErrServerClosed {
+			log.Fatalln("Error starting coordinator:", err)
+		}
+	}()
+
+	// Wait until all tasks are done
+	<-coordinator.Done()
+	fmt.Println("All tasks done, waiting for workers to shut down...")
+	time.Sleep(coordinatorShutdownDelay)
+	if err := e.Shutdown(context.Background()); err != nil {
+		fmt.Println("Error shutting down coordinator:", err)
+	}
+
+	// Write the remaining results before they are post processed
+	if err = coordinator.closeResultSink(); err != nil {
+		fmt.Println("Error closing result sinks:", err)
+	}
+	if err = FinishScanSession(config.ResultsDir, session); err != nil {
+		fmt.Println("Error finishing scan session:", err)
+	}
+
+	// Run post process script at the end
+	templateHandler.postProcess()
+
+	fmt.Println("Finished")
+}
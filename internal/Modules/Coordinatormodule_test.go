@@ -0,0 +1,162 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Create test suite for the coordinator module
+type CoordinatorModuleTestSuite struct {
+	suite.Suite
+	tempDir    string
+	fileHelper *FileHandler
+	resultSink *CSVResultSink
+	tasks      []Analyzer.Task
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *CoordinatorModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	// Initialize the fileHelper with the temporary directory as the result dir
+	suite.fileHelper = &FileHandler{Analyzer.Config{ResultsDir: suite.tempDir}}
+	// Create some tasks
+	suite.tasks = nil
+	for i := 0; i < 10; i++ {
+		suite.tasks = append(suite.tasks, Analyzer.Task{URL: "https://github.com/gitanalyzer/test" + strconv.Itoa(i)})
+	}
+}
+
+// startCoordinator creates a coordinator for the tasks of the suite and serves it on localhost
+func (suite *CoordinatorModuleTestSuite) startCoordinator(leaseTimeout time.Duration, maxAttempts int) (*Coordinator, *CoordinatorClient) {
+	config := Analyzer.CoordinatorConfig{LeaseTimeout: leaseTimeout, MaxAttempts: maxAttempts}
+	suite.resultSink = NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(suite.resultSink.Open(Analyzer.ScanSession{ID: 1}), "Opening the result sink should not fail.")
+	coordinator := NewCoordinator(config, suite.resultSink, suite.tasks)
+	server := httptest.NewServer(coordinator.Handler())
+	suite.T().Cleanup(server.Close)
+	return coordinator, NewCoordinatorClient(server.URL)
+}
+
+// TestSeveralWorkers checks that several workers scan every task exactly once
+func (suite *CoordinatorModuleTestSuite) TestSeveralWorkers() {
+	coordinator, client := suite.startCoordinator(time.Minute, 3)
+
+	// Start some workers which lease and complete tasks until the coordinator is done
+	var mutex sync.Mutex
+	scanned := make(map[string]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+			for {
+				lease, finished, err := client.Lease(worker)
+				suite.Assertions.NoError(err, "Leasing should not fail.")
+				if finished || err != nil {
+					return
+				}
+				if lease == nil {
+					time.Sleep(time.Millisecond * 10)
+					continue
+				}
+				mutex.Lock()
+				scanned[lease.Task.URL]++
+				mutex.Unlock()
+
+				// Send the result back
+				task := lease.Task
+				task.State = "finished"
+				task.Results = []Analyzer.Result{{TemplateName: "test", URL: task.URL, Output: "found"}}
+				suite.Assertions.NoError(client.Complete(lease.ID, task), "Completing should not fail.")
+			}
+		}("worker" + strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	// Check that every task was scanned once
+	suite.Assertions.Len(scanned, len(suite.tasks), "All tasks should be scanned.")
+	for url, count := range scanned {
+		suite.Assertions.Equal(1, count, "Task should be scanned once: "+url)
+	}
+	// Check that the coordinator is done and the results were written
+	suite.Assertions.Equal(int32(10), coordinator.Stats().FinishedScans, "All tasks should be finished.")
+	suite.Assertions.NoError(coordinator.closeResultSink(), "Closing the result sink should not fail.")
+	suite.Assertions.FileExists(suite.fileHelper.GetResultCSVPath("test"), "Result file should exist.")
+	suite.Assertions.Len(suite.fileHelper.UnMarshallResults("test"), len(suite.tasks), "All results should be written.")
+	suite.Assertions.Len(suite.fileHelper.UnMarshallStats(suite.fileHelper.GetResultCSVPath("checked")), len(suite.tasks),
+		"All tasks should be marked as checked.")
+	select {
+	case <-coordinator.Done():
+	default:
+		suite.Fail("Coordinator should be done.")
+	}
+}
+
+// TestLeaseExpiry checks that the task of a dead worker is queued again and failed after the maximum attempts
+func (suite *CoordinatorModuleTestSuite) TestLeaseExpiry() {
+	suite.tasks = suite.tasks[:1]
+	coordinator, client := suite.startCoordinator(time.Millisecond*50, 2)
+
+	// Lease the task and let the lease expire
+	lease, _, err := client.Lease("dead worker")
+	suite.Assertions.NoError(err, "Leasing should not fail.")
+	suite.Assertions.NotNil(lease, "Task should be leased.")
+	time.Sleep(time.Millisecond * 100)
+
+	// Check that the expired lease can't be completed
+	suite.Assertions.ErrorIs(client.Complete(lease.ID, lease.Task), ErrLeaseExpired, "Expired lease should not be completed.")
+
+	// Check that the task is leased again
+	secondLease, _, err := client.Lease("second worker")
+	suite.Assertions.NoError(err, "Leasing should not fail.")
+	suite.Assertions.NotNil(secondLease, "Task should be leased again.")
+	suite.Assertions.Equal(lease.Task.URL, secondLease.Task.URL, "Leased tasks should equal.")
+
+	// Renew the lease
+	suite.Assertions.NoError(client.Heartbeat(secondLease.ID), "Heartbeat should not fail.")
+
+	// Let the second lease expire, the task should fail after two attempts
+	time.Sleep(time.Millisecond * 100)
+	_, finished, err := client.Lease("third worker")
+	suite.Assertions.NoError(err, "Leasing should not fail.")
+	suite.Assertions.True(finished, "Coordinator should be finished.")
+	suite.Assertions.Equal(int32(1), coordinator.Stats().FailedScans, "Task should be failed.")
+	suite.Assertions.Equal(map[string]queuedTask{secondLease.ID: {task: secondLease.Task, attempts: 2}}, coordinator.expired,
+		"Only the expired lease of the last attempt should be kept.")
+	suite.Assertions.NoError(coordinator.closeResultSink(), "Closing the result sink should not fail.")
+	suite.Assertions.NoFileExists(suite.tempDir+string(os.PathSeparator)+"checked.csv", "Failed task should not be checked.")
+}
+
+// TestExpiredLeasesPruned checks that the expired leases of a finished task are removed
+func (suite *CoordinatorModuleTestSuite) TestExpiredLeasesPruned() {
+	suite.tasks = suite.tasks[:1]
+	coordinator, client := suite.startCoordinator(time.Millisecond*50, 3)
+
+	// Let the lease of the first worker expire, it never comes back
+	_, _, err := client.Lease("dead worker")
+	suite.Assertions.NoError(err, "Leasing should not fail.")
+	time.Sleep(time.Millisecond * 100)
+	lease, _, err := client.Lease("second worker")
+	suite.Assertions.NoError(err, "Leasing should not fail.")
+	suite.Assertions.NotNil(lease, "Task should be leased again.")
+	suite.Assertions.Len(coordinator.expired, 1, "Expired lease should be kept until the task is done.")
+
+	task := lease.Task
+	task.State = "finished"
+	suite.Assertions.NoError(client.Complete(lease.ID, task), "Completing should not fail.")
+	suite.Assertions.Equal(int32(1), coordinator.Stats().FinishedScans, "Task should be finished.")
+	suite.Assertions.Empty(coordinator.expired, "Expired lease of the finished task should be removed.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestCoordinatorModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(CoordinatorModuleTestSuite))
+}
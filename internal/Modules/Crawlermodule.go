@@ -12,6 +12,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -20,6 +21,22 @@ import "github.com/google/go-github/v50/github"
 
 // loadConfig loads the configuration from the local "config.env" file
 func loadConfig() Analyzer.CrawlConfig {
+	// Load the database settings
+	crawlConfig := loadDBConfig()
+	// Get the values
+	gitHubToken := viper.GetString("GITHUB_API_KEY")
+	if gitHubToken == "" {
+		log.Fatalln("GITHUB_API_KEY not set in config.env!")
+	}
+	crawlConfig.GitHubAPIToken = gitHubToken
+
+	// Return new CrawlConfig with loaded settings
+	return crawlConfig
+}
+
+// loadDBConfig loads the database settings from the local "config.env" file.
+// The GitHub API token is not needed to access the database.
+func loadDBConfig() Analyzer.CrawlConfig {
 	// Read the config.env file
 	viper.SetConfigFile("config.env")
 	err := viper.ReadInConfig()
@@ -27,10 +44,6 @@ func loadConfig() Analyzer.CrawlConfig {
 		log.Fatalln("error reading config.env:", err)
 	}
 	// Get the values
-	gitHubToken := viper.GetString("GITHUB_API_KEY")
-	if gitHubToken == "" {
-		log.Fatalln("GITHUB_API_KEY not set in config.env!")
-	}
 	dbUser := viper.GetString("DB_USER")
 	if dbUser == "" {
 		log.Fatalln("DB_USER not set in config.env!")
@@ -49,7 +62,7 @@ func loadConfig() Analyzer.CrawlConfig {
 	}
 
 	// Return new CrawlConfig with loaded settings
-	return Analyzer.CrawlConfig{GitHubAPIToken: gitHubToken, DBUser: dbUser, DBPassword: dbPassword, DBIP: dbIP, DBPort: dbPort}
+	return Analyzer.CrawlConfig{DBUser: dbUser, DBPassword: dbPassword, DBIP: dbIP, DBPort: dbPort}
 }
 
 // Crawl starts the process of crawling all public repositories of GitHub
@@ -311,6 +324,95 @@ func loadMaxId(db *sql.DB) int {
 	return id
 }
 
+// LoadTasksFromDB selects the repositories matching the provided filter from the database of the crawler
+// and returns them as tasks. The credentials of the database are loaded from the local "config.env" file.
+func LoadTasksFromDB(filter Analyzer.RepoFilter) []Analyzer.Task {
+	// Load config
+	crawlConfig := loadDBConfig()
+	// Open database connection
+	db := openDB(crawlConfig.DBUser, crawlConfig.DBPassword, crawlConfig.DBIP, crawlConfig.DBPort)
+	if db == nil {
+		return nil
+	}
+	defer db.Close()
+
+	tasks, err := selectTasks(db, filter)
+	if err != nil {
+		log.Fatalln("Error selecting tasks from crawled data:", err.Error())
+		return nil
+	}
+	return tasks
+}
+
+// buildTaskQuery builds the select statement and its arguments to select the repositories
+// matching the provided filter.
+func buildTaskQuery(filter Analyzer.RepoFilter) (string, []interface{}) {
+	query := "SELECT url, language, stars, size, update_date FROM repos WHERE 1 = 1"
+	var args []interface{}
+
+	// Add the languages
+	var languages []string
+	for _, language := range strings.Split(filter.Language, ",") {
+		language = strings.TrimSpace(language)
+		if language != "" {
+			languages = append(languages, strings.ToLower(language))
+		}
+	}
+	if len(languages) > 0 {
+		query += " AND LOWER(language) IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(languages)), ", ") + ")"
+		for _, language := range languages {
+			args = append(args, language)
+		}
+	}
+	// Add the other filters if they are set
+	if filter.MinStars > 0 {
+		query += " AND stars >= ?"
+		args = append(args, filter.MinStars)
+	}
+	if filter.CreatedAfter != "" {
+		query += " AND creation_date >= ?"
+		args = append(args, filter.CreatedAfter)
+	}
+	if filter.MaxSize > 0 {
+		query += " AND size <= ?"
+		args = append(args, filter.MaxSize)
+	}
+	query += " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	return query, args
+}
+
+// selectTasks selects the repositories matching the provided filter from the provided database
+func selectTasks(db *sql.DB, filter Analyzer.RepoFilter) ([]Analyzer.Task, error) {
+	// Build and execute the statement
+	query, args := buildTaskQuery(filter)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Create a task for every repository
+	var tasks []Analyzer.Task
+	for rows.Next() {
+		var task Analyzer.Task
+		if err = rows.Scan(&task.URL, &task.Language, &task.Stars, &task.Size, &task.UpdateDate); err != nil {
+			return nil, err
+		}
+		// Some drivers return the date with a time, only keep the date
+		if len(task.UpdateDate) > len("2006-01-02") {
+			task.UpdateDate = task.UpdateDate[:len("2006-01-02")]
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
 // openDB uses the provided database credentials to open a new connection.
 // If the repos table is not found, it will be created
 func openDB(username, password, ip, port string) *sql.DB {
@@ -0,0 +1,91 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"database/sql"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"testing"
+)
+
+// Create test suite for the crawler module
+type CrawlerModuleTestSuite struct {
+	suite.Suite
+	db *sql.DB
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *CrawlerModuleTestSuite) SetupTest() {
+	// Use an in memory database as stand-in for the database of the crawler
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		log.Fatalln("Error opening test database:", err)
+	}
+	db.SetMaxOpenConns(1)
+	suite.db = db
+
+	// Create the repos table with some crawled repositories
+	_, err = db.Exec(`CREATE TABLE repos (url VARCHAR(255) NOT NULL, id INT NOT NULL PRIMARY KEY,
+creation_date DATE NOT NULL, fork_count INT NOT NULL, size INT NOT NULL, stars INT NOT NULL,
+update_date DATE NOT NULL, language VARCHAR(255) NOT NULL, commit_count INT NOT NULL);
+INSERT INTO repos VALUES ('git://github.com/test/java1.git', 1, '2020-01-01', 0, 100, 10, '2021-01-01', 'Java', 5);
+INSERT INTO repos VALUES ('git://github.com/test/java2.git', 2, '2022-01-01', 0, 5000, 100, '2023-01-01', 'Java', 5);
+INSERT INTO repos VALUES ('git://github.com/test/go1.git', 3, '2022-06-01', 0, 200, 1, '2022-07-01', 'Go', 5);
+INSERT INTO repos VALUES ('git://github.com/test/js1.git', 4, '2019-06-01', 0, 300, 50, '2020-07-01', 'JavaScript', 5);`)
+	if err != nil {
+		log.Fatalln("Error creating test repos:", err)
+	}
+}
+
+// TearDownTest is run after every test of the test suite to close the database
+func (suite *CrawlerModuleTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+// selectURLs calls selectTasks with the given filter and returns the URLs of the tasks
+func (suite *CrawlerModuleTestSuite) selectURLs(filter Analyzer.RepoFilter) []string {
+	tasks, err := selectTasks(suite.db, filter)
+	suite.Assertions.NoError(err, "Selecting tasks should not fail.")
+	var urls []string
+	for _, task := range tasks {
+		urls = append(urls, task.URL)
+	}
+	return urls
+}
+
+// TestSelectTasks checks that the repositories are selected by the filters
+func (suite *CrawlerModuleTestSuite) TestSelectTasks() {
+	// Check that all repositories are selected without filters
+	suite.Assertions.Len(suite.selectURLs(Analyzer.RepoFilter{}), 4, "All repositories should be selected.")
+
+	// Check the language filter
+	suite.Assertions.Equal([]string{"git://github.com/test/java1.git", "git://github.com/test/java2.git",
+		"git://github.com/test/go1.git"}, suite.selectURLs(Analyzer.RepoFilter{Language: "java, go"}),
+		"Repositories should be selected by language.")
+
+	// Check the combined filters
+	suite.Assertions.Equal([]string{"git://github.com/test/js1.git"},
+		suite.selectURLs(Analyzer.RepoFilter{MinStars: 20, MaxSize: 1000}), "Repositories should be selected by stars and size.")
+	suite.Assertions.Equal([]string{"git://github.com/test/java2.git", "git://github.com/test/go1.git"},
+		suite.selectURLs(Analyzer.RepoFilter{CreatedAfter: "2022-01-01"}), "Repositories should be selected by creation date.")
+	suite.Assertions.Equal([]string{"git://github.com/test/java1.git"},
+		suite.selectURLs(Analyzer.RepoFilter{Limit: 1}), "Number of repositories should be limited.")
+}
+
+// TestSelectTasks_Metadata checks that the metadata of the repositories is carried into the tasks
+func (suite *CrawlerModuleTestSuite) TestSelectTasks_Metadata() {
+	// Call selectTasks
+	tasks, err := selectTasks(suite.db, Analyzer.RepoFilter{Language: "javascript"})
+	suite.Assertions.NoError(err, "Selecting tasks should not fail.")
+
+	// Check the metadata of the task
+	expectedTasks := []Analyzer.Task{{URL: "git://github.com/test/js1.git", Language: "JavaScript", Stars: 50,
+		Size: 300, UpdateDate: "2020-07-01"}}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestCrawlerModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(CrawlerModuleTestSuite))
+}
@@ -0,0 +1,94 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"math"
+	"strings"
+)
+
+// The types of the regex rules
+const (
+	// RegexTypeRegex is the default type, the matches of the expression are the results
+	RegexTypeRegex = "regex"
+	// RegexTypeEntropy finds high entropy strings assigned to secret-looking keys
+	RegexTypeEntropy = "entropy"
+)
+
+// entropyExpression is the built-in expression of the entropy rules.
+// It matches values of at least 16 characters assigned to keys like password, secret or api_key, the value is group 1.
+const entropyExpression = `(?i)(?:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|auth[_-]?key|private[_-]?key|credential)s?[a-z0-9_.-]*["']?\s*(?::=|=>|=|:)\s*["']?([A-Za-z0-9+/=_-]{16,})`
+
+// defaultEntropy contains the thresholds of the entropy rules, if no thresholds are provided
+var defaultEntropy = Analyzer.Entropy{Hex: 3.0, Base64: 4.0}
+
+// hexCharset and base64Charset contain the characters of the charsets the entropy thresholds are selected by
+const (
+	hexCharset    = "0123456789abcdefABCDEF"
+	base64Charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=-_"
+)
+
+// IsValidRegexType returns true if the given type of a regex rule is supported, empty means the default type
+func IsValidRegexType(regexType string) bool {
+	return regexType == "" || regexType == RegexTypeRegex || regexType == RegexTypeEntropy
+}
+
+// prepareRegex fills the defaults of the entropy rules: the built-in expression, its group and the thresholds
+func prepareRegex(regex Analyzer.Regex) Analyzer.Regex {
+	if regex.Type != RegexTypeEntropy {
+		return regex
+	}
+	if strings.TrimSpace(regex.Expression) == "" {
+		regex.Expression = entropyExpression
+		regex.Group = 1
+	}
+	if regex.Entropy == (Analyzer.Entropy{}) {
+		regex.Entropy = defaultEntropy
+	}
+	return regex
+}
+
+// shannonEntropy returns the Shannon entropy of the characters of the value in bits per character
+func shannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	length := 0
+	for _, char := range value {
+		counts[char]++
+		length++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		probability := float64(count) / float64(length)
+		entropy -= probability * math.Log2(probability)
+	}
+	return entropy
+}
+
+// inCharset returns true if all characters of the value are part of the charset
+func inCharset(value, charset string) bool {
+	for _, char := range value {
+		if !strings.ContainsRune(charset, char) {
+			return false
+		}
+	}
+	return true
+}
+
+// passesEntropy returns true if the match reaches the entropy threshold of its charset.
+// Hex matches use the hex threshold if it is set, otherwise they are checked as base64.
+// Matches without a threshold for their charset are dropped, all matches pass if no threshold is set.
+func passesEntropy(match string, entropy Analyzer.Entropy) bool {
+	if entropy == (Analyzer.Entropy{}) {
+		return true
+	}
+	switch {
+	case entropy.Hex > 0 && inCharset(match, hexCharset):
+		return shannonEntropy(match) >= entropy.Hex
+	case entropy.Base64 > 0 && inCharset(match, base64Charset):
+		return shannonEntropy(match) >= entropy.Base64
+	}
+	return false
+}
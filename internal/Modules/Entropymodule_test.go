@@ -0,0 +1,69 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"regexp"
+	"testing"
+)
+
+// Create test suite for the entropy module
+type EntropyModuleTestSuite struct {
+	suite.Suite
+}
+
+// TestShannonEntropy checks the entropy of values with known distributions
+func (suite *EntropyModuleTestSuite) TestShannonEntropy() {
+	suite.Assertions.Equal(0.0, shannonEntropy(""), "Entropy of an empty value should equal.")
+	suite.Assertions.Equal(0.0, shannonEntropy("aaaa"), "Entropy of a single character should equal.")
+	suite.Assertions.Equal(1.0, shannonEntropy("abab"), "Entropy of two characters should equal.")
+	suite.Assertions.Equal(4.0, shannonEntropy("0123456789abcdef"), "Entropy of all hex characters should equal.")
+}
+
+// TestPassesEntropy checks that the threshold is selected by the charset of the match
+func (suite *EntropyModuleTestSuite) TestPassesEntropy() {
+	entropy := Analyzer.Entropy{Hex: 3.0, Base64: 4.0}
+	suite.Assertions.True(passesEntropy("9f86d081884c7d659a2feaa0c55ad015", entropy), "Random hex should pass.")
+	suite.Assertions.False(passesEntropy("1111111111111111aaaa", entropy), "Repeating hex should not pass.")
+	suite.Assertions.True(passesEntropy("q9X2vL7pR4tZ8wK1mN6bC3yH5jD0sF", entropy), "Random base64 should pass.")
+	suite.Assertions.False(passesEntropy("changeme_password_here", entropy), "Placeholder should not pass.")
+	suite.Assertions.False(passesEntropy("q9X2vL7pR4tZ8wK1 mN6bC3yH5jD0sF!", entropy), "Other charsets should not pass.")
+	suite.Assertions.True(passesEntropy("aaaa", Analyzer.Entropy{}), "All matches should pass without thresholds.")
+
+	// Hex matches are checked as base64 if no hex threshold is set
+	suite.Assertions.False(passesEntropy("9f86d081884c7d659a2feaa0c55ad015", Analyzer.Entropy{Base64: 4.0}),
+		"Hex should be checked with the base64 threshold.")
+}
+
+// TestPrepareRegex checks that entropy rules get the built-in expression and thresholds
+func (suite *EntropyModuleTestSuite) TestPrepareRegex() {
+	regex := prepareRegex(Analyzer.Regex{Type: RegexTypeEntropy})
+	suite.Assertions.Equal(entropyExpression, regex.Expression, "Expression should equal.")
+	suite.Assertions.Equal(1, regex.Group, "Group should equal.")
+	suite.Assertions.Equal(defaultEntropy, regex.Entropy, "Thresholds should equal.")
+
+	// Provided values are kept
+	regex = prepareRegex(Analyzer.Regex{Type: RegexTypeEntropy, Expression: "key=(.*)", Group: 1,
+		Entropy: Analyzer.Entropy{Hex: 3.5}})
+	suite.Assertions.Equal("key=(.*)", regex.Expression, "Expression should equal.")
+	suite.Assertions.Equal(Analyzer.Entropy{Hex: 3.5}, regex.Entropy, "Thresholds should equal.")
+	suite.Assertions.Equal(Analyzer.Regex{Expression: "a"}, prepareRegex(Analyzer.Regex{Expression: "a"}),
+		"Regex rules should not be changed.")
+}
+
+// TestMatchLine checks that the false positives and entropy thresholds are applied to the matches of a line
+func (suite *EntropyModuleTestSuite) TestMatchLine() {
+	regex := prepareRegex(Analyzer.Regex{Type: RegexTypeEntropy, FalsePositives: []string{"EXAMPLE"}})
+	expression := regexp.MustCompile(regex.Expression)
+	suite.Assertions.Equal([]string{"q9X2vL7pR4tZ8wK1mN6bC3yH5jD0sF"},
+		matchLine(expression, regex, `token: "q9X2vL7pR4tZ8wK1mN6bC3yH5jD0sF", secret: "changeme_password_here"`),
+		"Only the high entropy value should match.")
+	suite.Assertions.Empty(matchLine(expression, regex, "aws_secret_access_key: wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"),
+		"False positive should not match.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestEntropyModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(EntropyModuleTestSuite))
+}
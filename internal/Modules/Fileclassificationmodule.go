@@ -0,0 +1,175 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The reasons why files are skipped by the file walker
+const (
+	// SkipBinary is used for files whose content contains NUL bytes
+	SkipBinary = "binary"
+	// SkipOversized is used for files larger than the maximal file size
+	SkipOversized = "oversized"
+	// SkipVendored is used for vendored and generated directories
+	SkipVendored = "vendored"
+)
+
+// DefaultMaxFileSize is the default maximal size of the scanned files in MB
+const DefaultMaxFileSize = 10
+
+// binarySniffLength is the number of bytes at the beginning of a file which are checked for NUL bytes, like git does
+const binarySniffLength = 8000
+
+// defaultExcludeDirs are the names of the vendored and generated directories skipped with the default excludes
+var defaultExcludeDirs = []string{"node_modules", "bower_components", "jspm_packages", "vendor", "third_party",
+	"Pods", "Carthage", ".venv", "venv", "site-packages", "__pycache__", ".tox", ".gradle", "target", "dist", "build",
+	".next", ".nuxt", "coverage"}
+
+// isVendoredPath returns true if one of the directories of the path relative to the repository is a vendored or
+// generated directory of the default excludes
+func isVendoredPath(relativePath string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(relativePath)), "/")
+	for _, dir := range dirs {
+		for _, excluded := range defaultExcludeDirs {
+			if dir == excluded {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// The fileClassifier struct decides which files of a repository are scanned.
+// The .git directory is always skipped, binary, oversized and vendored files depend on the run options and the
+// overrides of the template.
+type fileClassifier struct {
+	// Maximal size of the scanned files in bytes, 0 disables the limit
+	maxFileSize int64
+	// If scanBinary is set, binary files are scanned too
+	scanBinary bool
+	// Names of the skipped vendored and generated directories
+	excludeDirs map[string]bool
+	// Map path => reason of every skipped file or directory
+	skipped map[string]string
+}
+
+// newFileClassifier is the constructor to create a new fileClassifier from the run options and the file overrides
+// of the template
+func newFileClassifier(config Analyzer.Config, template Analyzer.Template) *fileClassifier {
+	classifier := &fileClassifier{maxFileSize: int64(config.MaxFileSize) * 1024 * 1024,
+		scanBinary: template.Files.Binary, excludeDirs: make(map[string]bool), skipped: make(map[string]string)}
+	// The template overrides the maximal file size and the default excludes of the run
+	if template.Files.MaxFileSize > 0 {
+		classifier.maxFileSize = int64(template.Files.MaxFileSize) * 1024 * 1024
+	}
+	defaultExcludes := config.DefaultExcludes
+	if template.Files.DefaultExcludes != nil {
+		defaultExcludes = *template.Files.DefaultExcludes
+	}
+	if defaultExcludes {
+		for _, dir := range defaultExcludeDirs {
+			classifier.excludeDirs[dir] = true
+		}
+	}
+	return classifier
+}
+
+// skipDir returns true if the directory is not walked.
+// The root directory is never skipped, the .git directory always.
+func (fc *fileClassifier) skipDir(rootDir, path string, info os.FileInfo) bool {
+	if path == rootDir {
+		return false
+	}
+	if info.Name() == ".git" {
+		return true
+	}
+	if fc.excludeDirs[info.Name()] {
+		fc.skipped[path] = SkipVendored
+		return true
+	}
+	return false
+}
+
+// skipFile returns true if the file is not scanned because it is too large, binary or inside a vendored directory.
+// The directory walk is shared by all templates, so files of directories skipped by this classifier can still be
+// visited. The isBinary function sniffs the content of the file, it is only called if the file type matters.
+func (fc *fileClassifier) skipFile(rootDir, path string, info os.FileInfo, isBinary func() bool) bool {
+	if fc.inExcludedDir(rootDir, path) {
+		return true
+	}
+	if reason, found := fc.skipped[path]; found {
+		// The file was already classified for another regular expression
+		return reason != ""
+	}
+	reason := ""
+	if fc.maxFileSize > 0 && info.Size() > fc.maxFileSize {
+		reason = SkipOversized
+	} else if !fc.scanBinary && isBinary() {
+		reason = SkipBinary
+	}
+	fc.skipped[path] = reason
+	return reason != ""
+}
+
+// inExcludedDir returns true if one of the directories of the path relative to the root directory is skipped
+func (fc *fileClassifier) inExcludedDir(rootDir, path string) bool {
+	if len(fc.excludeDirs) == 0 {
+		return false
+	}
+	relativePath, err := filepath.Rel(rootDir, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(relativePath), "/") {
+		if fc.excludeDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// skipCounts returns the number of skipped files and directories per reason
+func (fc *fileClassifier) skipCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, reason := range fc.skipped {
+		if reason != "" {
+			counts[reason]++
+		}
+	}
+	return counts
+}
+
+// summary returns the counts of the skipped files as text e.g. "2 binary, 1 vendored", empty if nothing was skipped
+func (fc *fileClassifier) summary() string {
+	counts := fc.skipCounts()
+	var parts []string
+	for _, reason := range []string{SkipBinary, SkipOversized, SkipVendored} {
+		if counts[reason] > 0 {
+			parts = append(parts, strconv.Itoa(counts[reason])+" "+reason)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isBinaryFile returns true if the beginning of the file contains a NUL byte.
+// Files which can't be read are not classified as binary, so the error is reported when they are scanned.
+func isBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	buffer := make([]byte, binarySniffLength)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	return bytes.IndexByte(buffer[:n], 0) >= 0
+}
@@ -0,0 +1,132 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create test suite for the file classification module
+type FileClassificationModuleTestSuite struct {
+	suite.Suite
+	tempDir     string
+	fileHandler FileHandler
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *FileClassificationModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+	suite.fileHandler.Config = Analyzer.Config{ResultsDir: suite.tempDir, MaxFileSize: 1}
+
+	// Create a repository with source files, git objects, a vendored dependency, a binary and a large file
+	files := map[string]string{
+		"config.env":                  "API_KEY: S3cr3tK3y\n",
+		".git/config.env":             "API_KEY: S3cr3tK3y\n",
+		"node_modules/lib/config.env": "API_KEY: S3cr3tK3y\n",
+		"image.env":                   "\x89PNG\r\n\x1a\n\x00\x00API_KEY: S3cr3tK3y\n",
+		"large.env":                   "API_KEY: S3cr3tK3y\n" + strings.Repeat("x", 2*1024*1024),
+	}
+	for name, content := range files {
+		path := filepath.Join(suite.tempDir, name)
+		suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+		suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Creating the file should not fail.")
+	}
+}
+
+// getFilePaths returns the names of the .env files which are scanned with the given template
+func (suite *FileClassificationModuleTestSuite) getFilePaths(template Analyzer.Template) ([]string, *fileClassifier) {
+	classifier := newFileClassifier(suite.fileHandler.Config, template)
+	paths, _ := suite.fileHandler.getFilePaths(suite.tempDir, nil, []string{".env"}, nil, classifier)
+	var names []string
+	for _, path := range paths {
+		name, _ := filepath.Rel(suite.tempDir, path)
+		names = append(names, filepath.ToSlash(name))
+	}
+	return names, classifier
+}
+
+// TestDefaultClassification checks that the .git directory, binary and oversized files are skipped
+func (suite *FileClassificationModuleTestSuite) TestDefaultClassification() {
+	names, classifier := suite.getFilePaths(Analyzer.Template{})
+	suite.Assertions.Equal([]string{"config.env", "node_modules/lib/config.env"}, names, "Scanned files should equal.")
+	suite.Assertions.Equal(map[string]int{SkipBinary: 1, SkipOversized: 1}, classifier.skipCounts(),
+		"Skipped files should equal.")
+	suite.Assertions.Equal("1 binary, 1 oversized", classifier.summary(), "Summary should equal.")
+}
+
+// TestDefaultExcludes checks that vendored directories are only skipped if the default excludes are enabled
+func (suite *FileClassificationModuleTestSuite) TestDefaultExcludes() {
+	suite.fileHandler.Config.DefaultExcludes = true
+	names, classifier := suite.getFilePaths(Analyzer.Template{})
+	suite.Assertions.Equal([]string{"config.env"}, names, "Vendored files should be skipped.")
+	suite.Assertions.Equal(1, classifier.skipCounts()[SkipVendored], "Vendored directory should be counted.")
+
+	// The template can disable the default excludes of the run
+	disabled := false
+	names, _ = suite.getFilePaths(Analyzer.Template{Files: Analyzer.Files{DefaultExcludes: &disabled}})
+	suite.Assertions.Equal([]string{"config.env", "node_modules/lib/config.env"}, names,
+		"Template should override the default excludes.")
+}
+
+// TestTemplateOverrides checks that templates can scan binary and larger files
+func (suite *FileClassificationModuleTestSuite) TestTemplateOverrides() {
+	names, classifier := suite.getFilePaths(Analyzer.Template{Files: Analyzer.Files{MaxFileSize: 5, Binary: true}})
+	suite.Assertions.Equal([]string{"config.env", "image.env", "large.env", "node_modules/lib/config.env"}, names,
+		"Binary and large files should be scanned.")
+	suite.Assertions.Empty(classifier.summary(), "No file should be skipped.")
+
+	// Without limit all sizes are scanned
+	suite.fileHandler.Config.MaxFileSize = 0
+	names, _ = suite.getFilePaths(Analyzer.Template{})
+	suite.Assertions.Contains(names, "large.env", "Large file should be scanned without limit.")
+}
+
+// TestIsBinaryFile checks the content sniffing of binary files
+func (suite *FileClassificationModuleTestSuite) TestIsBinaryFile() {
+	suite.Assertions.True(isBinaryFile(filepath.Join(suite.tempDir, "image.env")), "Image should be binary.")
+	suite.Assertions.False(isBinaryFile(filepath.Join(suite.tempDir, "config.env")), "Text should not be binary.")
+	suite.Assertions.False(isBinaryFile(filepath.Join(suite.tempDir, "missing.env")), "Missing file should not be binary.")
+}
+
+// TestSearchFilesSkipsGit checks that secrets inside the .git directory are not reported
+func (suite *FileClassificationModuleTestSuite) TestSearchFilesSkipsGit() {
+	template := Analyzer.Template{Name: "TestTemplate", Regex: []Analyzer.Regex{{FileEndings: []string{".env"},
+		Expression: "API_KEY:\\s*(.*)$", Group: 1, Description: "Test regex"}}}
+	suite.fileHandler.Config.DefaultExcludes = true
+	results := suite.fileHandler.SearchFilesByRegex(suite.tempDir, template)
+	suite.Assertions.Equal([]Analyzer.Result{{TemplateName: "TestTemplate", Path: filepath.Join(suite.tempDir, "config.env"),
+		Line: 1, Description: "Test regex", Regex: 1, Output: "S3cr3tK3y"}}, results, "Only the source file should be scanned.")
+}
+
+// TestSearchFilesByTemplates checks that every template of the shared walk uses its own file classification
+func (suite *FileClassificationModuleTestSuite) TestSearchFilesByTemplates() {
+	suite.fileHandler.Config.DefaultExcludes = true
+	vendored := false
+	regex := Analyzer.Regex{FileEndings: []string{".env"}, Expression: "API_KEY:\\s*(\\w+)", Group: 1}
+	templates := []Analyzer.Template{
+		{Name: "source", Regex: []Analyzer.Regex{regex}},
+		{Name: "dependencies", Regex: []Analyzer.Regex{regex}, Files: Analyzer.Files{DefaultExcludes: &vendored}},
+		{Name: "script"},
+	}
+	results := suite.fileHandler.SearchFilesByTemplates(suite.tempDir, walkCheckout(suite.tempDir), templates)
+
+	suite.Assertions.Len(results, 3, "Results should be returned per template.")
+	suite.Assertions.Equal([]Analyzer.Result{{TemplateName: "source", Path: filepath.Join(suite.tempDir, "config.env"),
+		Line: 1, Regex: 1, Output: "S3cr3tK3y"}}, results[0], "Vendored files should be skipped.")
+	var paths []string
+	for _, result := range results[1] {
+		paths = append(paths, result.Path)
+	}
+	suite.Assertions.Equal([]string{filepath.Join(suite.tempDir, "config.env"),
+		filepath.Join(suite.tempDir, "node_modules", "lib", "config.env")}, paths, "Vendored files should be scanned.")
+	suite.Assertions.Empty(results[2], "Template without regex should have no results.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestFileClassificationModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(FileClassificationModuleTestSuite))
+}
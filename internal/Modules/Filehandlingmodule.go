@@ -5,8 +5,10 @@ import (
 	"GitAnalyzer/api/Analyzer"
 	"GitAnalyzer/pkg/Utils"
 	"bufio"
+	"bytes"
 	"fmt"
 	"github.com/gocarina/gocsv"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -19,11 +21,11 @@ import (
 //
 //go:generate mockery --name IFileHelper
 type IFileHelper interface {
-	SearchFilesByRegex(rootDir string, template Analyzer.Template) (result []Analyzer.Result)
+	SearchFilesByTemplates(rootDir string, files []Analyzer.CheckoutFile, templates []Analyzer.Template) [][]Analyzer.Result
 	FindFilesForCommands(rootDir string, template Analyzer.Template) map[string][]string
 	GetResultCSVPath(templateName string) string
 	GenerateUniqueCSV(templateName string)
-	GetTasks(urlsCSVPath string, checkedCSVPath string) (tasks []Analyzer.Task)
+	GetTasks(source string, checkedCSVPath string) (tasks []Analyzer.Task)
 	PrepareResultsFolder(path string)
 	MarshalSingleResult(result Analyzer.Result)
 	MarshalMultipleResults(results []Analyzer.Result)
@@ -137,10 +139,15 @@ func (fh *FileHandler) uniqueResultsPerURL(results []Analyzer.Result) map[string
 			// If no URL was found, create a new Map Output => Result under the Map entry of the URL
 			urlsToResult[result.URL] = make(map[string]Analyzer.Result)
 		}
+		// Redacted outputs are identified by their hash
+		output := result.Output
+		if result.OutputHash != "" {
+			output = result.OutputHash
+		}
 		// Check if the output was already found for the given repository URL
-		if _, exists := urlsToResult[result.URL][result.Output]; !exists {
+		if _, exists := urlsToResult[result.URL][output]; !exists {
 			// If the output was not found, add the current result under the output entry.
-			urlsToResult[result.URL][result.Output] = result
+			urlsToResult[result.URL][output] = result
 		}
 	}
 
@@ -246,96 +253,393 @@ func (fh *FileHandler) MarshalStat(stat Analyzer.Stat) {
 // the given root directory. Any matches will be returned inside slice
 // of Analyzer.Result.
 func (fh *FileHandler) SearchFilesByRegex(rootDir string, template Analyzer.Template) (result []Analyzer.Result) {
-	if len(template.Regex) == 0 {
-		// Return nil if template has no regular expressions
+	return fh.SearchFilesByTemplates(rootDir, walkCheckout(rootDir), []Analyzer.Template{template})[0]
+}
+
+// regexRule is a prepared regular expression of one of the searched templates
+type regexRule struct {
+	// Index of the template of the regular expression
+	template int
+	// Position of the regular expression inside the template, starting at 1
+	position int
+	// The regular expression with the defaults of its type
+	regex Analyzer.Regex
+	// The compiled expression
+	expression *regexp.Regexp
+}
+
+// ruleMatch is a match of a regexRule inside a file
+type ruleMatch struct {
+	regexMatch
+	// The rule which found the match
+	rule regexRule
+}
+
+// walkCheckout walks the checked out commit once and returns its files and directories in the order of the walk.
+// The .git directory is skipped, the vendored directories are returned, as templates can decide to scan them.
+// The languages of the files are detected while walking. Files which can't be read are logged and skipped.
+func walkCheckout(rootDir string) []Analyzer.CheckoutFile {
+	var files []Analyzer.CheckoutFile
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+		if info.IsDir() && path != rootDir && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		file := Analyzer.CheckoutFile{Path: path, Info: info}
+		if !info.IsDir() {
+			file.Language = fileLanguage(path, info)
+		}
+		files = append(files, file)
 		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+	return files
+}
+
+// SearchFilesByTemplates applies the regular expressions of all given templates to the files of the root directory
+// returned by walkCheckout. Every file is read once with all regular expressions whose filters match it.
+// The results are returned per template in the order of the templates.
+func (fh *FileHandler) SearchFilesByTemplates(rootDir string, files []Analyzer.CheckoutFile,
+	templates []Analyzer.Template) [][]Analyzer.Result {
+	results := make([][]Analyzer.Result, len(templates))
+
+	// Prepare the regular expressions and the file classification of every template
+	var rules []regexRule
+	classifiers := make([]*fileClassifier, len(templates))
+	for i, template := range templates {
+		for j, regex := range template.Regex {
+			regex = prepareRegex(regex)
+			rules = append(rules, regexRule{template: i, position: j + 1, regex: regex,
+				expression: compiledExpression(regex)})
+		}
+		classifiers[i] = newFileClassifier(fh.Config, template)
+	}
+	if len(rules) == 0 {
+		// Return if no template has regular expressions
+		return results
 	}
 
-	// Iterate over all regular expressions of the template
-	for _, regex := range template.Regex {
-		// Compile the regular expression
-		expression := regexp.MustCompile(strings.TrimSpace(regex.Expression))
-		// Save filtering parameters
-		excludes := regex.Exclude
-		fileEndings := regex.FileEndings
-		fileNames := regex.Filename
-		// Fetch the matching file paths
-		filePaths, found := fh.getFilePaths(rootDir, fileNames, fileEndings, excludes)
-		if !found {
-			// Continue with next regex if no matching file was found.
+	// Go through the files of the walk, the files of skipped directories follow their directory
+	skippedDir := ""
+	for _, file := range files {
+		path, info := file.Path, file.Info
+		if skippedDir != "" && strings.HasPrefix(path, skippedDir+string(os.PathSeparator)) {
+			continue
+		}
+		skippedDir = ""
+		if info.IsDir() {
+			// Skip the directory if it is skipped for all templates e.g. a vendored directory
+			skip := true
+			for i, classifier := range classifiers {
+				if len(templates[i].Regex) > 0 && !classifier.skipDir(rootDir, path, info) {
+					skip = false
+				}
+			}
+			if skip {
+				skippedDir = path
+			}
 			continue
 		}
 
-		// Iterate over matching file paths
-		for _, path := range filePaths {
-			// Open the current file
-			file, err := os.Open(path)
-			if err != nil {
-				log.Printf("Error opening file %s: %v\n", path, err.Error())
-				errClose := file.Close()
-				if errClose != nil {
-					log.Println("Error closing file:", errClose.Error())
+		// Select the regular expressions whose filters match the file and whose template scans the file.
+		// The content of the file is only sniffed once for all templates.
+		binary := -1
+		isBinary := func() bool {
+			if binary < 0 {
+				binary = 0
+				if isBinaryFile(path) {
+					binary = 1
 				}
-				continue
 			}
+			return binary == 1
+		}
+		var fileRules []regexRule
+		for _, rule := range rules {
+			if matchesPath(path, rule.regex.Filename, rule.regex.FileEndings, rule.regex.Exclude) &&
+				!classifiers[rule.template].skipFile(rootDir, path, info, isBinary) {
+				fileRules = append(fileRules, rule)
+			}
+		}
+		if len(fileRules) == 0 {
+			continue
+		}
 
-			// Read the file line by line
-			scanner := bufio.NewScanner(file)
-			for scanner.Scan() {
-				line := scanner.Text()
+		// Read the file once with all selected regular expressions
+		matches, errMatch := matchFileRules(path, info, fileRules)
+		if errMatch != nil {
+			// Keep the matches found before the error
+			log.Printf("Error reading file %s: %v\n", path, errMatch.Error())
+		}
+		// Create a new result for every match and append it to the results of its template
+		for _, match := range matches {
+			i := match.rule.template
+			results[i] = append(results[i], Analyzer.Result{TemplateName: templates[i].Name, Path: path,
+				Line: match.line, Description: match.rule.regex.Description, Regex: match.rule.position,
+				Output: match.output})
+		}
+	}
 
-				// Check if line contains matches.
-				// (-1) matches all occurrences in the line.
-				matchesSlice := expression.FindAllStringSubmatch(line, -1)
+	for i, template := range templates {
+		fh.logSkippedFiles(rootDir, template, classifiers[i])
+	}
+	return results
+}
 
-				// Check if some matches were found
-				if len(matchesSlice) == 0 {
-					continue
-				}
-				var output string
-				// Iterate over found matches
-				for _, matches := range matchesSlice {
-					// Get the correct match via the regex group
-					match := matches[regex.Group]
-					// Check if the match is a false positive
-					falsePositiveFound := false
-					for _, falsePositive := range regex.FalsePositives {
-						if strings.Contains(match, falsePositive) {
-							falsePositiveFound = true
-						}
-
-					}
-					if falsePositiveFound {
-						//Continue/skip if false positive is found in matched string.
-						continue
-					}
-
-					// Add the result to the output
-					if output == "" {
-						output = output + match
-					} else {
-						output = output + " " + match
-					}
-				}
-				if len(output) == 0 {
-					// If no output was found, continue with the next regex
-					continue
-				}
-				// Create new result and append it to the slice of results
-				result = append(result, Analyzer.Result{TemplateName: template.Name, Path: file.Name(), Description: regex.Description, Output: output})
+// The modes of the regular expressions
+const (
+	// RegexModeLine is the default mode, the expression is matched against every line on its own
+	RegexModeLine = "line"
+	// RegexModeMultiline matches the expression against the whole file, ^ and $ match at line boundaries
+	RegexModeMultiline = "multiline"
+	// RegexModeFile matches the expression against the whole file, all matches are reported as a single result
+	RegexModeFile = "file"
+)
+
+// maxLineLength is the length after which long lines (e.g. minified JavaScript) are matched in several parts
+const maxLineLength = 1024 * 1024
+
+// maxMatchLength is the length of the longest match, which is found across the parts of a long line.
+// Every part repeats this many bytes of the previous part, longer matches may still be cut at the end of a part.
+const maxMatchLength = 64 * 1024
+
+// maxRegexFileSize is the size of the largest file which is read completely for the multiline and file modes
+const maxRegexFileSize = 10 * 1024 * 1024
+
+// IsValidRegexMode returns true if the given mode of a regular expression is supported, empty means the default mode
+func IsValidRegexMode(mode string) bool {
+	return mode == "" || mode == RegexModeLine || mode == RegexModeMultiline || mode == RegexModeFile
+}
 
+// regexExpression returns the expression of the regular expression with the flags of its mode
+func regexExpression(regex Analyzer.Regex) string {
+	expression := strings.TrimSpace(regex.Expression)
+	if regex.Mode == RegexModeMultiline {
+		return "(?m)" + expression
+	}
+	return expression
+}
+
+// regexMatch contains the output of a match and the line of the file where it starts
+type regexMatch struct {
+	// Line where the match starts, the first line is 1
+	line int
+	// The matched values
+	output string
+}
+
+// The lineReader struct reads a file line by line without limiting the length of the lines.
+// Lines longer than maxLineLength are returned in several parts with the same line number,
+// every part starts with the last maxMatchLength bytes of the previous part.
+type lineReader struct {
+	reader *bufio.Reader
+	// Number of the current line
+	number int
+	// Set if the last part did not end the line
+	continued bool
+	// End of the last part, which is repeated by the next part of the line
+	tail []byte
+}
+
+// next returns the next line or part of a long line, io.EOF is returned at the end of the file
+func (lr *lineReader) next() (string, error) {
+	var line []byte
+	if lr.continued {
+		line = append(line, lr.tail...)
+	} else {
+		lr.number++
+	}
+	for {
+		chunk, isPrefix, err := lr.reader.ReadLine()
+		if err != nil {
+			if len(line) > 0 {
+				// Return the rest of the line before the error
+				lr.continued = false
+				return string(line), nil
 			}
-			// Close the file
-			errClose := file.Close()
-			if errClose != nil {
-				log.Println("Error closing file:", errClose.Error())
-				return nil
+			return "", err
+		}
+		line = append(line, chunk...)
+		if !isPrefix {
+			lr.continued = false
+			return string(line), nil
+		}
+		if len(line) >= maxLineLength {
+			// Return the part, the rest of the line is returned by the next call
+			lr.continued = true
+			lr.tail = append(lr.tail[:0], line[len(line)-maxMatchLength:]...)
+			return string(line), nil
+		}
+	}
+}
+
+// matchEnd returns the position in the last part returned by next, before which matches have to start.
+// Matches starting inside the bytes repeated by the next part are only reported by the next part.
+func (lr *lineReader) matchEnd(line string) int {
+	if lr.continued {
+		return len(line) - maxMatchLength
+	}
+	return len(line)
+}
+
+// compiledExpression returns the expression compiled while loading the template.
+// Regular expressions of templates which were not loaded from a file are compiled on demand.
+func compiledExpression(regex Analyzer.Regex) *regexp.Regexp {
+	if regex.Compiled != nil {
+		return regex.Compiled
+	}
+	return regexp.MustCompile(regexExpression(regex))
+}
+
+// isWholeFileMode returns true if the regular expression is matched against the whole content of the files
+func isWholeFileMode(mode string) bool {
+	return mode == RegexModeMultiline || mode == RegexModeFile
+}
+
+// matchFileRules reads the file once and matches all given regular expressions.
+// Whole-file modes are skipped for files larger than maxRegexFileSize, the line mode reads files of any size.
+// The matches found before an error are returned together with the error.
+func matchFileRules(path string, info os.FileInfo, rules []regexRule) (matches []ruleMatch, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Split the rules by mode
+	var lineRules, wholeFileRules []regexRule
+	for _, rule := range rules {
+		if isWholeFileMode(rule.regex.Mode) {
+			wholeFileRules = append(wholeFileRules, rule)
+		} else {
+			lineRules = append(lineRules, rule)
+		}
+	}
+
+	var reader io.Reader = file
+	if len(wholeFileRules) > 0 {
+		if info.Size() > maxRegexFileSize {
+			err = fmt.Errorf("file is larger than %d bytes, skipped multiline and file modes", maxRegexFileSize)
+		} else {
+			content, errRead := io.ReadAll(file)
+			if errRead != nil {
+				return nil, errRead
+			}
+			for _, rule := range wholeFileRules {
+				for _, match := range matchContent(rule.expression, rule.regex, string(content)) {
+					matches = append(matches, ruleMatch{regexMatch: match, rule: rule})
+				}
 			}
+			// The line mode reuses the content, so the file is only read once
+			reader = bytes.NewReader(content)
 		}
+	}
+	if len(lineRules) == 0 {
+		return matches, err
+	}
 
+	// Match the lines with all line mode rules
+	lines := &lineReader{reader: bufio.NewReader(reader)}
+	for {
+		line, errRead := lines.next()
+		if errRead == io.EOF {
+			return matches, err
+		}
+		if errRead != nil {
+			return matches, errRead
+		}
+		end := lines.matchEnd(line)
+		for _, rule := range lineRules {
+			// Check if line contains matches
+			output := strings.Join(matchLineBefore(rule.expression, rule.regex, line, end), " ")
+			if len(output) == 0 {
+				continue
+			}
+			matches = append(matches, ruleMatch{regexMatch: regexMatch{line: lines.number, output: output}, rule: rule})
+		}
 	}
-	// Return the slice of results
-	return result
+}
+
+// matchContent returns the matches of the regular expression in the content with the lines where they start.
+// In file mode all matches are returned as a single match starting at the line of the first one.
+func matchContent(expression *regexp.Regexp, regex Analyzer.Regex, content string) []regexMatch {
+	var matches []regexMatch
+	// Count the lines incrementally, the matches are ordered by their position
+	line, counted := 1, 0
+	for _, indexes := range expression.FindAllStringSubmatchIndex(content, -1) {
+		// Skip optional groups which did not match
+		start, end := indexes[2*regex.Group], indexes[2*regex.Group+1]
+		if start < 0 {
+			continue
+		}
+		match := content[start:end]
+		if !acceptMatch(match, regex) {
+			continue
+		}
+		line += strings.Count(content[counted:indexes[0]], "\n")
+		counted = indexes[0]
+		matches = append(matches, regexMatch{line: line, output: match})
+	}
+
+	if regex.Mode == RegexModeFile && len(matches) > 1 {
+		outputs := make([]string, len(matches))
+		for i, match := range matches {
+			outputs[i] = match.output
+		}
+		return []regexMatch{{line: matches[0].line, output: strings.Join(outputs, " ")}}
+	}
+	return matches
+}
+
+// matchLine returns the matches of the regular expression in the given line.
+// The match of the selected group is returned, if it is no false positive and reaches the entropy thresholds.
+func matchLine(expression *regexp.Regexp, regex Analyzer.Regex, line string) []string {
+	return matchLineBefore(expression, regex, line, len(line))
+}
+
+// matchLineBefore returns the matches of the regular expression in the given line, which start before end.
+func matchLineBefore(expression *regexp.Regexp, regex Analyzer.Regex, line string, end int) []string {
+	// (-1) matches all occurrences in the line.
+	matchesSlice := expression.FindAllStringSubmatchIndex(line, -1)
+
+	var values []string
+	// Iterate over found matches
+	for _, indexes := range matchesSlice {
+		if indexes[0] >= end {
+			continue
+		}
+		// Get the correct match via the regex group, optional groups which did not match are empty
+		var match string
+		if start, end := indexes[2*regex.Group], indexes[2*regex.Group+1]; start >= 0 {
+			match = line[start:end]
+		}
+		if !acceptMatch(match, regex) {
+			continue
+		}
+		// Add the match to the values
+		values = append(values, match)
+	}
+	return values
+}
+
+// acceptMatch returns true if the match is not empty, no false positive and reaches the entropy thresholds
+func acceptMatch(match string, regex Analyzer.Regex) bool {
+	// Skip optional groups which did not match
+	if match == "" {
+		return false
+	}
+	// Check if the match is a false positive
+	for _, falsePositive := range regex.FalsePositives {
+		if strings.Contains(match, falsePositive) {
+			return false
+		}
+	}
+	// Skip matches with a low entropy e.g. placeholders like "changeme"
+	return passesEntropy(match, regex.Entropy)
 }
 
 // FindFilesForCommands creates a unique map of path => files for files needed for the command.
@@ -353,7 +657,10 @@ func (fh *FileHandler) FindFilesForCommands(rootDir string, template Analyzer.Te
 	//keywords := template.Match.Keywords
 	// TODO: Add Keywords
 	// Find the file paths
-	filePaths, found := fh.getFilePaths(rootDir, template.Match.Filename, template.Match.FileEndings, template.Match.Exclude)
+	classifier := newFileClassifier(fh.Config, template)
+	defer fh.logSkippedFiles(rootDir, template, classifier)
+	filePaths, found := fh.getFilePaths(rootDir, template.Match.Filename, template.Match.FileEndings, template.Match.Exclude,
+		classifier)
 	if !found {
 		// Return nil if no wanted filenames where found in the repository
 		return result
@@ -379,10 +686,21 @@ func (fh *FileHandler) FindFilesForCommands(rootDir string, template Analyzer.Te
 	return result
 }
 
+// logSkippedFiles prints the number of files skipped by the classifier, if the verbose output is enabled
+func (fh *FileHandler) logSkippedFiles(rootDir string, template Analyzer.Template, classifier *fileClassifier) {
+	if !fh.Config.Verbose {
+		return
+	}
+	if summary := classifier.summary(); summary != "" {
+		log.Printf("Template %s skipped files in %s: %s\n", template.Name, rootDir, summary)
+	}
+}
+
 // getFilePaths searches all paths inside the provided root dir which
 // match one of the provided filters.
+// The classifier skips the .git directory, vendored directories and binary or oversized files.
 func (fh *FileHandler) getFilePaths(rootDir string, filenames []string,
-	fileEndings []string, excludes []string) (filePaths []string, found bool) {
+	fileEndings []string, excludes []string, classifier *fileClassifier) (filePaths []string, found bool) {
 	found = false
 
 	// Iterate over all paths inside the given root dir
@@ -391,49 +709,21 @@ func (fh *FileHandler) getFilePaths(rootDir string, filenames []string,
 			if err != nil {
 				return err
 			}
-
-			// Check if path contains a wanted filename
-			for _, filename := range filenames {
-				if excludes != nil {
-					for _, exclude := range excludes {
-						// Check if excluded keyword is not found
-						if !strings.Contains(path, exclude) {
-							// Add filepath if filename is found
-							if strings.Contains(path, filename) {
-								filePaths = append(filePaths, path)
-								found = true
-							}
-						}
-					}
-				} else {
-					// Add filepath if filename is found
-					if strings.Contains(path, filename) {
-						filePaths = append(filePaths, path)
-						found = true
-					}
-				}
+			// Skip the .git directory and the vendored directories
+			if info.IsDir() && classifier.skipDir(rootDir, path, info) {
+				return filepath.SkipDir
 			}
 
-			// Check if path ends on a wanted fileEnding
-			fileExtension := filepath.Ext(path)
-			if excludes != nil {
-				for _, exclude := range excludes {
-					// Check if excluded keyword is not found
-					if !strings.Contains(path, exclude) {
-						// Add filepath if fileEnding is found
-						if Utils.Contains(fileEndings, fileExtension) {
-							filePaths = append(filePaths, path)
-							found = true
-						}
-					}
-				}
-			} else {
-				// Add filepath if fileEnding is found
-				if Utils.Contains(fileEndings, fileExtension) {
-					filePaths = append(filePaths, path)
-					found = true
-				}
+			// Check if path contains a wanted filename or ends on a wanted fileEnding and is not excluded
+			if !matchesPath(path, filenames, fileEndings, excludes) {
+				return nil
+			}
+			// Skip binary and oversized files
+			if !info.IsDir() && classifier.skipFile(rootDir, path, info, func() bool { return isBinaryFile(path) }) {
+				return nil
 			}
+			filePaths = append(filePaths, path)
+			found = true
 			return nil
 		})
 	if err != nil {
@@ -442,26 +732,42 @@ func (fh *FileHandler) getFilePaths(rootDir string, filenames []string,
 	return filePaths, found
 }
 
-// GetTasks loads all tasks from the provided checked.csv (path) and removes the allready
-// checked repos from the loaded repos of the urls.csv file (path)
-// Returns the list of none checked repos/tasks.
-func (fh *FileHandler) GetTasks(urlsCSVPath string, checkedCSVPath string) (tasks []Analyzer.Task) {
-	// Open the file of all tasks (url.csv)
-	tasksFile, err := os.Open(urlsCSVPath)
-	if err != nil {
-		log.Println("Error opening CSV file:", err.Error())
-		return nil
+// matchesPath returns true if the path contains one of the filenames or ends on one of the file endings
+// and contains none of the excludes
+func matchesPath(path string, filenames []string, fileEndings []string, excludes []string) bool {
+	matched := Utils.Contains(fileEndings, filepath.Ext(path))
+	for _, filename := range filenames {
+		if strings.Contains(path, filename) {
+			matched = true
+		}
+	}
+	if !matched {
+		return false
 	}
+	// Check if an excluded keyword is found
+	for _, exclude := range excludes {
+		if strings.Contains(path, exclude) {
+			return false
+		}
+	}
+	return true
+}
 
-	// Load tasks from urls.csv
-	if err = gocsv.UnmarshalWithoutHeaders(tasksFile, &tasks); err != nil {
-		log.Fatalln("Error Unmarshalling Tasks:", err.Error())
+// GetTasks loads all tasks from the provided task source (see NewTaskSource) and removes the already
+// checked repos of the provided checked.csv (path) from the loaded tasks.
+// Returns the list of none checked repos/tasks.
+func (fh *FileHandler) GetTasks(source string, checkedCSVPath string) (tasks []Analyzer.Task) {
+	// Create the task source e.g. urls.csv or stdin
+	taskSource, err := NewTaskSource(source, os.Stdin)
+	if err != nil {
+		log.Println("Error opening task source:", err.Error())
 		return nil
 	}
 
-	err = tasksFile.Close()
+	// Load tasks from the source
+	tasks, err = taskSource.Tasks()
 	if err != nil {
-		log.Println("Error closing URL file!", err.Error())
+		log.Println("Error loading tasks:", err.Error())
 		return nil
 	}
 
@@ -481,11 +787,6 @@ func (fh *FileHandler) GetTasks(urlsCSVPath string, checkedCSVPath string) (task
 	// Remove checked stats from Tasks
 	tasks = fh.filterTasksByStats(stats, tasks)
 
-	if err != nil {
-		log.Println("Error closing checked file!", err.Error())
-		return nil
-	}
-
 	return tasks
 }
 
@@ -508,19 +809,19 @@ func (fh *FileHandler) UnMarshallStats(checkedCSVPath string) []Analyzer.Stat {
 
 // filterTasksByStats removes all stats (done tasks) from the list of tasks which need to be scanned.
 func (fh *FileHandler) filterTasksByStats(stats []Analyzer.Stat, tasks []Analyzer.Task) []Analyzer.Task {
-	// Iterate over all stats
+	// Create set of all checked URLs
+	checkedURLs := make(map[string]struct{}, len(stats))
 	for _, stat := range stats {
-		statURL := stat.URL
-		// Iterate over tasks
-		for i, task := range tasks {
-			// If URL of stat and task match, the repo was already checked.
-			if statURL == task.URL {
-				// Reslice tasks, to exclude the already checked repo
-				tasks = append(tasks[:i], tasks[i+1:]...)
-				// Continue with the next stat
-				break
-			}
+		checkedURLs[stat.URL] = struct{}{}
+	}
+	// Keep the tasks which were not checked yet
+	var filteredTasks []Analyzer.Task
+	for _, task := range tasks {
+		// If URL of stat and task match, the repo was already checked.
+		if _, checked := checkedURLs[task.URL]; checked {
+			continue
 		}
+		filteredTasks = append(filteredTasks, task)
 	}
-	return tasks
+	return filteredTasks
 }
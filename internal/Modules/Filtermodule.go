@@ -0,0 +1,277 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// templateMatcher returns true if the template is selected by the filter
+type templateMatcher func(template Analyzer.Template) bool
+
+// The filterToken struct contains an operator or a term of a filter expression and its position
+type filterToken struct {
+	// Text of the token e.g. &&, ( or severity>=high
+	text string
+	// Position of the token inside the expression, starting at 1
+	position int
+}
+
+// severityOperators contains the comparison operators of the severity predicates, longer operators first
+var severityOperators = []string{">=", "<=", "!=", ">", "<", "=", ":"}
+
+// ValidateTemplateFilter returns an error if the filter expression can't be parsed
+func ValidateTemplateFilter(expression string) error {
+	_, err := parseTemplateFilter(expression)
+	return err
+}
+
+// parseTemplateFilter parses the filter expression of the templates. Terms can be combined with && (and),
+// || or , (or), ! (not) and parentheses. A term is either a tag, name:<glob> matching the template name or a
+// severity predicate like severity>=high. Tags may contain the glob characters * ? and [].
+func parseTemplateFilter(expression string) (templateMatcher, error) {
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty template filter")
+	}
+	parser := &filterParser{tokens: tokens}
+	matcher, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.index < len(tokens) {
+		token := tokens[parser.index]
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.position)
+	}
+	return matcher, nil
+}
+
+// tokenizeFilter splits the filter expression into operators and terms.
+// An exclamation mark is only an operator at the start of a term, so severity!=info stays one term.
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',' || r == '!':
+			tokens = append(tokens, filterToken{text: string(r), position: i + 1})
+			i++
+		case r == '&' || r == '|':
+			// The boolean operators are written twice
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("expected %c%c at position %d", r, r, i+1)
+			}
+			tokens = append(tokens, filterToken{text: string([]rune{r, r}), position: i + 1})
+			i += 2
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()&|,", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[start:i]), position: start + 1})
+		}
+	}
+	return tokens, nil
+}
+
+// The filterParser struct parses the tokens of a filter expression by recursive descent
+type filterParser struct {
+	// All tokens of the expression
+	tokens []filterToken
+	// Index of the next token
+	index int
+}
+
+// next returns the next token without consuming it, false at the end of the expression
+func (p *filterParser) next() (filterToken, bool) {
+	if p.index >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.index], true
+}
+
+// parseOr parses the terms combined with || or , which bind weakest
+func (p *filterParser) parseOr() (templateMatcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for token, found := p.next(); found && (token.text == "||" || token.text == ","); token, found = p.next() {
+		p.index++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		first := left
+		left = func(template Analyzer.Template) bool { return first(template) || right(template) }
+	}
+	return left, nil
+}
+
+// parseAnd parses the terms combined with &&
+func (p *filterParser) parseAnd() (templateMatcher, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for token, found := p.next(); found && token.text == "&&"; token, found = p.next() {
+		p.index++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		first := left
+		left = func(template Analyzer.Template) bool { return first(template) && right(template) }
+	}
+	return left, nil
+}
+
+// parseNot parses a negated term, a term in parentheses or a single term
+func (p *filterParser) parseNot() (templateMatcher, error) {
+	token, found := p.next()
+	if !found {
+		return nil, fmt.Errorf("unexpected end of template filter")
+	}
+	p.index++
+	switch token.text {
+	case "!":
+		negated, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(template Analyzer.Template) bool { return !negated(template) }, nil
+	case "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, found := p.next(); !found || closing.text != ")" {
+			return nil, fmt.Errorf("missing ) for ( at position %d", token.position)
+		}
+		p.index++
+		return inner, nil
+	case ")", "&&", "||", ",":
+		return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.position)
+	}
+	return parseFilterTerm(token)
+}
+
+// parseFilterTerm parses a tag, name or severity term of the filter expression
+func parseFilterTerm(token filterToken) (templateMatcher, error) {
+	term := strings.ToLower(token.text)
+	if strings.HasPrefix(term, "severity") && len(term) > len("severity") {
+		return parseSeverityTerm(token, strings.TrimPrefix(term, "severity"))
+	}
+	field, pattern := "tag", term
+	if i := strings.Index(term, ":"); i > 0 {
+		field, pattern = term[:i], term[i+1:]
+	}
+	if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+		return nil, fmt.Errorf("invalid pattern %q at position %d", token.text, token.position)
+	}
+	switch field {
+	case "tag":
+		return func(template Analyzer.Template) bool {
+			for _, tag := range template.Tags {
+				if matchesFold(pattern, tag) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "name":
+		return func(template Analyzer.Template) bool { return matchesFold(pattern, template.Name) }, nil
+	}
+	return nil, fmt.Errorf("unknown field %q at position %d (valid: tag, name, severity)", field, token.position)
+}
+
+// parseSeverityTerm parses the comparison of a severity predicate e.g. >=high. Higher severities are greater,
+// templates without severity only match = unknown and != comparisons.
+func parseSeverityTerm(token filterToken, comparison string) (templateMatcher, error) {
+	for _, operator := range severityOperators {
+		if !strings.HasPrefix(comparison, operator) {
+			continue
+		}
+		level := strings.TrimPrefix(comparison, operator)
+		if !Utils.Contains(severities, level) {
+			return nil, fmt.Errorf("unknown severity %q at position %d (valid: %s)", level, token.position,
+				strings.Join(severities, ", "))
+		}
+		return func(template Analyzer.Template) bool {
+			severity := templateSeverity(template)
+			// Unknown severities can't be compared
+			if operator != "=" && operator != ":" && operator != "!=" && (severity == "unknown" || level == "unknown") {
+				return false
+			}
+			// The ranks are ordered from the highest severity, so the comparisons are reversed
+			rank, levelRank := severityRank(severity), severityRank(level)
+			switch operator {
+			case ">=":
+				return rank <= levelRank
+			case "<=":
+				return rank >= levelRank
+			case ">":
+				return rank < levelRank
+			case "<":
+				return rank > levelRank
+			case "!=":
+				return rank != levelRank
+			}
+			return rank == levelRank
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid severity predicate %q at position %d (e.g. severity>=high)", token.text,
+		token.position)
+}
+
+// defaultTemplateFilter selects the misc templates and the templates tagged with one of the comma separated languages
+func defaultTemplateFilter(language string) templateMatcher {
+	words := []string{"misc"}
+	for _, word := range strings.Split(language, ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	return func(template Analyzer.Template) bool {
+		for _, tag := range template.Tags {
+			if containsFold(words, strings.TrimSpace(tag)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchesFold returns true if the value matches the glob pattern, ignoring the case
+func matchesFold(pattern, value string) bool {
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(strings.TrimSpace(value)))
+	return matched
+}
+
+// ListTemplates prints the templates, which would run on a repository of the given languages with the filter and
+// excluded names of the config. Templates with conditions are marked, as they are only run where these match.
+func ListTemplates(config Analyzer.Config, language string) {
+	templateHandler := &TemplateHandler{Config: config}
+	templateHandler.LoadTemplates(config.TemplatesPath)
+	templates := templateHandler.FilterTemplates(config.Tags, language, config.Excluded)
+	for _, template := range templates {
+		conditional := ""
+		if !reflect.DeepEqual(template.When, Analyzer.When{}) {
+			conditional = "\t(when)"
+		}
+		fmt.Printf("%s\t%s\t%s%s\n", template.Name, templateSeverity(template), strings.Join(template.Tags, ","),
+			conditional)
+	}
+	fmt.Println(len(templates), "of", len(templateHandler.templates), "templates selected")
+}
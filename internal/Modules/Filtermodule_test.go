@@ -0,0 +1,92 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Create test suite for the filter module
+type FilterModuleTestSuite struct {
+	suite.Suite
+	templates []Analyzer.Template
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *FilterModuleTestSuite) SetupTest() {
+	suite.templates = []Analyzer.Template{
+		{Name: "Credentials", Tags: []string{"credentials", "misc"}, Meta: Analyzer.Meta{Severity: "high"}},
+		{Name: "Noisy-Credentials", Tags: []string{"credentials", "noisy"}, Meta: Analyzer.Meta{Severity: "low"}},
+		{Name: "Log4Shell", Tags: []string{"java", "log4j"}, Meta: Analyzer.Meta{Severity: "critical"}},
+		{Name: "NPM-Audit", Tags: []string{"javascript", "npm-audit"}, Meta: Analyzer.Meta{Severity: "medium"}},
+		{Name: "NPM-Extract", Tags: []string{"javascript", "npm"}},
+	}
+}
+
+// selectedNames returns the names of the templates selected by the filter expression
+func (suite *FilterModuleTestSuite) selectedNames(expression string) []string {
+	matcher, err := parseTemplateFilter(expression)
+	suite.Assertions.NoError(err, "Parsing %q should not fail.", expression)
+	var names []string
+	for _, template := range suite.templates {
+		if matcher(template) {
+			names = append(names, template.Name)
+		}
+	}
+	return names
+}
+
+// TestParseTemplateFilter checks the boolean operators, name globs and severity predicates of the filter
+func (suite *FilterModuleTestSuite) TestParseTemplateFilter() {
+	tests := map[string][]string{
+		"credentials":                          {"Credentials", "Noisy-Credentials"},
+		"credentials && !noisy":                {"Credentials"},
+		"java || javascript":                   {"Log4Shell", "NPM-Audit", "NPM-Extract"},
+		"misc, JAVA":                           {"Credentials", "Log4Shell"},
+		"npm*":                                 {"NPM-Audit", "NPM-Extract"},
+		"name:npm-*":                           {"NPM-Audit", "NPM-Extract"},
+		"tag:log4?":                            {"Log4Shell"},
+		"severity>=high":                       {"Credentials", "Log4Shell"},
+		"severity<medium":                      {"Noisy-Credentials"},
+		"severity:unknown":                     {"NPM-Extract"},
+		"severity!=info && javascript":         {"NPM-Audit", "NPM-Extract"},
+		"!(java || javascript) || name:npm-a*": {"Credentials", "Noisy-Credentials", "NPM-Audit"},
+		"credentials && severity>medium || java && !severity=critical": {"Credentials"},
+	}
+	for expression, names := range tests {
+		suite.Assertions.Equal(names, suite.selectedNames(expression), "Templates of %q should equal.", expression)
+	}
+
+	// Invalid expressions are reported with their position
+	errors := map[string]string{
+		"":                   "empty template filter",
+		"java &| javascript": "expected && at position 6",
+		"(java":              "missing ) for ( at position 1",
+		"java)":              `unexpected ")" at position 5`,
+		"java ||":            "unexpected end of template filter",
+		"!, java":            `unexpected "," at position 2`,
+		"severity>=urgent":   `unknown severity "urgent" at position 1 (valid: critical, high, medium, low, info, unknown)`,
+		"severity~high":      `invalid severity predicate "severity~high" at position 1 (e.g. severity>=high)`,
+		"owner:alice":        `unknown field "owner" at position 1 (valid: tag, name, severity)`,
+		"name:[a-":           `invalid pattern "name:[a-" at position 1`,
+	}
+	for expression, message := range errors {
+		suite.Assertions.EqualError(ValidateTemplateFilter(expression), message, "Error of %q should equal.", expression)
+	}
+}
+
+// TestFilterTemplatesOnce checks that templates with several matching tags are selected once
+func (suite *FilterModuleTestSuite) TestFilterTemplatesOnce() {
+	th := NewTemplateHandlerWithMocks(nil, &FileHandler{}, &CommandHandler{}, Analyzer.Config{})
+	th.templates = suite.templates
+	suite.Assertions.Equal(suite.templates[:2], th.FilterTemplates("credentials, noisy, misc", "", ""),
+		"Templates should be selected once.")
+	suite.Assertions.Equal(suite.templates[:1], th.FilterTemplates("", "Java,Go", "log4*"),
+		"Excluded globs should drop the templates.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestFilterModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterModuleTestSuite))
+}
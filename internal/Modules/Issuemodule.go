@@ -0,0 +1,267 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// secretTags are the tags of the templates whose findings contain secret values
+var secretTags = []string{"credentials", "secrets"}
+
+// isSecretTemplate returns true if the findings of the template contain secret values
+func isSecretTemplate(template Analyzer.Template) bool {
+	for _, tag := range template.Tags {
+		for _, secretTag := range secretTags {
+			if strings.EqualFold(tag, secretTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactSecret keeps the first four characters of every value of the output and masks the rest.
+// Values with less than eight characters are masked completely.
+func redactSecret(output string) string {
+	values := strings.Fields(output)
+	for i, value := range values {
+		// Count characters instead of bytes, so multibyte characters aren't cut
+		runes := []rune(value)
+		visible := 4
+		if len(runes) < 8 {
+			visible = 0
+		}
+		values[i] = string(runes[:visible]) + strings.Repeat("*", len(runes)-visible)
+	}
+	return strings.Join(values, " ")
+}
+
+// redactSecrets returns a copy of the results with the outputs of the secret templates redacted.
+// Results of templates which aren't loaded are redacted too, as it's unknown whether they contain secrets.
+// Results which were already redacted by the policy of their template are not changed.
+func redactSecrets(results []Analyzer.Result, templates []Analyzer.Template) []Analyzer.Result {
+	secret := make(map[string]bool)
+	for _, template := range templates {
+		secret[template.Name] = isSecretTemplate(template)
+	}
+	redacted := make([]Analyzer.Result, len(results))
+	for i, result := range results {
+		isSecret, known := secret[result.TemplateName]
+		if (isSecret || !known) && result.OutputHash == "" {
+			result.Output = redactSecret(result.Output)
+		}
+		redacted[i] = result
+	}
+	return redacted
+}
+
+// issueTemplate contains a template with findings inside a repository
+type issueTemplate struct {
+	// Name of the template
+	Name string
+	// Description of the template
+	Description string
+	// Severity of the template
+	Severity string
+	// Meta information of the template like references, mitigation and CWE
+	Meta Analyzer.Meta
+	// The findings of the template inside the repository
+	Findings []reportFinding
+}
+
+// issueData is passed to the issue template to render the issue of a single repository
+type issueData struct {
+	// URL of the repository
+	URL string
+	// Highest severity of the findings of the repository
+	Severity string
+	// The scan session which found the findings
+	Session Analyzer.ScanSession
+	// All findings of the repository sorted by severity and template
+	Findings []reportFinding
+	// The templates with findings sorted by severity and name
+	Templates []issueTemplate
+}
+
+// issueIndexEntry describes a written issue inside the index.json file
+type issueIndexEntry struct {
+	// URL of the repository
+	URL string `json:"url"`
+	// Highest severity of the findings of the repository
+	Severity string `json:"severity"`
+	// Number of findings of the repository
+	Findings int `json:"findings"`
+	// Name of the Markdown file of the issue inside the directory
+	File string `json:"file"`
+}
+
+// defaultIssueTemplate is used to render the issues if no issue template is provided
+const defaultIssueTemplate = `# Security findings in {{.URL}}
+
+An automated scan{{if .Session.ID}} (scan {{.Session.ID}}, {{.Session.StartedAt}}){{end}} found {{len .Findings}} potential issue(s) in this repository.
+The highest severity is **{{.Severity}}**.
+{{range .Templates}}
+## {{.Name}} ({{.Severity}})
+{{if .Description}}
+{{.Description}}
+{{end}}
+{{- if .Meta.CWE}}
+**CWE:** {{.Meta.CWE}}
+{{end}}
+{{- if .Meta.CVE}}
+**CVE:** {{.Meta.CVE}}
+{{end}}
+{{- if .Meta.Impact}}
+**Impact:** {{.Meta.Impact}}
+{{end}}
+### Findings
+{{range .Findings}}
+- {{link .Path .FileURL}}{{if .Line}} line {{.Line}}{{end}}{{if .CommitHash}} in commit {{link (code .CommitHash) .CommitURL}}{{end}}{{if .Description}} ({{.Description}}){{end}}: {{code .Output}}
+{{- end}}
+{{if .Meta.Mitigation}}
+### Mitigation
+
+{{.Meta.Mitigation}}
+{{end}}
+{{- if .Meta.References}}
+### References
+{{range .Meta.References}}
+- {{.}}
+{{- end}}
+{{end}}
+{{- end}}`
+
+// issueTemplateFuncs are the functions available inside the issue templates
+var issueTemplateFuncs = template.FuncMap{
+	"code":  markdownCode,
+	"link":  markdownLink,
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// markdownCode formats the value as inline code, backticks inside the value are fenced by longer backtick strings
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		value = " " + value + " "
+	}
+	return fence + value + fence
+}
+
+// markdownLink formats a link with the given text, only the text is returned if the link is empty
+func markdownLink(text, link string) string {
+	if link == "" {
+		return text
+	}
+	return "[" + strings.NewReplacer("[", "\\[", "]", "\\]").Replace(text) + "](" + link + ")"
+}
+
+// issueFileNameRegex matches all characters which are replaced inside the file names of the issues
+var issueFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// issueFileName returns the name of the Markdown file of the issue of the repository with the given URL.
+// A short hash of the URL is appended, as different URLs like a/b_c and a_b/c get the same readable name.
+func issueFileName(repoURL string) string {
+	name := repoURL
+	if index := strings.Index(name, "://"); index >= 0 {
+		name = name[index+3:]
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+	hash := sha256.Sum256([]byte(repoURL))
+	return strings.Trim(issueFileNameRegex.ReplaceAllString(name, "_"), "_.") + "-" + hex.EncodeToString(hash[:4]) +
+		".md"
+}
+
+// buildIssues groups the findings of every repository of the report by template
+func buildIssues(data reportData) []issueData {
+	// Map template name => summary of the template
+	templates := make(map[string]reportTemplate)
+	for _, template := range data.Templates {
+		templates[template.Name] = template
+	}
+
+	issues := make([]issueData, 0, len(data.Repositories))
+	for _, repository := range data.Repositories {
+		issue := issueData{URL: repository.URL, Severity: repository.Severity, Session: data.Session,
+			Findings: repository.Findings}
+		// The findings are sorted by severity and template, so the templates keep this order
+		index := make(map[string]int)
+		for _, finding := range repository.Findings {
+			i, found := index[finding.TemplateName]
+			if !found {
+				summary := templates[finding.TemplateName]
+				i = len(issue.Templates)
+				index[finding.TemplateName] = i
+				issue.Templates = append(issue.Templates, issueTemplate{Name: summary.Name,
+					Description: summary.Description, Severity: summary.Severity, Meta: summary.Meta})
+			}
+			issue.Templates[i].Findings = append(issue.Templates[i].Findings, finding)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// writeMarkdownIssues renders one Markdown issue per repository with findings into the output directory.
+// The issues are rendered with the given text/template file or the default issue template.
+// The index.json file inside the directory lists all written issues.
+func writeMarkdownIssues(outputDir, templatePath string, data reportData) error {
+	// Parse the issue template
+	text := defaultIssueTemplate
+	if templatePath != "" {
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return err
+		}
+		text = string(content)
+	}
+	tmpl, err := template.New("issue").Funcs(issueTemplateFuncs).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	index := make([]issueIndexEntry, 0, len(data.Repositories))
+	for _, issue := range buildIssues(data) {
+		entry := issueIndexEntry{URL: issue.URL, Severity: issue.Severity, Findings: len(issue.Findings),
+			File: issueFileName(issue.URL)}
+		file, err := os.Create(filepath.Join(outputDir, entry.File))
+		if err != nil {
+			return err
+		}
+		if err = tmpl.Execute(file, issue); err != nil {
+			file.Close()
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+		index = append(index, entry)
+	}
+
+	// Write the index sorted by file name
+	sort.Slice(index, func(i, j int) bool { return index[i].File < index[j].File })
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, "index.json"), content, 0644)
+}
@@ -0,0 +1,163 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// minLanguageShare is the minimal share of the bytes of the repository a secondary language needs
+const minLanguageShare = 0.05
+
+// languageExtensions maps the file extensions to their languages
+var languageExtensions = map[string]string{
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".py": "Python", ".pyw": "Python",
+	".go":   "Go",
+	".java": "Java",
+	".kt":   "Kotlin", ".kts": "Kotlin",
+	".groovy": "Groovy", ".gradle": "Groovy",
+	".rb":  "Ruby",
+	".php": "PHP",
+	".pl":  "Perl", ".pm": "Perl",
+	".cs": "C#",
+	".c":  "C", ".h": "C",
+	".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".hpp": "C++",
+	".m":     "Objective-C",
+	".rs":    "Rust",
+	".swift": "Swift",
+	".scala": "Scala",
+	".dart":  "Dart",
+	".lua":   "Lua",
+	".r":     "R",
+	".sh":    "Shell", ".bash": "Shell", ".zsh": "Shell",
+	".ps1": "PowerShell",
+	".tf":  "HCL",
+	".ino": "Arduino",
+}
+
+// languageFilenames maps the names of files without a language extension to their languages
+var languageFilenames = map[string]string{
+	"Dockerfile": "Dockerfile", "Makefile": "Makefile", "Gemfile": "Ruby", "Rakefile": "Ruby",
+	"Jenkinsfile": "Groovy",
+}
+
+// shebangInterpreters maps the interpreters of the shebang lines to their languages
+var shebangInterpreters = map[string]string{
+	"python": "Python", "node": "JavaScript", "nodejs": "JavaScript", "sh": "Shell", "bash": "Shell", "zsh": "Shell",
+	"ruby": "Ruby", "perl": "Perl", "php": "PHP",
+}
+
+// The languageBytes struct contains the number of bytes of a language inside the repository
+type languageBytes struct {
+	language string
+	bytes    int64
+}
+
+// detectLanguages detects the languages of the checkout by the sizes of the files of every language, like GitHub's
+// linguist. The languages of the files are identified by walkCheckout from their extension, name or shebang line.
+// Vendored directories and minified files are skipped. The language with the most bytes is returned as primary
+// language, the other languages with a share of at least 5% as secondary languages.
+func detectLanguages(rootDir string, files []Analyzer.CheckoutFile) (string, []string) {
+	sizes := make(map[string]int64)
+	var total int64
+	for _, file := range files {
+		if file.Language == "" {
+			continue
+		}
+		relativePath, err := filepath.Rel(rootDir, file.Path)
+		if err != nil || isVendoredPath(relativePath) {
+			continue
+		}
+		sizes[file.Language] += file.Info.Size()
+		total += file.Info.Size()
+	}
+	if len(sizes) == 0 {
+		return "", nil
+	}
+
+	// Sort the languages by their bytes, the names break ties
+	languages := make([]languageBytes, 0, len(sizes))
+	for language, bytes := range sizes {
+		languages = append(languages, languageBytes{language: language, bytes: bytes})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].bytes != languages[j].bytes {
+			return languages[i].bytes > languages[j].bytes
+		}
+		return languages[i].language < languages[j].language
+	})
+	var secondary []string
+	for _, language := range languages[1:] {
+		if total > 0 && float64(language.bytes)/float64(total) >= minLanguageShare {
+			secondary = append(secondary, language.language)
+		}
+	}
+	return languages[0].language, secondary
+}
+
+// fileLanguage returns the language of the file identified by its name, its extension or for files without an
+// extension by its shebang line. Minified files are not counted, empty if the language is unknown.
+func fileLanguage(path string, info os.FileInfo) string {
+	name := info.Name()
+	if language, found := languageFilenames[name]; found {
+		return language
+	}
+	if strings.Contains(name, ".min.") {
+		return ""
+	}
+	extension := filepath.Ext(name)
+	if extension != "" {
+		return languageExtensions[strings.ToLower(extension)]
+	}
+	if info.Size() == 0 {
+		return ""
+	}
+	return shebangLanguage(path)
+}
+
+// maxShebangLength is the number of bytes read to find the interpreter of a shebang line
+const maxShebangLength = 256
+
+// shebangLanguage returns the language of the interpreter of the shebang line of the file e.g. #!/usr/bin/env python3
+func shebangLanguage(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	// Only read a short prefix of the file, a file without line break isn't read completely
+	reader := bufio.NewReaderSize(file, maxShebangLength)
+	if magic, err := reader.Peek(2); err != nil || string(magic) != "#!" {
+		return ""
+	}
+	// A file shorter than the prefix returns its content with io.EOF, which is ignored
+	prefix, _ := reader.Peek(maxShebangLength)
+	line := string(prefix)
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Use the first argument of env, which is no option
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	// Remove the version of the interpreter e.g. python3.11
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return shebangInterpreters[interpreter]
+}
@@ -0,0 +1,99 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create test suite for the language module
+type LanguageModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *LanguageModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+}
+
+// writeFiles writes the files with the given contents into the temporary directory
+func (suite *LanguageModuleTestSuite) writeFiles(files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(suite.tempDir, name)
+		suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+		suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Creating the file should not fail.")
+	}
+}
+
+// TestDetectLanguages checks that the languages are ordered by their bytes and small shares are dropped
+func (suite *LanguageModuleTestSuite) TestDetectLanguages() {
+	suite.writeFiles(map[string]string{
+		"src/main/App.java":        strings.Repeat("j", 6000),
+		"src/main/Util.java":       strings.Repeat("j", 2000),
+		"web/app.js":               strings.Repeat("s", 1500),
+		"web/app.min.js":           strings.Repeat("m", 50000),
+		"bin/deploy":               "#!/usr/bin/env python3\n" + strings.Repeat("p", 600),
+		"scripts/tiny.rb":          "puts 1",
+		"node_modules/lib/big.js":  strings.Repeat("v", 50000),
+		".git/hooks/pre-commit.sh": strings.Repeat("g", 50000),
+		"README":                   "No language",
+	})
+	primary, secondary := detectLanguages(suite.tempDir, walkCheckout(suite.tempDir))
+	suite.Assertions.Equal("Java", primary, "Primary language should equal.")
+	suite.Assertions.Equal([]string{"JavaScript", "Python"}, secondary, "Secondary languages should equal.")
+
+	emptyDir := suite.T().TempDir()
+	empty, none := detectLanguages(emptyDir, walkCheckout(emptyDir))
+	suite.Assertions.Empty(empty, "Empty repository should have no language.")
+	suite.Assertions.Empty(none, "Empty repository should have no secondary language.")
+}
+
+// TestFileLanguage checks the detection by name, extension and shebang line
+func (suite *LanguageModuleTestSuite) TestFileLanguage() {
+	files := map[string]string{
+		"Dockerfile": "FROM alpine",
+		"main.GO":    "package main",
+		"run":        "#!/bin/bash\necho",
+		"server":     "#! /usr/bin/env -S node --harmony\n",
+		"tool":       "#!/usr/local/bin/python3.11",
+		"notes":      "#!unknown",
+		"LICENSE":    "MIT",
+		"data.json":  "{}",
+		"hash":       "#",
+		"long":       "#!/bin/sh " + strings.Repeat("x", 2*maxShebangLength),
+	}
+	suite.writeFiles(files)
+	expected := map[string]string{"Dockerfile": "Dockerfile", "main.GO": "Go", "run": "Shell", "server": "JavaScript",
+		"tool": "Python", "notes": "", "LICENSE": "", "data.json": "", "hash": "", "long": "Shell"}
+	for name, language := range expected {
+		path := filepath.Join(suite.tempDir, name)
+		info, err := os.Stat(path)
+		suite.Assertions.NoError(err, "Stat of the file should not fail.")
+		suite.Assertions.Equal(language, fileLanguage(path, info), "Language of %s should equal.", name)
+	}
+}
+
+// TestLanguageKeywords checks that the primary and secondary languages are used as keywords of the templates
+func (suite *LanguageModuleTestSuite) TestLanguageKeywords() {
+	suite.Assertions.Equal("Java,JavaScript", languageKeywords(Analyzer.Task{Language: "Java",
+		SecondaryLanguages: []string{"JavaScript"}}), "Keywords should equal.")
+	suite.Assertions.Empty(languageKeywords(Analyzer.Task{}), "Task without language should have no keywords.")
+
+	// The detected languages select the language tagged templates
+	th := NewTemplateHandlerWithMocks(nil, &FileHandler{}, &CommandHandler{}, Analyzer.Config{})
+	th.templates = []Analyzer.Template{{Name: "Log4Shell", Tags: []string{"java"}},
+		{Name: "NPM-Audit", Tags: []string{"javascript"}}, {Name: "Python-Audit", Tags: []string{"python"}}}
+	filtered := th.FilterTemplates("", languageKeywords(Analyzer.Task{Language: "Java",
+		SecondaryLanguages: []string{"JavaScript"}}), "")
+	suite.Assertions.Equal(th.templates[:2], filtered, "Language tagged templates should be selected.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestLanguageModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(LanguageModuleTestSuite))
+}
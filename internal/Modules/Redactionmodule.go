@@ -0,0 +1,181 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The redaction policies of the templates
+const (
+	// RedactNone stores the outputs unchanged
+	RedactNone = "none"
+	// RedactPartial keeps the first characters of every value of the output
+	RedactPartial = "partial"
+	// RedactHash replaces the output by its salted hash
+	RedactHash = "hash"
+)
+
+// redactionSaltFile is the name of the file inside the results directory which contains the generated salt
+const redactionSaltFile = "redact.salt"
+
+// IsValidRedactPolicy returns true if the given redaction policy is supported, empty means the default policy
+func IsValidRedactPolicy(policy string) bool {
+	switch policy {
+	case "", RedactNone, RedactPartial, RedactHash:
+		return true
+	}
+	return false
+}
+
+// The Redactor struct applies the redaction policies of the templates to their results.
+// The original output of every redacted result is kept as salted hash, so the findings can still be deduplicated
+// and compared between scans using the same salt.
+type Redactor struct {
+	// Key of the HMAC used to hash the outputs
+	salt []byte
+	// Map template name => redaction policy of the template
+	templatePolicies map[string]string
+	// Map template name + position of the regex => redaction policy of the regular expression
+	regexPolicies map[regexPolicyKey]string
+}
+
+// regexPolicyKey identifies a regular expression of a template
+type regexPolicyKey struct {
+	// Name of the template
+	template string
+	// Position of the regular expression inside the template, starting at 1
+	regex int
+}
+
+// NewRedactor is the constructor to create a new Redactor for the policies of the given templates.
+// An error is returned if a template uses an unknown policy.
+func NewRedactor(templates []Analyzer.Template, salt string) (*Redactor, error) {
+	redactor := &Redactor{salt: []byte(salt), templatePolicies: make(map[string]string),
+		regexPolicies: make(map[regexPolicyKey]string)}
+	for _, template := range templates {
+		if !IsValidRedactPolicy(template.Output.Redact) {
+			return nil, fmt.Errorf("template %s: unknown redact policy %q (valid: partial, hash, none)", template.Name,
+				template.Output.Redact)
+		}
+		if template.Output.Redact != "" {
+			redactor.templatePolicies[template.Name] = template.Output.Redact
+		}
+		// The results of a regular expression are identified by its position, as descriptions may repeat
+		for i, regex := range template.Regex {
+			if !IsValidRedactPolicy(regex.Redact) {
+				return nil, fmt.Errorf("template %s: unknown redact policy %q of regex %q (valid: partial, hash, none)",
+					template.Name, regex.Redact, regex.Description)
+			}
+			if regex.Redact != "" {
+				redactor.regexPolicies[regexPolicyKey{template.Name, i + 1}] = regex.Redact
+			}
+		}
+	}
+	return redactor, nil
+}
+
+// policy returns the redaction policy of the result, the policy of the regular expression overrides the one of the template
+func (r *Redactor) policy(result Analyzer.Result) string {
+	if policy, found := r.regexPolicies[regexPolicyKey{result.TemplateName, result.Regex}]; found {
+		return policy
+	}
+	if policy, found := r.templatePolicies[result.TemplateName]; found {
+		return policy
+	}
+	return RedactNone
+}
+
+// hash returns the salted hash of the output
+func (r *Redactor) hash(output string) string {
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(output))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Redact returns a copy of the results with the redaction policies applied.
+// Results which are already redacted are not changed.
+func (r *Redactor) Redact(results []Analyzer.Result) []Analyzer.Result {
+	if results == nil {
+		return nil
+	}
+	redacted := make([]Analyzer.Result, len(results))
+	for i, result := range results {
+		policy := r.policy(result)
+		if policy != RedactNone && result.OutputHash == "" {
+			result.OutputHash = r.hash(result.Output)
+			if policy == RedactHash {
+				result.Output = "sha256:" + result.OutputHash
+			} else {
+				result.Output = redactSecret(result.Output)
+			}
+		}
+		redacted[i] = result
+	}
+	return redacted
+}
+
+// RedactTask returns a copy of the task with the redaction policies applied to its results
+func (r *Redactor) RedactTask(task Analyzer.Task) Analyzer.Task {
+	task.Results = r.Redact(task.Results)
+	return task
+}
+
+// The RedactingResultSink struct applies the redaction policies to the results before they are written into the sink
+type RedactingResultSink struct {
+	IResultSink
+	// The redactor applying the policies
+	redactor *Redactor
+}
+
+// NewRedactingResultSink is the constructor to create a new RedactingResultSink writing into the given sink
+func NewRedactingResultSink(sink IResultSink, redactor *Redactor) *RedactingResultSink {
+	return &RedactingResultSink{IResultSink: sink, redactor: redactor}
+}
+
+// Write redacts the results of the task and writes it into the sink
+func (rs *RedactingResultSink) Write(task Analyzer.Task) error {
+	return rs.IResultSink.Write(rs.redactor.RedactTask(task))
+}
+
+// loadRedactionSalt returns the salt used to hash the redacted outputs.
+// The salt is read from the REDACT_SALT environment variable or config.env, so several results directories
+// can share it. Otherwise, a random salt is generated once and stored inside the results directory.
+func loadRedactionSalt(resultsDir string) (string, error) {
+	if salt := apiToken("REDACT_SALT"); salt != "" {
+		return salt, nil
+	}
+	path := filepath.Join(resultsDir, redactionSaltFile)
+	if Utils.FileExists(path) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	// Generate a new salt
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	salt := hex.EncodeToString(bytes)
+	return salt, os.WriteFile(path, []byte(salt+"\n"), 0600)
+}
+
+// NewTemplateRedactor creates the Redactor for the given templates using the salt of the results directory
+func NewTemplateRedactor(templates []Analyzer.Template, resultsDir string) (*Redactor, error) {
+	salt, err := loadRedactionSalt(resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedactor(templates, salt)
+}
@@ -138,6 +138,17 @@ func (rh *RepoHandler) GetTemplateTasks(repo *git.Repository, templates []Analyz
 			}
 		}
 
+		// Skip the commits outside the commit dates of the template conditions
+		if template.When.CommitAfter != "" || template.When.CommitBefore != "" {
+			var commitsInRange []*object.Commit
+			for _, commit := range commits {
+				if matchesCommitDate(template.When, commit.Committer.When) {
+					commitsInRange = append(commitsInRange, commit)
+				}
+			}
+			commits = commitsInRange
+		}
+
 		// Check if number of maxCommits is reached for the current template
 		if template.MaxCommits > 0 && template.MaxCommits >= len(commits) {
 			// If more than maxCommits are found, reslice to maxCommits
@@ -428,6 +428,32 @@ func (suite *RepoModuleTestSuite) TestGetTemplateTasks() {
 
 }
 
+// TestGetTemplateTasksCommitDates checks that only the commits inside the commit dates of the template are returned
+func (suite *RepoModuleTestSuite) TestGetTemplateTasksCommitDates() {
+	// Create commits of different years
+	var commits []plumbing.Hash
+	for _, date := range []string{"2022-01-01", "2023-06-01", "2024-01-01"} {
+		when, _ := time.Parse("2006-01-02", date)
+		commit, errCommit := suite.worktree.Commit("Commit of "+date, &git.CommitOptions{
+			AllowEmptyCommits: true,
+			Author:            &object.Signature{Name: "John Doe", Email: "john@doe.org", When: when},
+		})
+		if errCommit != nil {
+			log.Fatalln("Error creating commit for test repository:", errCommit.Error())
+		}
+		commits = append(commits, commit)
+	}
+	template := Analyzer.Template{Name: "TestTemplate", Type: "Deep",
+		When: Analyzer.When{CommitAfter: "2023-01-01", CommitBefore: "2024-01-01"}}
+
+	// Call GetTemplateTasks
+	gotTemplateTasks := suite.repoHandler.GetTemplateTasks(suite.repo, []Analyzer.Template{template})
+
+	// Check that only the commit of 2023 is scanned
+	suite.Assertions.Equal([]Analyzer.TemplateTask{{CommitHash: commits[1].String(),
+		Templates: []Analyzer.Template{template}}}, gotTemplateTasks, "TemplateTasks should equal.")
+}
+
 // This functions runs the test suite add a 'go test' command
 func TestRepoModuleTestSuite(t *testing.T) {
 	suite.Run(t, new(RepoModuleTestSuite))
@@ -0,0 +1,459 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// severities contains all severities of the templates ordered from the highest to the lowest
+var severities = []string{"critical", "high", "medium", "low", "info", "unknown"}
+
+// severityRank returns the position of the severity inside the severities, unknown severities are ranked last
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(severities) - 1
+}
+
+// templateSeverity returns the severity of the template in lower case, unknown if the template has no severity
+func templateSeverity(template Analyzer.Template) string {
+	severity := strings.ToLower(strings.TrimSpace(template.Meta.Severity))
+	if severityRank(severity) == len(severities)-1 {
+		return "unknown"
+	}
+	return severity
+}
+
+// reportFinding is a single finding shown inside the report
+type reportFinding struct {
+	Analyzer.Result
+	// Severity of the template of the finding
+	Severity string
+	// Link to the commit of the finding on the forge, empty if unknown
+	CommitURL string
+	// Link to the file of the finding on the forge, empty if unknown
+	FileURL string
+}
+
+// reportRepository contains the findings of a single scanned repository
+type reportRepository struct {
+	// URL of the repository
+	URL string
+	// Time it took to scan the repository
+	ElapsedTime string
+	// Highest severity of the findings of the repository
+	Severity string
+	// The findings of the repository sorted by severity and template
+	Findings []reportFinding
+}
+
+// reportTemplate contains the summary of a single template
+type reportTemplate struct {
+	// Name of the template
+	Name string
+	// Description of the template
+	Description string
+	// Severity of the template
+	Severity string
+	// Meta information of the template like references and mitigation
+	Meta Analyzer.Meta
+	// Number of findings of the template
+	Findings int
+	// Number of repositories with findings of the template
+	Repositories int
+}
+
+// reportCount is the number of findings with a severity
+type reportCount struct {
+	// Name of the severity
+	Severity string
+	// Number of findings
+	Findings int
+}
+
+// reportTiming contains the timing stats of the scanned repositories
+type reportTiming struct {
+	// Sum of the scan times of all repositories
+	Total time.Duration
+	// Average scan time of a repository
+	Average time.Duration
+	// The repositories which took the longest to scan
+	Slowest []Analyzer.Stat
+}
+
+// reportData contains everything shown inside the report
+type reportData struct {
+	// Time the report was generated
+	GeneratedAt string
+	// The scan session of the report, the ID is 0 if the results were written without a session
+	Session Analyzer.ScanSession
+	// Number of scanned repositories
+	Scanned int
+	// Number of findings
+	Findings int
+	// Number of findings per severity, ordered from the highest to the lowest severity
+	Severities []reportCount
+	// Summary of every template with findings
+	Templates []reportTemplate
+	// The repositories with findings, ordered by severity and URL
+	Repositories []reportRepository
+	// Timing stats of the scanned repositories
+	Timing reportTiming
+}
+
+// GenerateReport loads the results of a scan session and writes them into a report file
+func GenerateReport(config Analyzer.ReportConfig) error {
+	if config.Format != "html" && config.Format != "markdown" {
+		return fmt.Errorf("unsupported report format: %s (valid: html, markdown)", config.Format)
+	}
+
+	// Select the scan session, by default the latest one
+	sessions, err := LoadScanSessions(config.ResultsDir)
+	if err != nil {
+		return err
+	}
+	var session Analyzer.ScanSession
+	for _, s := range sessions {
+		if (config.ScanID == 0 && s.ID > session.ID) || s.ID == config.ScanID {
+			session = s
+		}
+	}
+	if config.ScanID != 0 && session.ID != config.ScanID {
+		return fmt.Errorf("unknown scan: %d", config.ScanID)
+	}
+
+	// Load the results of the session
+	results, stats, err := loadSourceScanResults(config.Source, config.ResultsDir, session.ID)
+	if err != nil {
+		return err
+	}
+
+	// Load the templates to show their meta information, invalid templates don't prevent the report
+	templateHandler := &TemplateHandler{Config: Analyzer.Config{ResultsDir: config.ResultsDir,
+		InvalidTemplates: InvalidTemplatesSkip}}
+	if config.TemplatesPath != "" && Utils.FileExists(config.TemplatesPath) {
+		templateHandler.LoadTemplates(config.TemplatesPath)
+	}
+
+	// Write the issues of the repositories
+	if config.Format == "markdown" {
+		outputDir := config.OutputPath
+		if outputDir == "" {
+			outputDir = filepath.Join(config.ResultsDir, "issues")
+		}
+		// Secrets must not be pasted into public issues
+		results = redactSecrets(results, templateHandler.templates)
+		data := buildReport(session, results, stats, templateHandler.templates)
+		if err = writeMarkdownIssues(outputDir, config.MarkdownTemplate, data); err != nil {
+			return err
+		}
+		fmt.Println("Issues written to:", outputDir)
+		return nil
+	}
+
+	outputPath := config.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join(config.ResultsDir, "report.html")
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	data := buildReport(session, results, stats, templateHandler.templates)
+	if err = writeHTMLReport(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	fmt.Println("Report written to:", outputPath)
+	return nil
+}
+
+// buildReport summarizes the results and stats of a scan session for the report
+func buildReport(session Analyzer.ScanSession, results []Analyzer.Result, stats []Analyzer.Stat,
+	templates []Analyzer.Template) reportData {
+	data := reportData{GeneratedAt: time.Now().Format(time.RFC3339), Session: session, Findings: len(results)}
+
+	// Map template name => template
+	templatesByName := make(map[string]Analyzer.Template)
+	for _, template := range templates {
+		templatesByName[template.Name] = template
+	}
+
+	// Group the findings by repository and template
+	repositories := make(map[string]*reportRepository)
+	summaries := make(map[string]*reportTemplate)
+	affected := make(map[string]map[string]bool)
+	severityCounts := make(map[string]int)
+	for _, result := range results {
+		template, found := templatesByName[result.TemplateName]
+		if !found {
+			template = Analyzer.Template{Name: result.TemplateName}
+		}
+		severity := templateSeverity(template)
+		severityCounts[severity]++
+
+		summary, found := summaries[template.Name]
+		if !found {
+			summary = &reportTemplate{Name: template.Name, Description: template.Description, Severity: severity,
+				Meta: template.Meta}
+			summaries[template.Name] = summary
+			affected[template.Name] = make(map[string]bool)
+		}
+		summary.Findings++
+		if !affected[template.Name][result.URL] {
+			affected[template.Name][result.URL] = true
+			summary.Repositories++
+		}
+
+		repository, found := repositories[result.URL]
+		if !found {
+			repository = &reportRepository{URL: result.URL, Severity: severity}
+			repositories[result.URL] = repository
+		}
+		if severityRank(severity) < severityRank(repository.Severity) {
+			repository.Severity = severity
+		}
+		commitURL, fileURL := forgeLinks(result.URL, result.CommitHash, result.Path, result.Line)
+		repository.Findings = append(repository.Findings, reportFinding{Result: result, Severity: severity,
+			CommitURL: commitURL, FileURL: fileURL})
+	}
+
+	// Count the findings per severity
+	for _, severity := range severities {
+		if severityCounts[severity] > 0 {
+			data.Severities = append(data.Severities, reportCount{Severity: severity, Findings: severityCounts[severity]})
+		}
+	}
+
+	// Sort the templates by severity and name
+	for _, summary := range summaries {
+		data.Templates = append(data.Templates, *summary)
+	}
+	sort.Slice(data.Templates, func(i, j int) bool {
+		a, b := data.Templates[i], data.Templates[j]
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		return a.Name < b.Name
+	})
+
+	// Add the timing stats, the scanned repositories without a stat are counted as well
+	scanned := scannedRepositories(results, stats)
+	data.Scanned = len(scanned)
+	data.Timing = timingStats(stats)
+	elapsedTimes := make(map[string]string)
+	for _, stat := range stats {
+		elapsedTimes[stat.URL] = stat.ElapsedTime
+	}
+
+	// Sort the repositories by severity and URL, their findings by severity, template and path
+	for _, repository := range repositories {
+		repository.ElapsedTime = elapsedTimes[repository.URL]
+		sort.SliceStable(repository.Findings, func(i, j int) bool {
+			a, b := repository.Findings[i], repository.Findings[j]
+			if severityRank(a.Severity) != severityRank(b.Severity) {
+				return severityRank(a.Severity) < severityRank(b.Severity)
+			}
+			return findingKey(a.Result) < findingKey(b.Result)
+		})
+		data.Repositories = append(data.Repositories, *repository)
+	}
+	sort.Slice(data.Repositories, func(i, j int) bool {
+		a, b := data.Repositories[i], data.Repositories[j]
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) < severityRank(b.Severity)
+		}
+		return a.URL < b.URL
+	})
+	return data
+}
+
+// timingStats calculates the total and average scan time and the slowest repositories.
+// Stats with an invalid elapsed time are ignored.
+func timingStats(stats []Analyzer.Stat) reportTiming {
+	var timing reportTiming
+	var timed []Analyzer.Stat
+	durations := make(map[string]time.Duration)
+	for _, stat := range stats {
+		duration, err := time.ParseDuration(stat.ElapsedTime)
+		if err != nil {
+			continue
+		}
+		timing.Total += duration
+		durations[stat.URL] = duration
+		timed = append(timed, stat)
+	}
+	if len(timed) == 0 {
+		return timing
+	}
+	timing.Average = timing.Total / time.Duration(len(timed))
+
+	// Keep the ten slowest repositories
+	sort.SliceStable(timed, func(i, j int) bool { return durations[timed[i].URL] > durations[timed[j].URL] })
+	if len(timed) > 10 {
+		timed = timed[:10]
+	}
+	timing.Slowest = timed
+	return timing
+}
+
+// forgeLinks returns the links to the commit and the file of a finding on the forge hosting the repository.
+// GitHub, GitLab and Bitbucket are supported, empty links are returned if a link can't be built.
+// The file link points to the given line, if it is set.
+func forgeLinks(repoURL, commitHash, filePath string, line int) (commitURL, fileURL string) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || commitHash == "" {
+		return "", ""
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+
+	// Select the URL layout of the forge
+	commitPath, filePrefix, lineAnchor := "/commit/", "/blob/", "#L"
+	switch {
+	case strings.Contains(parsed.Host, "gitlab"):
+		commitPath, filePrefix = "/-/commit/", "/-/blob/"
+	case strings.Contains(parsed.Host, "bitbucket"):
+		commitPath, filePrefix, lineAnchor = "/commits/", "/src/", "#lines-"
+	}
+	commitURL = base + commitPath + commitHash
+
+	// Only paths inside the repository can be linked
+	if filePath == "" || filePath == "." || path.IsAbs(filePath) || filepath.IsAbs(filePath) || strings.HasPrefix(filePath, "..") {
+		return commitURL, ""
+	}
+	segments := strings.Split(strings.TrimPrefix(filepath.ToSlash(filePath), "./"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	fileURL = base + filePrefix + commitHash + "/" + strings.Join(segments, "/")
+	if line > 0 {
+		fileURL += lineAnchor + strconv.Itoa(line)
+	}
+	return commitURL, fileURL
+}
+
+// writeHTMLReport writes the report as self-contained static HTML page
+func writeHTMLReport(writer io.Writer, data reportData) error {
+	tmpl, err := template.New("report").Parse(htmlReportTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(writer, data)
+}
+
+// htmlReportTemplate is the template of the HTML report, styles are inlined so the report has no dependencies
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>gitAnalyzer report{{if .Session.ID}} - scan {{.Session.ID}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1, h2 { border-bottom: 1px solid #ddd; padding-bottom: .3em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ddd; padding: .4em .8em; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+code { font-family: Consolas, monospace; word-break: break-all; }
+details { margin-bottom: .5em; border: 1px solid #ddd; border-radius: 4px; padding: .5em; }
+summary { cursor: pointer; font-weight: bold; }
+.severity { display: inline-block; min-width: 5em; padding: .1em .5em; border-radius: 3px; color: #fff; text-align: center; }
+.critical { background: #7b1fa2; } .high { background: #d32f2f; } .medium { background: #f57c00; }
+.low { background: #fbc02d; color: #222; } .info { background: #1976d2; } .unknown { background: #757575; }
+</style>
+</head>
+<body>
+<h1>gitAnalyzer report</h1>
+<table>
+{{- if .Session.ID}}
+<tr><th>Scan</th><td>{{.Session.ID}}</td></tr>
+<tr><th>Started</th><td>{{.Session.StartedAt}}</td></tr>
+<tr><th>Finished</th><td>{{if .Session.FinishedAt}}{{.Session.FinishedAt}}{{else}}not finished{{end}}</td></tr>
+{{- end}}
+<tr><th>Generated</th><td>{{.GeneratedAt}}</td></tr>
+<tr><th>Scanned repositories</th><td>{{.Scanned}}</td></tr>
+<tr><th>Repositories with findings</th><td>{{len .Repositories}}</td></tr>
+<tr><th>Findings</th><td>{{.Findings}}</td></tr>
+<tr><th>Total scan time</th><td>{{.Timing.Total}}</td></tr>
+<tr><th>Average scan time</th><td>{{.Timing.Average}}</td></tr>
+</table>
+
+<h2>Severities</h2>
+<table>
+<tr><th>Severity</th><th>Findings</th></tr>
+{{- range .Severities}}
+<tr><td><span class="severity {{.Severity}}">{{.Severity}}</span></td><td>{{.Findings}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Templates</h2>
+<table>
+<tr><th>Severity</th><th>Template</th><th>Findings</th><th>Repositories</th><th>Details</th></tr>
+{{- range .Templates}}
+<tr>
+<td><span class="severity {{.Severity}}">{{.Severity}}</span></td>
+<td>{{.Name}}{{if .Description}}<br><small>{{.Description}}</small>{{end}}</td>
+<td>{{.Findings}}</td>
+<td>{{.Repositories}}</td>
+<td>
+{{- if .Meta.Impact}}<p><b>Impact:</b> {{.Meta.Impact}}</p>{{end}}
+{{- if .Meta.CVE}}<p><b>CVE:</b> {{.Meta.CVE}}</p>{{end}}
+{{- if .Meta.CWE}}<p><b>CWE:</b> {{.Meta.CWE}}</p>{{end}}
+{{- if .Meta.CVSS}}<p><b>CVSS:</b> {{.Meta.CVSS}}</p>{{end}}
+{{- if .Meta.Mitigation}}<p><b>Mitigation:</b> {{.Meta.Mitigation}}</p>{{end}}
+{{- if .Meta.References}}<p><b>References:</b></p><ul>{{range .Meta.References}}<li><a href="{{.}}">{{.}}</a></li>{{end}}</ul>{{end}}
+</td>
+</tr>
+{{- end}}
+</table>
+
+<h2>Repositories</h2>
+{{- range .Repositories}}
+<details>
+<summary><span class="severity {{.Severity}}">{{.Severity}}</span> {{.URL}} ({{len .Findings}} findings{{if .ElapsedTime}}, scanned in {{.ElapsedTime}}{{end}})</summary>
+<p><a href="{{.URL}}">{{.URL}}</a></p>
+<table>
+<tr><th>Severity</th><th>Template</th><th>File</th><th>Commit</th><th>Description</th><th>Output</th></tr>
+{{- range .Findings}}
+<tr>
+<td><span class="severity {{.Severity}}">{{.Severity}}</span></td>
+<td>{{.TemplateName}}</td>
+<td>{{if .FileURL}}<a href="{{.FileURL}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}{{if .Line}}:{{.Line}}{{end}}</td>
+<td>{{if .CommitURL}}<a href="{{.CommitURL}}"><code>{{.CommitHash}}</code></a>{{else}}<code>{{.CommitHash}}</code>{{end}}</td>
+<td>{{.Description}}</td>
+<td><code>{{.Output}}</code></td>
+</tr>
+{{- end}}
+</table>
+</details>
+{{- end}}
+
+{{- if .Timing.Slowest}}
+<h2>Slowest repositories</h2>
+<table>
+<tr><th>Repository</th><th>Scan time</th></tr>
+{{- range .Timing.Slowest}}
+<tr><td>{{.URL}}</td><td>{{.ElapsedTime}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`
@@ -0,0 +1,152 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Create test suite for the report module
+type ReportModuleTestSuite struct {
+	suite.Suite
+	tempDir    string
+	fileHelper *FileHandler
+	templates  []Analyzer.Template
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *ReportModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	// Initialize the fileHelper with the temporary directory as the result dir
+	suite.fileHelper = &FileHandler{Analyzer.Config{ResultsDir: suite.tempDir}}
+	// Initialize templates with different severities
+	suite.templates = []Analyzer.Template{
+		{Name: "secrets", Meta: Analyzer.Meta{Severity: "High", Mitigation: "Revoke the token.",
+			References: []string{"https://example.com/secrets"}}},
+		{Name: "outdated", Meta: Analyzer.Meta{Severity: "low"}},
+	}
+}
+
+// TestForgeLinks checks the links to commits and files of the supported forges
+func (suite *ReportModuleTestSuite) TestForgeLinks() {
+	commitURL, fileURL := forgeLinks("https://github.com/owner/repo", "abc", "dir/my file.txt", 0)
+	suite.Assertions.Equal("https://github.com/owner/repo/commit/abc", commitURL, "GitHub commit URL should equal.")
+	suite.Assertions.Equal("https://github.com/owner/repo/blob/abc/dir/my%20file.txt", fileURL, "GitHub file URL should equal.")
+
+	commitURL, fileURL = forgeLinks("https://gitlab.com/group/sub/repo.git", "abc", "a.txt", 0)
+	suite.Assertions.Equal("https://gitlab.com/group/sub/repo/-/commit/abc", commitURL, "GitLab commit URL should equal.")
+	suite.Assertions.Equal("https://gitlab.com/group/sub/repo/-/blob/abc/a.txt", fileURL, "GitLab file URL should equal.")
+
+	commitURL, fileURL = forgeLinks("https://bitbucket.org/owner/repo", "abc", "a.txt", 0)
+	suite.Assertions.Equal("https://bitbucket.org/owner/repo/commits/abc", commitURL, "Bitbucket commit URL should equal.")
+	suite.Assertions.Equal("https://bitbucket.org/owner/repo/src/abc/a.txt", fileURL, "Bitbucket file URL should equal.")
+
+	// The file links point to the line of the finding
+	_, fileURL = forgeLinks("https://github.com/owner/repo", "abc", "a.txt", 12)
+	suite.Assertions.Equal("https://github.com/owner/repo/blob/abc/a.txt#L12", fileURL, "GitHub line URL should equal.")
+	_, fileURL = forgeLinks("https://bitbucket.org/owner/repo", "abc", "a.txt", 12)
+	suite.Assertions.Equal("https://bitbucket.org/owner/repo/src/abc/a.txt#lines-12", fileURL, "Bitbucket line URL should equal.")
+
+	// Paths outside of the repository and unknown commits can't be linked
+	commitURL, fileURL = forgeLinks("https://github.com/owner/repo", "abc", "/tmp/clone/a.txt", 0)
+	suite.Assertions.Equal("https://github.com/owner/repo/commit/abc", commitURL, "Commit URL should equal.")
+	suite.Assertions.Empty(fileURL, "Absolute paths should not be linked.")
+	commitURL, fileURL = forgeLinks("https://github.com/owner/repo", "", "a.txt", 0)
+	suite.Assertions.Empty(commitURL+fileURL, "Findings without commit should not be linked.")
+	commitURL, fileURL = forgeLinks("git@github.com:owner/repo.git", "abc", "a.txt", 0)
+	suite.Assertions.Empty(commitURL+fileURL, "SSH URLs should not be linked.")
+
+	// The paths of the clone are stored relative to the repository, so they can be linked
+	clonePath := filepath.Join("repos", "owner", "repo")
+	relativePath := repositoryRelativePath(clonePath, filepath.Join(clonePath, "src", "a.go"))
+	suite.Assertions.Equal("src/a.go", relativePath, "Relative path should equal.")
+	_, fileURL = forgeLinks("https://github.com/owner/repo", "abc", relativePath, 3)
+	suite.Assertions.Equal("https://github.com/owner/repo/blob/abc/src/a.go#L3", fileURL, "Clone file URL should equal.")
+	_, fileURL = forgeLinks("https://github.com/owner/repo", "abc", repositoryRelativePath(clonePath, clonePath), 0)
+	suite.Assertions.Empty(fileURL, "Root of the repository should not be linked.")
+	suite.Assertions.Equal("/tmp/a.txt", repositoryRelativePath(clonePath, "/tmp/a.txt"),
+		"Paths outside of the repository should be kept.")
+}
+
+// TestBuildReport checks that the findings are summarized by template, severity and repository
+func (suite *ReportModuleTestSuite) TestBuildReport() {
+	results := []Analyzer.Result{
+		{TemplateName: "outdated", URL: "repo1", Output: "lib"},
+		{TemplateName: "secrets", URL: "repo1", Output: "key1"},
+		{TemplateName: "secrets", URL: "repo2", Output: "key2"},
+		{TemplateName: "removed", URL: "repo2", Output: "unknown"},
+	}
+	stats := []Analyzer.Stat{{URL: "repo1", ElapsedTime: "1s"}, {URL: "repo2", ElapsedTime: "3s"},
+		{URL: "repo3", ElapsedTime: "invalid"}}
+
+	data := buildReport(Analyzer.ScanSession{ID: 1}, results, stats, suite.templates)
+	suite.Assertions.Equal(3, data.Scanned, "Scanned repositories should equal.")
+	suite.Assertions.Equal(4, data.Findings, "Findings should equal.")
+	suite.Assertions.Equal([]reportCount{{"high", 2}, {"low", 1}, {"unknown", 1}}, data.Severities,
+		"Findings per severity should equal.")
+
+	suite.Assertions.Len(data.Templates, 3, "Every template with findings should be summarized.")
+	suite.Assertions.Equal("secrets", data.Templates[0].Name, "Templates should be sorted by severity.")
+	suite.Assertions.Equal(2, data.Templates[0].Repositories, "Affected repositories should equal.")
+	suite.Assertions.Equal("Revoke the token.", data.Templates[0].Meta.Mitigation, "Mitigation should equal.")
+
+	suite.Assertions.Len(data.Repositories, 2, "Only repositories with findings should be listed.")
+	suite.Assertions.Equal("high", data.Repositories[0].Severity, "Highest severity should equal.")
+	suite.Assertions.Equal("key1", data.Repositories[0].Findings[0].Output, "Findings should be sorted by severity.")
+	suite.Assertions.Equal("3s", data.Repositories[1].ElapsedTime, "Elapsed time should equal.")
+
+	suite.Assertions.Equal(4*time.Second, data.Timing.Total, "Total time should equal.")
+	suite.Assertions.Equal(2*time.Second, data.Timing.Average, "Average time should equal.")
+	suite.Assertions.Equal("repo2", data.Timing.Slowest[0].URL, "Slowest repository should equal.")
+}
+
+// TestGenerateReport checks that the report of the latest scan is written from the CSV results
+func (suite *ReportModuleTestSuite) TestGenerateReport() {
+	// Write the results of two scans
+	for _, output := range []string{"oldSecret", "newSecret"} {
+		session, err := StartScanSession(suite.tempDir, Analyzer.Config{}, nil)
+		suite.Assertions.NoError(err, "Starting the session should not fail.")
+		sink := NewCSVResultSink(suite.fileHelper)
+		suite.Assertions.NoError(sink.Open(session), "Opening the sink should not fail.")
+		suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "https://github.com/owner/repo", ElapsedTime: "1s",
+			Results: []Analyzer.Result{{TemplateName: "secrets", URL: "https://github.com/owner/repo", CommitHash: "abc",
+				Path: "config.env", Output: output}}}), "Writing the task should not fail.")
+		suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	}
+
+	// Write a template with meta information
+	templatesPath := filepath.Join(suite.tempDir, "templates")
+	suite.Assertions.NoError(os.Mkdir(templatesPath, 0755), "Creating the template directory should not fail.")
+	suite.Assertions.NoError(os.WriteFile(filepath.Join(templatesPath, "secrets.yaml"),
+		[]byte("name: secrets\nregex:\n  - expression: secret=(.*)\n    group: 1\n    file_endings: [\".env\"]\n"+
+			"meta:\n  severity: critical\n  mitigation: Rotate <the> secret.\n"), 0644),
+		"Writing the template should not fail.")
+
+	output := filepath.Join(suite.tempDir, "report.html")
+	suite.Assertions.NoError(GenerateReport(Analyzer.ReportConfig{Format: "html", ResultsDir: suite.tempDir,
+		TemplatesPath: templatesPath}), "Generating the report should not fail.")
+	content, err := os.ReadFile(output)
+	suite.Assertions.NoError(err, "Reading the report should not fail.")
+	suite.Assertions.Contains(string(content), "newSecret", "Findings of the latest scan should be shown.")
+	suite.Assertions.NotContains(string(content), "oldSecret", "Findings of older scans should not be shown.")
+	suite.Assertions.Contains(string(content), `href="https://github.com/owner/repo/blob/abc/config.env"`,
+		"File should be linked.")
+	suite.Assertions.Contains(string(content), "Rotate &lt;the&gt; secret.", "Mitigation should be escaped.")
+	suite.Assertions.Contains(string(content), `class="severity critical"`, "Severity should be shown.")
+
+	// Unknown scans and formats are rejected
+	suite.Assertions.Error(GenerateReport(Analyzer.ReportConfig{Format: "html", ResultsDir: suite.tempDir, ScanID: 3}),
+		"Unknown scans should be rejected.")
+	suite.Assertions.Error(GenerateReport(Analyzer.ReportConfig{Format: "pdf", ResultsDir: suite.tempDir}),
+		"Unknown formats should be rejected.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestReportModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(ReportModuleTestSuite))
+}
@@ -0,0 +1,422 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/gocarina/gocsv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resultSinkFlushInterval is the interval the buffered results are written to the sinks during a scan
+const resultSinkFlushInterval = 5 * time.Second
+
+// IResultSink the interface is used to define a destination for the results of a scan.
+// Open is called once with the session of the scan before the first Write and Close once after the last Write.
+// The sinks stamp the ID of the session on every written result.
+// Written tasks may be buffered until Flush or Close is called.
+type IResultSink interface {
+	Open(session Analyzer.ScanSession) error
+	Write(task Analyzer.Task) error
+	Flush() error
+	Close() error
+}
+
+// NewResultSink creates the sinks with the given names (comma separated) e.g. "csv,jsonl".
+// The sinks store their files inside the results directory.
+func NewResultSink(names string, resultsDir string, fileHelper IFileHelper) (IResultSink, error) {
+	var sinks MultiResultSink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case "csv":
+			sinks = append(sinks, NewCSVResultSink(fileHelper))
+		case "jsonl":
+			sinks = append(sinks, NewJSONLResultSink(filepath.Join(resultsDir, "results.jsonl")))
+		case "sqlite":
+			sinks = append(sinks, NewSQLResultSink("sqlite3", sqliteResultDSN(resultsDir)))
+		case "mysql":
+			// Use the database of the crawler configured in config.env
+			sinks = append(sinks, NewSQLResultSink("mysql", mysqlResultDSN()))
+		default:
+			return nil, fmt.Errorf("unknown result sink: %s (valid: csv, jsonl, sqlite, mysql)", name)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no result sink provided")
+	}
+	return sinks, nil
+}
+
+// The MultiResultSink type writes the results into several sinks at once
+type MultiResultSink []IResultSink
+
+// Open opens all sinks, if one sink fails the already opened sinks are closed again
+func (ms MultiResultSink) Open(session Analyzer.ScanSession) error {
+	for i, sink := range ms {
+		if err := sink.Open(session); err != nil {
+			ms[:i].Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes the task into all sinks, the first error is returned
+func (ms MultiResultSink) Write(task Analyzer.Task) error {
+	var firstErr error
+	for _, sink := range ms {
+		if err := sink.Write(task); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flush flushes all sinks, the first error is returned
+func (ms MultiResultSink) Flush() error {
+	var firstErr error
+	for _, sink := range ms {
+		if err := sink.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes all sinks, the first error is returned
+func (ms MultiResultSink) Close() error {
+	var firstErr error
+	for _, sink := range ms {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// csvSinkFile is an opened CSV file of the CSVResultSink
+type csvSinkFile struct {
+	// The opened file
+	file *os.File
+	// Buffered writer of the file
+	writer *bufio.Writer
+	// hasHeader is true if the header line was already written
+	hasHeader bool
+}
+
+// The CSVResultSink struct writes the results into one CSV file per template
+// and the stats of the finished tasks into the checked.csv file.
+// The files are kept open until the sink is closed.
+type CSVResultSink struct {
+	// FileHelper to get the paths of the CSV files
+	fileHelper IFileHelper
+	// ID of the scan session
+	scanID int
+	// Map file name => opened file
+	files map[string]*csvSinkFile
+}
+
+// NewCSVResultSink is the constructor to create a new CSVResultSink, using the provided IFileHelper to get the paths.
+func NewCSVResultSink(fileHelper IFileHelper) *CSVResultSink {
+	return &CSVResultSink{fileHelper: fileHelper}
+}
+
+// Open prepares the sink, the files are opened when the first row is written into them
+func (cs *CSVResultSink) Open(session Analyzer.ScanSession) error {
+	cs.scanID = session.ID
+	cs.files = make(map[string]*csvSinkFile)
+	return nil
+}
+
+// Write appends the results of the task to the files of their templates and the stat of the task to checked.csv
+func (cs *CSVResultSink) Write(task Analyzer.Task) error {
+	// Group the results by template, so every file is written once
+	var templateNames []string
+	resultsPerTemplate := make(map[string][]Analyzer.Result)
+	for _, result := range stampResults(task.Results, cs.scanID) {
+		if _, found := resultsPerTemplate[result.TemplateName]; !found {
+			templateNames = append(templateNames, result.TemplateName)
+		}
+		resultsPerTemplate[result.TemplateName] = append(resultsPerTemplate[result.TemplateName], result)
+	}
+	for _, templateName := range templateNames {
+		if err := cs.write(templateName, resultsPerTemplate[templateName]); err != nil {
+			return err
+		}
+	}
+
+	// Mark the task as checked
+	return cs.write("checked", []Analyzer.Stat{{URL: task.URL, ElapsedTime: task.ElapsedTime, ScanID: cs.scanID}})
+}
+
+// write marshals the given rows into the file with the given name.
+// The header is only written into empty files.
+func (cs *CSVResultSink) write(name string, rows interface{}) error {
+	file, err := cs.open(name)
+	if err != nil {
+		return err
+	}
+	// Hide the bufio.Writer from the csv package, otherwise it is reused and flushed after every marshal
+	writer := struct{ io.Writer }{file.writer}
+	if file.hasHeader {
+		err = gocsv.MarshalWithoutHeaders(rows, writer)
+	} else {
+		err = gocsv.Marshal(rows, writer)
+		file.hasHeader = true
+	}
+	return err
+}
+
+// open returns the opened file with the given name, the file is opened if necessary
+func (cs *CSVResultSink) open(name string) (*csvSinkFile, error) {
+	if file, found := cs.files[name]; found {
+		return file, nil
+	}
+	path := cs.fileHelper.GetResultCSVPath(name)
+	// Files written by older versions may miss some columns
+	var rows interface{} = &[]Analyzer.Result{}
+	if name == "checked" {
+		rows = &[]Analyzer.Stat{}
+	}
+	if err := upgradeCSVHeader(path, rows); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// Files with content already have a header
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	sinkFile := &csvSinkFile{file: file, writer: bufio.NewWriter(file), hasHeader: fileInfo.Size() > 0}
+	cs.files[name] = sinkFile
+	return sinkFile, nil
+}
+
+// upgradeCSVHeader rewrites the file with the current header of the rows, if the header of the file differs.
+// rows must be a pointer to an empty slice of the type stored inside the file.
+// The rows are written into a temporary file, which replaces the file, so an interrupted upgrade keeps the old file.
+func upgradeCSVHeader(path string, rows interface{}) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Read the current header of the file
+	reader := bufio.NewReader(file)
+	header, err := reader.ReadString('\n')
+	if err == io.EOF && header == "" {
+		// Empty file
+		return nil
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+	expected, err := gocsv.MarshalString(rows)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(header) == strings.TrimSpace(expected) {
+		return nil
+	}
+
+	// Load the rows with the old header, missing columns stay empty
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err = gocsv.UnmarshalFile(file, rows); err != nil {
+		return err
+	}
+	// Write the rows again with the current header next to the file and replace it
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err = gocsv.MarshalFile(rows, tempFile); err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tempFile.Name())
+	}
+	return err
+}
+
+// Flush writes the buffered rows of all files
+func (cs *CSVResultSink) Flush() error {
+	var firstErr error
+	for _, file := range cs.files {
+		if err := file.writer.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close writes the buffered rows and closes all files
+func (cs *CSVResultSink) Close() error {
+	firstErr := cs.Flush()
+	for name, file := range cs.files {
+		if err := file.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(cs.files, name)
+	}
+	return firstErr
+}
+
+// The JSONLResultSink struct writes every result as json object into a single JSONL file
+type JSONLResultSink struct {
+	// Path of the JSONL file
+	path string
+	// The opened file
+	file *os.File
+	// Buffered writer of the file
+	writer *bufio.Writer
+	// ID of the scan session
+	scanID int
+}
+
+// NewJSONLResultSink is the constructor to create a new JSONLResultSink writing into the file with the given path
+func NewJSONLResultSink(path string) *JSONLResultSink {
+	return &JSONLResultSink{path: path}
+}
+
+// Open opens the JSONL file, new results are appended to the file
+func (js *JSONLResultSink) Open(session Analyzer.ScanSession) error {
+	js.scanID = session.ID
+	file, err := os.OpenFile(js.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	js.file = file
+	js.writer = bufio.NewWriter(file)
+	return nil
+}
+
+// Write appends one line per result of the task
+func (js *JSONLResultSink) Write(task Analyzer.Task) error {
+	encoder := json.NewEncoder(js.writer)
+	for _, result := range stampResults(task.Results, js.scanID) {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the buffered lines into the file
+func (js *JSONLResultSink) Flush() error {
+	return js.writer.Flush()
+}
+
+// Close writes the buffered lines and closes the file
+func (js *JSONLResultSink) Close() error {
+	if js.file == nil {
+		return nil
+	}
+	err := js.writer.Flush()
+	if errClose := js.file.Close(); err == nil {
+		err = errClose
+	}
+	js.file = nil
+	return err
+}
+
+// memoryResultSinkCapacity is the number of results kept by the MemoryResultSink of a scan
+const memoryResultSinkCapacity = 10000
+
+// The MemoryResultSink struct keeps the newest written results in memory, e.g. to serve them via the results API.
+// Once the capacity is reached, the oldest results are dropped, so large scans don't run out of memory.
+type MemoryResultSink struct {
+	// Mutex to protect the results, which are read while the scan is running
+	mutex sync.Mutex
+	// ID of the scan session
+	scanID int
+	// Maximum number of kept results
+	capacity int
+	// The newest written results
+	results []Analyzer.Result
+}
+
+// NewMemoryResultSink is the constructor to create a new MemoryResultSink keeping at most capacity results
+func NewMemoryResultSink(capacity int) *MemoryResultSink {
+	return &MemoryResultSink{capacity: capacity}
+}
+
+// Open removes the results of a previous scan
+func (ms *MemoryResultSink) Open(session Analyzer.ScanSession) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.scanID = session.ID
+	ms.results = nil
+	return nil
+}
+
+// Write keeps the results of the task
+func (ms *MemoryResultSink) Write(task Analyzer.Task) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.results = append(ms.results, stampResults(task.Results, ms.scanID)...)
+	if dropped := len(ms.results) - ms.capacity; dropped > 0 {
+		// Copy the kept results, so the memory of the dropped ones is released
+		ms.results = append([]Analyzer.Result{}, ms.results[dropped:]...)
+	}
+	return nil
+}
+
+// Flush does nothing, the results are not buffered
+func (ms *MemoryResultSink) Flush() error {
+	return nil
+}
+
+// Close does nothing, the results stay available after the scan
+func (ms *MemoryResultSink) Close() error {
+	return nil
+}
+
+// Results returns a copy of all kept results
+func (ms *MemoryResultSink) Results() []Analyzer.Result {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	return append([]Analyzer.Result{}, ms.results...)
+}
+
+// Page returns a copy of at most limit kept results starting at the offset and the number of all kept results
+func (ms *MemoryResultSink) Page(offset, limit int) ([]Analyzer.Result, int) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	total := len(ms.results)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return append([]Analyzer.Result{}, ms.results[offset:end]...), total
+}
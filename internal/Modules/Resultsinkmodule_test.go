@@ -0,0 +1,147 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"encoding/json"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create test suite for the result sink module
+type ResultSinkModuleTestSuite struct {
+	suite.Suite
+	tempDir    string
+	fileHelper *FileHandler
+	task       Analyzer.Task
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *ResultSinkModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	// Initialize the fileHelper with the temporary directory as the result dir
+	suite.fileHelper = &FileHandler{Analyzer.Config{ResultsDir: suite.tempDir}}
+	// Initialize a finished task with results of two templates
+	suite.task = Analyzer.Task{URL: "testURL", ElapsedTime: "1s", State: "finished", Results: []Analyzer.Result{
+		{TemplateName: "first", URL: "testURL", Output: "output1", Metadata: Analyzer.Metadata{"team": "security"}},
+		{TemplateName: "second", URL: "testURL", Output: "output2"},
+		{TemplateName: "first", URL: "testURL", Output: "output3"},
+	}}
+}
+
+// TestCSVResultSink checks that the results are buffered until flushed and appended to existing files
+func (suite *ResultSinkModuleTestSuite) TestCSVResultSink() {
+	sink := NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(suite.task), "Writing the task should not fail.")
+
+	// Nothing is written before the flush
+	content, _ := os.ReadFile(suite.fileHelper.GetResultCSVPath("first"))
+	suite.Assertions.Empty(content, "Results should be buffered.")
+	suite.Assertions.NoError(sink.Flush(), "Flushing the sink should not fail.")
+	suite.Assertions.Len(suite.fileHelper.UnMarshallResults("first"), 2, "Results of the first template should be written.")
+	suite.Assertions.Len(suite.fileHelper.UnMarshallResults("second"), 1, "Results of the second template should be written.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	// Reopen the sink, the results are appended without a second header
+	sink = NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(suite.task), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	results := suite.fileHelper.UnMarshallResults("first")
+	suite.Assertions.Len(results, 4, "Results should be appended.")
+	suite.Assertions.Equal(Analyzer.Metadata{"team": "security"}, results[0].Metadata, "Metadata should equal.")
+	stats := suite.fileHelper.UnMarshallStats(suite.fileHelper.GetResultCSVPath("checked"))
+	suite.Assertions.Equal([]Analyzer.Stat{{URL: "testURL", ElapsedTime: "1s", ScanID: 1},
+		{URL: "testURL", ElapsedTime: "1s", ScanID: 1}}, stats, "Stats should equal.")
+	suite.Assertions.Equal(1, results[0].ScanID, "Scan ID should equal.")
+}
+
+// TestCSVResultSinkMultilineOutput checks that multiline outputs and escaped line breaks are kept
+func (suite *ResultSinkModuleTestSuite) TestCSVResultSinkMultilineOutput() {
+	task := Analyzer.Task{URL: "testURL", Results: []Analyzer.Result{
+		{TemplateName: "keys", URL: "testURL", Output: "-----BEGIN KEY-----\nMIIE\n-----END KEY-----"},
+		{TemplateName: "keys", URL: "testURL", Output: `key = "-----BEGIN KEY-----\nMIIE"`}}}
+	sink := NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(task), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	results := suite.fileHelper.UnMarshallResults("keys")
+	suite.Assertions.Len(results, 2, "Results should be loaded.")
+	suite.Assertions.Equal(task.Results[0].Output, results[0].Output, "Multiline output should equal.")
+	suite.Assertions.Equal(task.Results[1].Output, results[1].Output, "Output with escaped line break should equal.")
+	suite.Assertions.NotEqual(findingKey(results[0]), findingKey(results[1]), "Findings should be different.")
+	suite.Assertions.Equal(findingKey(Analyzer.Result{Output: "a\r\nb"}), findingKey(Analyzer.Result{Output: "a\nb"}),
+		"Carriage returns should be ignored.")
+}
+
+// TestCSVResultSinkUpgradeHeader checks that files written by older versions get the current header
+func (suite *ResultSinkModuleTestSuite) TestCSVResultSinkUpgradeHeader() {
+	// Write a file with the header of an older version
+	path := suite.fileHelper.GetResultCSVPath("first")
+	suite.Assertions.NoError(os.WriteFile(path,
+		[]byte("template,url,commitHash,timestamp,path,description,output\nfirst,oldURL,abc,,a.txt,,old\n"), 0644),
+		"Writing the old file should not fail.")
+
+	sink := NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 2}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(suite.task), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	results := suite.fileHelper.UnMarshallResults("first")
+	suite.Assertions.Len(results, 3, "Old results should be kept.")
+	suite.Assertions.Equal("old", results[0].Output, "Output of the old result should equal.")
+	suite.Assertions.Equal(0, results[0].ScanID, "Old result should have no scan ID.")
+	suite.Assertions.Equal(Analyzer.Metadata{"team": "security"}, results[1].Metadata, "Metadata should equal.")
+	suite.Assertions.Equal(2, results[1].ScanID, "Scan ID should equal.")
+	tempFiles, err := filepath.Glob(path + ".*.tmp")
+	suite.Assertions.NoError(err, "Listing the temporary files should not fail.")
+	suite.Assertions.Empty(tempFiles, "Temporary file should be renamed.")
+}
+
+// TestJSONLResultSink checks that every result is written as one json line
+func (suite *ResultSinkModuleTestSuite) TestJSONLResultSink() {
+	path := filepath.Join(suite.tempDir, "results.jsonl")
+	sink := NewJSONLResultSink(path)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(suite.task), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	content, err := os.ReadFile(path)
+	suite.Assertions.NoError(err, "Reading the JSONL file should not fail.")
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	suite.Assertions.Len(lines, 3, "Every result should be one line.")
+	var result Analyzer.Result
+	suite.Assertions.NoError(json.Unmarshal([]byte(lines[0]), &result), "Line should be valid json.")
+	expected := suite.task.Results[0]
+	expected.ScanID = 1
+	suite.Assertions.Equal(expected, result, "Results should equal.")
+}
+
+// TestNewResultSink checks that several sinks are written at once and unknown sinks are rejected
+func (suite *ResultSinkModuleTestSuite) TestNewResultSink() {
+	sink, err := NewResultSink("csv, jsonl", suite.tempDir, suite.fileHelper)
+	suite.Assertions.NoError(err, "Creating the sinks should not fail.")
+	suite.Assertions.Len(sink, 2, "Should create 2 sinks.")
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sinks should not fail.")
+	suite.Assertions.NoError(sink.Write(suite.task), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sinks should not fail.")
+	suite.Assertions.Len(suite.fileHelper.UnMarshallResults("second"), 1, "CSV sink should be written.")
+	suite.Assertions.FileExists(filepath.Join(suite.tempDir, "results.jsonl"), "JSONL sink should be written.")
+
+	_, err = NewResultSink("csv,xml", suite.tempDir, suite.fileHelper)
+	suite.Assertions.Error(err, "Unknown sink should fail.")
+	_, err = NewResultSink("", suite.tempDir, suite.fileHelper)
+	suite.Assertions.Error(err, "Missing sink should fail.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestResultSinkModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(ResultSinkModuleTestSuite))
+}
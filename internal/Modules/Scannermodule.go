@@ -5,25 +5,33 @@ import (
 	"GitAnalyzer/api/Analyzer"
 	"encoding/json"
 	"fmt"
+	"github.com/go-git/go-git/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/ricochet2200/go-disk-usage/du"
 	"golang.org/x/net/websocket"
+	"log"
+	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 // webServer starts a local webserver for the webui frontend
 // The provided channel monitorStats can be used, to send stats to the frontend via a websocket.
-func webServer(monitorStats <-chan Analyzer.MonitorStat) {
+// The results of the scan are served by the results API, the redaction policies are applied to them.
+func webServer(monitorStats <-chan Analyzer.MonitorStat, results *MemoryResultSink, redactor *Redactor) {
 	e := echo.New()
 
 	// Attach middlewares for logging and recovering of failed requests.
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
+	// Define GET endpoint for the results found so far
+	e.GET("/api/results", resultsHandler(results, redactor))
+
 	// Define listening GET endpoint for the websocket of the frontend
 	e.GET("/ws", func(c echo.Context) error {
 		websocket.Handler(func(ws *websocket.Conn) {
@@ -55,42 +63,124 @@ func webServer(monitorStats <-chan Analyzer.MonitorStat) {
 	e.Logger.Fatal(e.Start(":8080"))
 }
 
-// cloneRepoAndRunTemplates reads tasks from the given tasks channel until the channel is empty.
-// the provided repoHandler is used to clone the repository from the read task.
-// the templateHandler is used to run all templates on the cloned repository
+// resultsPageSize is the default and resultsMaxPageSize the maximum number of results served by one request
+const (
+	resultsPageSize    = 100
+	resultsMaxPageSize = 1000
+)
+
+// resultsHandler returns the handler of the results API, which serves the newest results found so far as json.
+// The results are paginated with the offset and limit query parameters, the number of all results is sent in the
+// X-Total-Count header. The redaction policies are applied, so results written without a policy don't leak their
+// secrets.
+func resultsHandler(results *MemoryResultSink, redactor *Redactor) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		offset, limit := 0, resultsPageSize
+		if value := c.QueryParam("offset"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return c.String(http.StatusBadRequest, "invalid offset")
+			}
+			offset = parsed
+		}
+		if value := c.QueryParam("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return c.String(http.StatusBadRequest, "invalid limit")
+			}
+			limit = parsed
+		}
+		if limit > resultsMaxPageSize {
+			limit = resultsMaxPageSize
+		}
+		page, total := results.Page(offset, limit)
+		c.Response().Header().Set("X-Total-Count", strconv.Itoa(total))
+		return c.JSON(http.StatusOK, redactor.Redact(page))
+	}
+}
+
+// clonedRepository is passed from the clone workers to the scan workers.
+// It contains the task and the cloned repository of the task.
+type clonedRepository struct {
+	// The task the repository was cloned for
+	task Analyzer.Task
+	// The cloned repository
+	repo *git.Repository
+}
+
+// cloneRepositories reads tasks from the given tasks channel until the channel is closed.
+// The provided repoHandler is used to clone the repository from the read task.
+// Successfully cloned repositories are passed to the scan workers via the cloned channel.
 // Updates of the state of the running task are shared via the cTasks channel.
-func cloneRepoAndRunTemplates(repoHandler *RepoHandler, templateHandler *TemplateHandler,
-	tasks <-chan Analyzer.Task, cTasks chan<- Analyzer.Task) {
-	for {
-		select {
-		// Read task from tasks channel
-		case task := <-tasks:
-			// Wait a bit to debounce
-			time.Sleep(time.Millisecond * 200)
-			// Update state to cloning
-			task.State = "cloning"
+func cloneRepositories(repoHandler *RepoHandler, tasks <-chan Analyzer.Task,
+	cloned chan<- clonedRepository, cTasks chan<- Analyzer.Task) {
+	// Read task from tasks channel
+	for task := range tasks {
+		// Wait a bit to debounce
+		time.Sleep(time.Millisecond * 200)
+		// Update state to cloning
+		task.State = "cloning"
+		cTasks <- task
+		// Clone the repository from the task
+		repo := repoHandler.CloneRepositories(task)
+		if repo == nil {
+			// If the repository is nil the clone process failed
+			// Update state to failed
+			task.State = "failed"
 			cTasks <- task
-			// Clone the repository from the task
-			repo := repoHandler.CloneRepositories(task)
-			if repo == nil {
-				// If the repository is nil the clone process failed
-				// Update state to failed
-				task.State = "failed"
-				cTasks <- task
-				// Continue with next task
-				continue
-			}
-			// Run templates
-			templateHandler.RunAllTemplates(task, repo, cTasks)
-			// Reset task and repo to prevent memory leak
-			task = Analyzer.Task{}
-			repo = nil
+			// Continue with next task
+			continue
 		}
+		// Hand the repository over to the scan workers
+		cloned <- clonedRepository{task: task, repo: repo}
+	}
+}
+
+// runTemplates reads cloned repositories from the given cloned channel until the channel is closed.
+// The templateHandler is used to run all templates on the cloned repository.
+// Updates of the state of the running task are shared via the cTasks channel.
+func runTemplates(templateHandler *TemplateHandler, cloned <-chan clonedRepository, cTasks chan<- Analyzer.Task) {
+	// Read cloned repository from the cloned channel
+	for clonedRepo := range cloned {
+		// Run templates
+		templateHandler.RunAllTemplates(clonedRepo.task, clonedRepo.repo, cTasks)
 		// Wait a bit for debounce
 		time.Sleep(time.Millisecond * 200)
 	}
 }
 
+// cloneRepoAndRunTemplates starts the clone and scan worker pools for the given tasks channel.
+// The number of workers of each pool is taken from the provided config.
+// The cloned repositories are buffered for at most ScanWorkers repositories, so the clone workers
+// don't fill up the disk while all scan workers are busy.
+// Updates of the state of the running task are shared via the cTasks channel.
+func cloneRepoAndRunTemplates(repoHandler *RepoHandler, templateHandler *TemplateHandler, config Analyzer.Config,
+	tasks <-chan Analyzer.Task, cTasks chan<- Analyzer.Task) {
+	// Initialize the channel between the clone and scan workers
+	cloned := make(chan clonedRepository, config.ScanWorkers)
+
+	// Start the clone worker pool
+	var wgClone sync.WaitGroup
+	for i := 0; i < config.CloneWorkers; i++ {
+		wgClone.Add(1)
+		go func() {
+			defer wgClone.Done()
+			cloneRepositories(repoHandler, tasks, cloned, cTasks)
+		}()
+	}
+
+	// Start the scan worker pool
+	for i := 0; i < config.ScanWorkers; i++ {
+		go runTemplates(templateHandler, cloned, cTasks)
+	}
+
+	// Close the cloned channel once all clone workers are done, so the scan workers stop
+	go func() {
+		wgClone.Wait()
+		close(cloned)
+	}()
+}
+
 // Run is the main function to start the process of cloning and scanning a repository.
 // Therefore, the provided config object is used to set different settings.
 func Run(config Analyzer.Config) {
@@ -108,12 +198,84 @@ func Run(config Analyzer.Config) {
 	// Run preScripts
 	templateHandler.preScript()
 
-	// Load tasks (urls.csv)
+	// Create result directory
+	templateHandler.FileHelper.PrepareResultsFolder(config.ResultsDir)
+
+	// Open the task store
+	taskStore, err := OpenTaskStore(config.TaskDBPath)
+	if err != nil {
+		log.Fatalln("Error opening task store:", err)
+	}
+	defer taskStore.Close()
+
+	// Load tasks (task source, crawler database or repositories of an owner) into the task store
 	fmt.Println("Loading tasks...")
-	tasksSlc := templateHandler.FileHelper.GetTasks(config.UrlFilePath, "./results/checked.csv")
-	numberOfTasks := int32(len(tasksSlc))
-	fmt.Println("Tasks loaded:", strconv.Itoa(int(numberOfTasks)))
+	var tasksSlc []Analyzer.Task
+	if config.FromDB {
+		tasksSlc = LoadTasksFromDB(config.RepoFilter)
+	} else if ownerSource := NewOwnerTaskSource(config); ownerSource != nil {
+		// List the repositories of the organization, user or group
+		tasksSlc, err = ownerSource.Tasks()
+		if err != nil {
+			log.Fatalln("Error listing repositories:", err)
+		}
+	} else {
+		// A rescan keeps the repositories already checked by an earlier scan session
+		checkedCSVPath := templateHandler.FileHelper.GetResultCSVPath("checked")
+		if config.Rescan {
+			checkedCSVPath = ""
+		}
+		tasksSlc = templateHandler.FileHelper.GetTasks(config.UrlFilePath, checkedCSVPath)
+	}
+	added, err := taskStore.AddTasks(tasksSlc)
+	if err != nil {
+		log.Fatalln("Error adding tasks to task store:", err)
+	}
+	// Queue the tasks finished or failed by an earlier scan session again
+	if config.Rescan {
+		requeued, errRequeue := taskStore.RequeueTasks(tasksSlc)
+		if errRequeue != nil {
+			log.Fatalln("Error queueing tasks for the rescan:", errRequeue)
+		}
+		fmt.Println("Tasks queued for the rescan:", strconv.FormatInt(requeued, 10))
+	}
+	// Reset the taskSlc to prevent memory leaking
+	tasksSlc = nil
+	// Queue the tasks of an interrupted scan again
+	resumed, err := taskStore.ResetUnfinishedTasks(config.RetryFailed, config.MaxAttempts)
+	if err != nil {
+		log.Fatalln("Error resetting unfinished tasks:", err)
+	}
+	// Get the queued tasks
+	queuedIDs, err := taskStore.QueuedTaskIDs(config.Order, config.Seed)
+	if err != nil {
+		log.Fatalln("Error loading queued tasks:", err)
+	}
+	numberOfTasks := int32(len(queuedIDs))
+	fmt.Println("Tasks loaded:", strconv.Itoa(int(numberOfTasks)), "new:", strconv.Itoa(added),
+		"resumed:", strconv.FormatInt(resumed, 10))
 
+	// Open the sinks for the results, the results are redacted before they are written
+	sinks, err := NewResultSink(config.ResultSinks, config.ResultsDir, templateHandler.FileHelper)
+	if err != nil {
+		log.Fatalln("Error creating result sinks:", err)
+	}
+	redactor, err := NewTemplateRedactor(templateHandler.templates, config.ResultsDir)
+	if err != nil {
+		log.Fatalln("Error loading redaction policies:", err)
+	}
+	// Keep the results in memory for the results API of the webserver
+	memorySink := NewMemoryResultSink(memoryResultSinkCapacity)
+	resultSink := NewRedactingResultSink(MultiResultSink{sinks, memorySink}, redactor)
+	// Start a new scan session, its ID is stored with every result
+	session, err := StartScanSession(config.ResultsDir, config, templateHandler.templates)
+	if err != nil {
+		log.Fatalln("Error starting scan session:", err)
+	}
+	fmt.Println("Scan session:", strconv.Itoa(session.ID))
+	if err = resultSink.Open(session); err != nil {
+		log.Fatalln("Error opening result sinks:", err)
+	}
 	// Initialize variables for monitoring of stats
 	var failedScans, finishedScans, resultsFound, queuedScans, runningScans, cloning int32
 	failedScans = 0
@@ -123,86 +285,98 @@ func Run(config Analyzer.Config) {
 	resultsFound = 0
 	cloning = 0
 
-	// Create result directory
-	templateHandler.FileHelper.PrepareResultsFolder(config.ResultsDir)
-
 	// Start disk usage monitoring
 	go checkDiskUsage()
 
 	// Initialize necessary channels
-	cloneQueue := make(chan Analyzer.Task, numberOfTasks)
-	cTasks := make(chan Analyzer.Task, numberOfTasks)
-	monitor := make(chan Analyzer.MonitorStat, numberOfTasks)
+	cloneQueue := make(chan Analyzer.Task, config.CloneWorkers)
+	cTasks := make(chan Analyzer.Task, config.CloneWorkers+config.ScanWorkers)
+	// The monitor channel only holds the latest stat, so its size does not depend on the number of tasks
+	monitor := make(chan Analyzer.MonitorStat, 1)
 
 	// Start the webserver
-	go webServer(monitor)
+	go webServer(monitor, memorySink, redactor)
 
 	// Start the goroutine to send the stats frequently (every second) to monitoring channel.
 	go func(numberOfTasks int32, failedScans, queuedScans, runningScans, finishedScans,
-		resultsFound *int32, monitor chan<- Analyzer.MonitorStat) {
+		resultsFound, cloning *int32, monitor chan Analyzer.MonitorStat) {
 		for {
-			monitor <- Analyzer.MonitorStat{NumberOfTasks: numberOfTasks, QueuedScans: atomic.LoadInt32(queuedScans),
+			stat := Analyzer.MonitorStat{NumberOfTasks: numberOfTasks, QueuedScans: atomic.LoadInt32(queuedScans),
 				RunningScans: atomic.LoadInt32(runningScans), FailedScans: atomic.LoadInt32(failedScans),
-				FinishedScans: atomic.LoadInt32(finishedScans), ResultsFound: atomic.LoadInt32(resultsFound)}
+				FinishedScans: atomic.LoadInt32(finishedScans), ResultsFound: atomic.LoadInt32(resultsFound),
+				Cloning: atomic.LoadInt32(cloning)}
+			// Replace a stat that was not read yet, instead of blocking until the frontend connects
+			select {
+			case monitor <- stat:
+			default:
+				select {
+				case <-monitor:
+				default:
+				}
+				select {
+				case monitor <- stat:
+				default:
+				}
+			}
 			time.Sleep(1 * time.Second)
 		}
 
-	}(numberOfTasks, &failedScans, &queuedScans, &runningScans, &finishedScans, &resultsFound, monitor)
+	}(numberOfTasks, &failedScans, &queuedScans, &runningScans, &finishedScans, &resultsFound, &cloning, monitor)
 
-	// Add the loaded task to the cloneQueue channel
-	for _, task := range tasksSlc {
-		// Set state to queued
-		task.State = "queued"
-		// Add to channel
-		cloneQueue <- task
-	}
-	// Reset the taskSlc to prevent memory leaking
-	tasksSlc = nil
+	// Add the queued tasks to the cloneQueue channel, the channel is closed once all tasks are queued
+	go func() {
+		if errFeed := taskStore.feedQueuedTasks(queuedIDs, cloneQueue); errFeed != nil {
+			log.Fatalln("Error loading tasks from task store:", errFeed)
+		}
+	}()
 
-	// Start the worker pool
-	for i := 0; i < config.WorkerCount; i++ {
-		go cloneRepoAndRunTemplates(repoHandler, templateHandler, cloneQueue, cTasks)
-	}
+	// Start the clone and scan worker pools
+	cloneRepoAndRunTemplates(repoHandler, templateHandler, config, cloneQueue, cTasks)
 
 	// Read all updates from the cTasks channel
-	for {
-		select {
+	for numberOfTasks > 0 {
 		// Read task
-		case task := <-cTasks:
-			// Wait a bit for debounce
-			time.Sleep(time.Millisecond * 200)
-			// Read the current state of the task update
-			switch task.State {
-			case "finished":
-				//write result
-				if task.Results != nil {
-					templateHandler.FileHelper.MarshalMultipleResults(task.Results)
-					atomic.AddInt32(&resultsFound, 1)
-				}
-				//write to checked file
-				templateHandler.FileHelper.MarshalStat(Analyzer.Stat{URL: task.URL, ElapsedTime: task.ElapsedTime})
-
-				//Increment finishedScans
-				atomic.AddInt32(&finishedScans, 1)
-				//Decrement runningScans
-				atomic.AddInt32(&runningScans, -int32(1))
-			case "failed":
-				//Increment failedScans
+		task := <-cTasks
+		// Wait a bit for debounce
+		time.Sleep(time.Millisecond * 200)
+		// Read the current state of the task update
+		switch task.State {
+		case "finished":
+			// Write the results before the task is marked as finished, so no results are lost on a crash
+			if errWrite := writeResults(resultSink, task); errWrite != nil {
+				log.Println("Error writing results of", task.URL+":", errWrite)
+				// Keep the task running inside the task store, so the next scan resumes it
 				atomic.AddInt32(&failedScans, 1)
-				//Decrement cloning
-				atomic.AddInt32(&cloning, -int32(1))
-			case "running":
-				//Increment runningScans
-				atomic.AddInt32(&runningScans, 1)
-				//Decrement cloning
-				atomic.AddInt32(&cloning, -int32(1))
-			case "cloning":
-				//Increment cloning
-				atomic.AddInt32(&cloning, 1)
-				//Decrement queuedScans
-				atomic.AddInt32(&queuedScans, -int32(1))
+				atomic.AddInt32(&runningScans, -int32(1))
+				break
+			}
+			persistState(taskStore, task)
+			if task.Results != nil {
+				atomic.AddInt32(&resultsFound, 1)
 			}
 
+			//Increment finishedScans
+			atomic.AddInt32(&finishedScans, 1)
+			//Decrement runningScans
+			atomic.AddInt32(&runningScans, -int32(1))
+		case "failed":
+			persistState(taskStore, task)
+			//Increment failedScans
+			atomic.AddInt32(&failedScans, 1)
+			//Decrement cloning
+			atomic.AddInt32(&cloning, -int32(1))
+		case "running":
+			persistState(taskStore, task)
+			//Increment runningScans
+			atomic.AddInt32(&runningScans, 1)
+			//Decrement cloning
+			atomic.AddInt32(&cloning, -int32(1))
+		case "cloning":
+			persistState(taskStore, task)
+			//Increment cloning
+			atomic.AddInt32(&cloning, 1)
+			//Decrement queuedScans
+			atomic.AddInt32(&queuedScans, -int32(1))
 		}
 		// Check if all scans are finished
 		if allScansFinished(numberOfTasks, &finishedScans, &failedScans) {
@@ -214,12 +388,36 @@ func Run(config Analyzer.Config) {
 		time.Sleep(time.Millisecond * 200)
 	}
 
+	// Write the remaining results before they are post processed
+	if err = resultSink.Close(); err != nil {
+		log.Println("Error closing result sinks:", err)
+	}
+	if err = FinishScanSession(config.ResultsDir, session); err != nil {
+		log.Println("Error finishing scan session:", err)
+	}
+
 	// Run post process script at the end.
 	templateHandler.postProcess()
 
 	fmt.Println("Finished")
 }
 
+// writeResults writes the results of the finished task and flushes the sinks,
+// so the results are stored once the task is marked as finished.
+func writeResults(resultSink IResultSink, task Analyzer.Task) error {
+	if err := resultSink.Write(task); err != nil {
+		return err
+	}
+	return resultSink.Flush()
+}
+
+// persistState stores the state of the task inside the task store, errors are only logged
+func persistState(taskStore *TaskStore, task Analyzer.Task) {
+	if err := taskStore.UpdateState(task); err != nil {
+		log.Println("Error updating task state:", err)
+	}
+}
+
 // allScansFinished checks if any scans are still running or if all are finished.
 // numberOfTasks is the total number of all repos to scan.
 // finishedScans is the amount of scans which finished successfully
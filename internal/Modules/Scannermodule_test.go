@@ -27,19 +27,27 @@ func TestRegularExpressions(t *testing.T) {
 
 		// Iterate over all regular expressions of the current template
 		for _, regex := range template.Regex {
+			// Fill the defaults of entropy rules
+			regex = prepareRegex(regex)
 
 			// Compile regular expression
-			expression := regexp.MustCompile(strings.TrimSpace(regex.Expression))
+			expression := regexp.MustCompile(regexExpression(regex))
 
 			// Iterate over test vales for the current regular expression
 			for _, test := range regex.Tests {
 				// Initialize map of foundString => true
 				valuesFound := make(map[string]bool)
-				// Execute regex against test input
-				matchesFound := expression.FindAllStringSubmatch(test.Input, -1)
+				// Execute regex against test input, the entropy thresholds and false positives are applied
+				matchesFound := matchLine(expression, regex, test.Input)
+				if regex.Mode == RegexModeMultiline || regex.Mode == RegexModeFile {
+					// Match the whole input like the content of a file
+					matchesFound = nil
+					for _, match := range matchContent(expression, regex, test.Input) {
+						matchesFound = append(matchesFound, match.output)
+					}
+				}
 				// Add found values to the map
-				for _, got := range matchesFound {
-					value := got[regex.Group]
+				for _, value := range matchesFound {
 					value = strings.TrimSpace(value)
 					valuesFound[value] = true
 				}
@@ -65,3 +73,26 @@ func TestRegularExpressions(t *testing.T) {
 		}
 	}
 }
+
+// TestWriteResults checks that the results are stored, before the task is marked as finished
+func TestWriteResults(t *testing.T) {
+	fileHelper := &FileHandler{Analyzer.Config{ResultsDir: t.TempDir()}}
+	resultSink := NewCSVResultSink(fileHelper)
+	if err := resultSink.Open(Analyzer.ScanSession{ID: 1}); err != nil {
+		t.Fatal("Opening the result sink should not fail:", err)
+	}
+	defer resultSink.Close()
+
+	task := Analyzer.Task{URL: "https://github.com/gitanalyzer/test", State: "finished",
+		Results: []Analyzer.Result{{TemplateName: "test", URL: "https://github.com/gitanalyzer/test", Output: "found"}}}
+	if err := writeResults(resultSink, task); err != nil {
+		t.Fatal("Writing results should not fail:", err)
+	}
+	// The results are readable without closing the sink
+	if results := fileHelper.UnMarshallResults("test"); len(results) != 1 {
+		t.Errorf("Results should be written, got %d", len(results))
+	}
+	if stats := fileHelper.UnMarshallStats(fileHelper.GetResultCSVPath("checked")); len(stats) != 1 {
+		t.Errorf("Task should be marked as checked, got %d", len(stats))
+	}
+}
@@ -0,0 +1,161 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// filePlaceholder matches the {{File}} placeholder, scripts using it are run once per matched file
+var filePlaceholder = regexp.MustCompile(`\{\{-?[^}]*\bFile\b[^}]*-?\}\}`)
+
+// The scriptPlaceholder struct describes a placeholder of the template scripts
+type scriptPlaceholder struct {
+	// Name of the placeholder e.g. RepoURL for {{RepoURL}}
+	name string
+	// Name of the environment variable the value is exported to
+	env string
+	// Value of the placeholder inside the context
+	value func(context Analyzer.ScriptContext) string
+}
+
+// scriptPlaceholders contains all placeholders of the template scripts
+var scriptPlaceholders = []scriptPlaceholder{
+	{"Template", "GA_TEMPLATE", func(c Analyzer.ScriptContext) string { return c.Template }},
+	{"RepoURL", "GA_REPO_URL", func(c Analyzer.ScriptContext) string { return c.RepoURL }},
+	{"RepoPath", "GA_REPO_PATH", func(c Analyzer.ScriptContext) string { return c.RepoPath }},
+	{"Branch", "GA_BRANCH", func(c Analyzer.ScriptContext) string { return c.Branch }},
+	{"Hash", "GA_HASH", func(c Analyzer.ScriptContext) string { return c.Hash }},
+	{"CommitDate", "GA_COMMIT_DATE", func(c Analyzer.ScriptContext) string { return c.CommitDate }},
+	{"Author", "GA_AUTHOR", func(c Analyzer.ScriptContext) string { return c.Author }},
+	{"Language", "GA_LANGUAGE", func(c Analyzer.ScriptContext) string { return c.Language }},
+	{"Dir", "GA_DIR", func(c Analyzer.ScriptContext) string { return c.Dir }},
+	{"ResultsDir", "GA_RESULTS_DIR", func(c Analyzer.ScriptContext) string { return c.ResultsDir }},
+	{"File", "GA_FILE", func(c Analyzer.ScriptContext) string { return c.File }},
+}
+
+// parseScript parses the placeholders of the script code, the values are quoted for the language of the script
+func parseScript(code, language string, context Analyzer.ScriptContext) (*template.Template, error) {
+	functions := template.FuncMap{}
+	for _, placeholder := range scriptPlaceholders {
+		value := quoteScriptValue(placeholder.value(context), language)
+		functions[placeholder.name] = func() string { return value }
+	}
+	return template.New("script").Funcs(functions).Parse(code)
+}
+
+// renderScript replaces the placeholders of the script code with the values of the context.
+// The values are quoted as string literals of the language, so a crafted file name can't inject code.
+func renderScript(code, language string, context Analyzer.ScriptContext) (string, error) {
+	script, err := parseScript(code, language, context)
+	if err != nil {
+		return "", err
+	}
+	var rendered bytes.Buffer
+	if err := script.Execute(&rendered, nil); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// validateScript returns an error if the placeholders of the script code can't be parsed or are placed inside
+// quotes. The values are quoted already, the quotes around '{{File}}' would end the quotes of the value instead.
+func validateScript(code, language string) error {
+	if _, err := parseScript(code, language, Analyzer.ScriptContext{}); err != nil {
+		return err
+	}
+	if line, placeholder := quotedPlaceholder(code, language); placeholder != "" {
+		// Reported like the parse errors with the line inside the script code
+		return fmt.Errorf("template: script:%d: placeholder %s is inside quotes, remove the quotes as the value "+
+			"is quoted already", line, placeholder)
+	}
+	return nil
+}
+
+// quotedPlaceholder returns the line and the first placeholder of the code, which is inside a string literal.
+// Single quotes of bash don't support escapes, python strings and double quotes of bash do. Comments are skipped.
+func quotedPlaceholder(code, language string) (int, string) {
+	line := 1
+	quote := ""
+	for i := 0; i < len(code); i++ {
+		switch {
+		case code[i] == '\n':
+			line++
+		case strings.HasPrefix(code[i:], "{{"):
+			end := strings.Index(code[i:], "}}")
+			if end < 0 {
+				return 0, ""
+			}
+			if quote != "" {
+				return line, code[i : i+end+2]
+			}
+			i += end + 1
+		case code[i] == '\\' && (quote == "" || quote == `"` || language == "python"):
+			// Skip the escaped character
+			if i+1 < len(code) && code[i+1] == '\n' {
+				line++
+			}
+			i++
+		case quote != "":
+			if strings.HasPrefix(code[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			}
+		case code[i] == '\'' || code[i] == '"':
+			quote = code[i : i+1]
+			if language == "python" && strings.HasPrefix(code[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+		case code[i] == '#' && (i == 0 || strings.ContainsRune(" \t\n;", rune(code[i-1]))):
+			// Skip the comment until the end of the line
+			for i+1 < len(code) && code[i+1] != '\n' {
+				i++
+			}
+		}
+	}
+	return 0, ""
+}
+
+// scriptError formats the parse error of a script with the line inside the script code
+func scriptError(err error) string {
+	message := strings.TrimPrefix(err.Error(), "template: script:")
+	if message == err.Error() {
+		return message
+	}
+	return "line " + message
+}
+
+// usesFilePlaceholder returns true if the script code contains the {{File}} placeholder
+func usesFilePlaceholder(code string) bool {
+	return filePlaceholder.MatchString(code)
+}
+
+// quoteScriptValue quotes the value as string literal of the script language.
+// Python gets a double-quoted string literal, all other languages are run by bash and get single quotes.
+func quoteScriptValue(value, language string) string {
+	if language == "python" {
+		return strconv.Quote(value)
+	}
+	return shellQuote(value)
+}
+
+// shellQuote quotes the value with single quotes, so the shell doesn't interpret any character of it
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+// scriptEnvironment returns the values of the context as GA_* environment variables, e.g. GA_FILE=./package.json.
+// Scripts can read them unquoted, e.g. "$GA_FILE" or os.environ["GA_FILE"].
+func scriptEnvironment(context Analyzer.ScriptContext) []string {
+	env := make([]string, 0, len(scriptPlaceholders))
+	for _, placeholder := range scriptPlaceholders {
+		env = append(env, placeholder.env+"="+placeholder.value(context))
+	}
+	return env
+}
@@ -0,0 +1,120 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Create test suite for the script module
+type ScriptModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+	context Analyzer.ScriptContext
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *ScriptModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+	suite.context = Analyzer.ScriptContext{RepoURL: "https://github.com/gitanalyzer/test", Branch: "main",
+		Hash: "0123456789abcdef", Language: "Go", Dir: suite.tempDir, File: "./a'; touch pwned; echo '.js"}
+}
+
+// TestRenderScript checks that the placeholders are replaced with values quoted for the script language
+func (suite *ScriptModuleTestSuite) TestRenderScript() {
+	rendered, err := renderScript("cat {{File}} # {{Branch}} {{Language}}", "cli", suite.context)
+	suite.Assertions.NoError(err, "Rendering the script should not fail.")
+	suite.Assertions.Equal(`cat './a'\''; touch pwned; echo '\''.js' # 'main' 'Go'`, rendered,
+		"Shell script should equal.")
+
+	rendered, err = renderScript("path = {{File}}\nurl = {{ RepoURL }}", "python", suite.context)
+	suite.Assertions.NoError(err, "Rendering the script should not fail.")
+	suite.Assertions.Equal("path = \"./a'; touch pwned; echo '.js\"\nurl = \"https://github.com/gitanalyzer/test\"",
+		rendered, "Python script should equal.")
+
+	suite.Assertions.True(usesFilePlaceholder("wc -l {{ File }}"), "File placeholder should be found.")
+	suite.Assertions.False(usesFilePlaceholder("wc -l $GA_FILE {{Dir}}"), "File placeholder should not be found.")
+}
+
+// TestRunCommandQuoting checks that a crafted file name is passed as one argument and can't inject a command
+func (suite *ScriptModuleTestSuite) TestRunCommandQuoting() {
+	commandHandler := &CommandHandler{}
+	for _, language := range []string{"cli", "bash"} {
+		script, err := renderScript("printf '%s|' {{File}} \"$GA_FILE\" \"$GA_HASH\"", language, suite.context)
+		suite.Assertions.NoError(err, "Rendering the script should not fail.")
+		output := commandHandler.RunCommand(script, suite.tempDir, language, suite.context)
+		suite.Assertions.Equal(suite.context.File+"|"+suite.context.File+"|0123456789abcdef|", output,
+			"Output of %s should equal.", language)
+		_, errStat := os.Stat(filepath.Join(suite.tempDir, "pwned"))
+		suite.Assertions.True(os.IsNotExist(errStat), "Injected command of %s should not run.", language)
+	}
+}
+
+// TestValidateScript checks that unknown placeholders and syntax errors are reported with their line
+func (suite *ScriptModuleTestSuite) TestValidateScript() {
+	suite.Assertions.NoError(validateScript("echo {{RepoURL}} {{ResultsDir}} {{CommitDate}} {{Author}}", "bash"),
+		"Known placeholders should be valid.")
+	suite.Assertions.EqualError(validateScript("echo\necho {{Repo}}", "bash"), `template: script:2: function "Repo" not defined`,
+		"Unknown placeholder should be reported.")
+	suite.Assertions.Equal(`line 2: function "Repo" not defined`, scriptError(validateScript("echo\necho {{Repo}}", "")),
+		"Error should contain the line.")
+}
+
+// TestValidateQuotedPlaceholders checks that placeholders inside quotes are rejected, as their values are quoted
+func (suite *ScriptModuleTestSuite) TestValidateQuotedPlaceholders() {
+	tests := []struct {
+		code     string
+		language string
+		line     int
+	}{
+		{"cat '{{File}}'", "bash", 1},
+		{"echo start\ngrep -c \"TODO\" \"{{File}}\"", "cli", 2},
+		{"print('scan ' + '{{RepoURL}}')", "python", 1},
+		{"text = \"\"\"\n{{File}}\"\"\"", "python", 2},
+		{"cat {{File}}", "bash", 0},
+		{"echo 'it''s' {{File}} \"$HOME\" # don't quote '{{File}}'", "bash", 0},
+		{"echo \\'{{File}}", "bash", 0},
+		{"print(\"it\\\"s\", {{File}})", "python", 0},
+	}
+	for _, test := range tests {
+		err := validateScript(test.code, test.language)
+		if test.line == 0 {
+			suite.Assertions.NoError(err, "Unquoted placeholder of %q should be valid.", test.code)
+			continue
+		}
+		suite.Assertions.Error(err, "Quoted placeholder of %q should be reported.", test.code)
+		if err != nil {
+			suite.Assertions.True(strings.HasPrefix(scriptError(err), fmt.Sprintf("line %d: placeholder {{", test.line)),
+				"Error of %q should contain the line: %s", test.code, err)
+		}
+	}
+}
+
+// TestCommitContext checks that the hash, date and author of the commit are added to the context
+func (suite *ScriptModuleTestSuite) TestCommitContext() {
+	repo, err := git.PlainInit(suite.tempDir, false)
+	suite.Assertions.NoError(err, "Creating the repository should not fail.")
+	worktree, err := repo.Worktree()
+	suite.Assertions.NoError(err, "Getting the worktree should not fail.")
+	when := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	hash, err := worktree.Commit("Initial", &git.CommitOptions{AllowEmptyCommits: true,
+		Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: when}})
+	suite.Assertions.NoError(err, "Committing should not fail.")
+
+	context := commitContext(Analyzer.ScriptContext{Language: "Go"}, repo, hash.String())
+	suite.Assertions.Equal(Analyzer.ScriptContext{Language: "Go", Hash: hash.String(),
+		CommitDate: "2023-05-01T12:00:00Z", Author: "John Doe <john@doe.org>"}, context, "Context should equal.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestScriptModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScriptModuleTestSuite))
+}
@@ -0,0 +1,357 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"encoding/json"
+	"fmt"
+	"github.com/gocarina/gocsv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scanSessionsFile is the name of the file inside the results directory which contains all scan sessions
+const scanSessionsFile = "scans.csv"
+
+// LoadScanSessions loads all scan sessions of the given results directory.
+// An empty slice is returned if no scan was run yet.
+func LoadScanSessions(resultsDir string) ([]Analyzer.ScanSession, error) {
+	path := filepath.Join(resultsDir, scanSessionsFile)
+	if !Utils.FileExists(path) {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sessions []Analyzer.ScanSession
+	if err = gocsv.UnmarshalFile(file, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// saveScanSessions writes the given scan sessions into the results directory
+func saveScanSessions(resultsDir string, sessions []Analyzer.ScanSession) error {
+	file, err := os.Create(filepath.Join(resultsDir, scanSessionsFile))
+	if err != nil {
+		return err
+	}
+	if err = gocsv.MarshalFile(&sessions, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// StartScanSession creates a new scan session inside the results directory.
+// The settings of the scan and the versions of the templates are stored with the session.
+func StartScanSession(resultsDir string, config interface{}, templates []Analyzer.Template) (Analyzer.ScanSession, error) {
+	sessions, err := LoadScanSessions(resultsDir)
+	if err != nil {
+		return Analyzer.ScanSession{}, err
+	}
+
+	// The ID of the new session follows the highest known ID
+	id := 1
+	for _, session := range sessions {
+		if session.ID >= id {
+			id = session.ID + 1
+		}
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return Analyzer.ScanSession{}, err
+	}
+	templateVersions := make([]string, 0, len(templates))
+	for _, template := range templates {
+		templateVersions = append(templateVersions, template.Name+"@"+template.Hash)
+	}
+
+	session := Analyzer.ScanSession{ID: id, StartedAt: time.Now().Format(time.RFC3339), Config: string(configJSON),
+		Templates: strings.Join(templateVersions, ",")}
+	return session, saveScanSessions(resultsDir, append(sessions, session))
+}
+
+// FinishScanSession stores the end time of the given scan session
+func FinishScanSession(resultsDir string, session Analyzer.ScanSession) error {
+	sessions, err := LoadScanSessions(resultsDir)
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		if sessions[i].ID == session.ID {
+			sessions[i].FinishedAt = time.Now().Format(time.RFC3339)
+		}
+	}
+	return saveScanSessions(resultsDir, sessions)
+}
+
+// stampResults returns a copy of the given results with the ID of the scan session
+func stampResults(results []Analyzer.Result, scanID int) []Analyzer.Result {
+	if results == nil {
+		return nil
+	}
+	stamped := make([]Analyzer.Result, len(results))
+	for i, result := range results {
+		result.ScanID = scanID
+		stamped[i] = result
+	}
+	return stamped
+}
+
+// repositoryDiff contains the changes of the findings of a single repository between two scans
+type repositoryDiff struct {
+	// URL of the repository
+	URL string
+	// Findings which were only found by the second scan
+	New []Analyzer.Result
+	// Findings which were only found by the first scan
+	Fixed []Analyzer.Result
+	// Number of findings found by both scans
+	Unchanged int
+}
+
+// scanDiff contains the changes of the findings between two scans
+type scanDiff struct {
+	// The changes per repository scanned by both scans, sorted by URL
+	Repositories []repositoryDiff
+	// Repositories which were only scanned by the first scan
+	OnlyInA []string
+	// Repositories which were only scanned by the second scan
+	OnlyInB []string
+}
+
+// findingKey identifies a finding independent of the commit and the time it was found in.
+// The hash of redacted outputs is used, as different values can have the same redacted output.
+// Line breaks of multiline outputs are compared as \n, as the CSV files don't keep carriage returns.
+func findingKey(result Analyzer.Result) string {
+	output := strings.ReplaceAll(result.Output, "\r\n", "\n")
+	if result.OutputHash != "" {
+		output = result.OutputHash
+	}
+	return result.TemplateName + "\x00" + result.Path + "\x00" + output
+}
+
+// diffScans compares the results of two scans.
+// Only repositories scanned by both scans are compared, the scanned maps contain the URLs of the scanned repositories.
+func diffScans(resultsA, resultsB []Analyzer.Result, scannedA, scannedB map[string]bool) scanDiff {
+	// Map URL => finding key => result
+	findingsA := make(map[string]map[string]Analyzer.Result)
+	findingsB := make(map[string]map[string]Analyzer.Result)
+	for _, findings := range []struct {
+		results []Analyzer.Result
+		target  map[string]map[string]Analyzer.Result
+	}{{resultsA, findingsA}, {resultsB, findingsB}} {
+		for _, result := range findings.results {
+			if findings.target[result.URL] == nil {
+				findings.target[result.URL] = make(map[string]Analyzer.Result)
+			}
+			findings.target[result.URL][findingKey(result)] = result
+		}
+	}
+
+	var diff scanDiff
+	for url := range scannedA {
+		if !scannedB[url] {
+			diff.OnlyInA = append(diff.OnlyInA, url)
+			continue
+		}
+		repoDiff := repositoryDiff{URL: url}
+		for key, result := range findingsB[url] {
+			if _, found := findingsA[url][key]; found {
+				repoDiff.Unchanged++
+			} else {
+				repoDiff.New = append(repoDiff.New, result)
+			}
+		}
+		for key, result := range findingsA[url] {
+			if _, found := findingsB[url][key]; !found {
+				repoDiff.Fixed = append(repoDiff.Fixed, result)
+			}
+		}
+		sortResults(repoDiff.New)
+		sortResults(repoDiff.Fixed)
+		diff.Repositories = append(diff.Repositories, repoDiff)
+	}
+	for url := range scannedB {
+		if !scannedA[url] {
+			diff.OnlyInB = append(diff.OnlyInB, url)
+		}
+	}
+
+	sort.Slice(diff.Repositories, func(i, j int) bool { return diff.Repositories[i].URL < diff.Repositories[j].URL })
+	sort.Strings(diff.OnlyInA)
+	sort.Strings(diff.OnlyInB)
+	return diff
+}
+
+// sortResults sorts the results by template, path and output
+func sortResults(results []Analyzer.Result) {
+	sort.Slice(results, func(i, j int) bool { return findingKey(results[i]) < findingKey(results[j]) })
+}
+
+// loadScanResults loads the results and the stats of the repositories scanned by the scan with the given ID
+// from the CSV files of the results directory.
+func loadScanResults(resultsDir string, scanID int) ([]Analyzer.Result, []Analyzer.Stat, error) {
+	paths, err := filepath.Glob(filepath.Join(resultsDir, "*.csv"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var results []Analyzer.Result
+	var stats []Analyzer.Stat
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".csv")
+		switch {
+		case name+".csv" == scanSessionsFile || strings.HasSuffix(name, "_unique"):
+			// Not a result file
+			continue
+		case name == "checked":
+			// Load the repositories scanned by the scan
+			var fileStats []Analyzer.Stat
+			if err = unmarshalCSVFile(path, &fileStats); err != nil {
+				return nil, nil, err
+			}
+			for _, stat := range fileStats {
+				if stat.ScanID == scanID {
+					stats = append(stats, stat)
+				}
+			}
+		default:
+			// Load the results of a template, the name of the file is the name of the template
+			var templateResults []Analyzer.Result
+			if err = unmarshalCSVFile(path, &templateResults); err != nil {
+				return nil, nil, err
+			}
+			for _, result := range templateResults {
+				if result.ScanID != scanID {
+					continue
+				}
+				result.TemplateName = name
+				results = append(results, result)
+			}
+		}
+	}
+	return results, stats, nil
+}
+
+// loadSourceScanResults loads the results and the stats of the scan with the given ID from the given source,
+// which can be: csv, sqlite (<results>/results.db), mysql (config.env)
+func loadSourceScanResults(source, resultsDir string, scanID int) ([]Analyzer.Result, []Analyzer.Stat, error) {
+	switch source {
+	case "", "csv":
+		return loadScanResults(resultsDir, scanID)
+	case "sqlite":
+		return loadSQLScanResults("sqlite3", sqliteResultDSN(resultsDir), scanID)
+	case "mysql":
+		return loadSQLScanResults("mysql", mysqlResultDSN(), scanID)
+	default:
+		return nil, nil, fmt.Errorf("unknown results source: %s (valid: csv, sqlite, mysql)", source)
+	}
+}
+
+// sourceName returns the name of the results source, the CSV files are the default source
+func sourceName(source string) string {
+	if source == "" {
+		return "csv"
+	}
+	return source
+}
+
+// scannedRepositories returns the URLs of the scanned repositories.
+// A repository with results was scanned, even if its stat is missing.
+func scannedRepositories(results []Analyzer.Result, stats []Analyzer.Stat) map[string]bool {
+	scanned := make(map[string]bool)
+	for _, stat := range stats {
+		scanned[stat.URL] = true
+	}
+	for _, result := range results {
+		scanned[result.URL] = true
+	}
+	return scanned
+}
+
+// unmarshalCSVFile loads the rows of the CSV file with the given path into the provided slice
+func unmarshalCSVFile(path string, rows interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err = gocsv.UnmarshalFile(file, rows); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// PrintScanDiff prints the new, fixed and unchanged findings per repository between the two scans of the results directory.
+// The results are loaded from the given source, see loadSourceScanResults.
+func PrintScanDiff(resultsDir, source string, scanA, scanB int) error {
+	// Check that both scans exist
+	sessions, err := LoadScanSessions(resultsDir)
+	if err != nil {
+		return err
+	}
+	for _, scanID := range []int{scanA, scanB} {
+		found := false
+		for _, session := range sessions {
+			found = found || session.ID == scanID
+		}
+		if !found {
+			return fmt.Errorf("unknown scan: %d", scanID)
+		}
+	}
+
+	resultsA, statsA, err := loadSourceScanResults(source, resultsDir, scanA)
+	if err != nil {
+		return err
+	}
+	resultsB, statsB, err := loadSourceScanResults(source, resultsDir, scanB)
+	if err != nil {
+		return err
+	}
+	// A scan without any scanned repository was written to another source, comparing it would report no changes
+	for i, stats := range [][]Analyzer.Stat{statsA, statsB} {
+		if scanID := []int{scanA, scanB}[i]; len(stats) == 0 {
+			return fmt.Errorf("scan %d has no scanned repositories in the %s results, select the source it was written to with --source",
+				scanID, sourceName(source))
+		}
+	}
+	diff := diffScans(resultsA, resultsB, scannedRepositories(resultsA, statsA), scannedRepositories(resultsB, statsB))
+
+	// Print the changes per repository
+	var newFindings, fixedFindings, unchangedFindings int
+	for _, repoDiff := range diff.Repositories {
+		newFindings += len(repoDiff.New)
+		fixedFindings += len(repoDiff.Fixed)
+		unchangedFindings += repoDiff.Unchanged
+		if len(repoDiff.New) == 0 && len(repoDiff.Fixed) == 0 {
+			continue
+		}
+		fmt.Println(repoDiff.URL)
+		for _, result := range repoDiff.New {
+			fmt.Printf("  + [%s] %s: %s\n", result.TemplateName, result.Path, result.Output)
+		}
+		for _, result := range repoDiff.Fixed {
+			fmt.Printf("  - [%s] %s: %s\n", result.TemplateName, result.Path, result.Output)
+		}
+		fmt.Println("  unchanged:", repoDiff.Unchanged)
+	}
+	for _, url := range diff.OnlyInA {
+		fmt.Println("Only scanned by scan", scanA, url)
+	}
+	for _, url := range diff.OnlyInB {
+		fmt.Println("Only scanned by scan", scanB, url)
+	}
+	fmt.Printf("Repositories: %d, new: %d, fixed: %d, unchanged: %d\n", len(diff.Repositories), newFindings,
+		fixedFindings, unchangedFindings)
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+// Create test suite for the session module
+type SessionModuleTestSuite struct {
+	suite.Suite
+	tempDir    string
+	fileHelper *FileHandler
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *SessionModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	// Initialize the fileHelper with the temporary directory as the result dir
+	suite.fileHelper = &FileHandler{Analyzer.Config{ResultsDir: suite.tempDir}}
+}
+
+// TestScanSessions checks that every session gets a new ID and stores its templates and end time
+func (suite *SessionModuleTestSuite) TestScanSessions() {
+	templates := []Analyzer.Template{{Name: "first", Hash: "abc"}, {Name: "second", Hash: "def"}}
+	first, err := StartScanSession(suite.tempDir, Analyzer.Config{WorkerCount: 2}, templates)
+	suite.Assertions.NoError(err, "Starting the session should not fail.")
+	suite.Assertions.Equal(1, first.ID, "ID of the first session should equal.")
+	suite.Assertions.Equal("first@abc,second@def", first.Templates, "Templates should equal.")
+	suite.Assertions.Contains(first.Config, `"WorkerCount":2`, "Config should be stored.")
+
+	second, err := StartScanSession(suite.tempDir, Analyzer.Config{}, nil)
+	suite.Assertions.NoError(err, "Starting the session should not fail.")
+	suite.Assertions.Equal(2, second.ID, "ID of the second session should equal.")
+	suite.Assertions.NoError(FinishScanSession(suite.tempDir, first), "Finishing the session should not fail.")
+
+	sessions, err := LoadScanSessions(suite.tempDir)
+	suite.Assertions.NoError(err, "Loading the sessions should not fail.")
+	suite.Assertions.Len(sessions, 2, "Both sessions should be stored.")
+	suite.Assertions.NotEmpty(sessions[0].FinishedAt, "First session should be finished.")
+	suite.Assertions.Empty(sessions[1].FinishedAt, "Second session should not be finished.")
+}
+
+// TestDiffScans checks that the findings are reported as new, fixed or unchanged per repository
+func (suite *SessionModuleTestSuite) TestDiffScans() {
+	resultsA := []Analyzer.Result{
+		{TemplateName: "secrets", URL: "repo1", Path: "a.txt", Output: "key1", CommitHash: "c1"},
+		{TemplateName: "secrets", URL: "repo1", Path: "b.txt", Output: "key2"},
+		{TemplateName: "secrets", URL: "repo3", Path: "c.txt", Output: "key3"},
+	}
+	resultsB := []Analyzer.Result{
+		// Same finding in another commit is unchanged
+		{TemplateName: "secrets", URL: "repo1", Path: "a.txt", Output: "key1", CommitHash: "c2"},
+		{TemplateName: "secrets", URL: "repo1", Path: "d.txt", Output: "key4"},
+		{TemplateName: "secrets", URL: "repo4", Path: "e.txt", Output: "key5"},
+	}
+	scannedA := map[string]bool{"repo1": true, "repo2": true, "repo3": true}
+	scannedB := map[string]bool{"repo1": true, "repo2": true, "repo4": true}
+
+	diff := diffScans(resultsA, resultsB, scannedA, scannedB)
+	suite.Assertions.Len(diff.Repositories, 2, "Repositories scanned by both scans should be compared.")
+	suite.Assertions.Equal("repo1", diff.Repositories[0].URL, "URL should equal.")
+	suite.Assertions.Equal(1, diff.Repositories[0].Unchanged, "Unchanged findings should equal.")
+	suite.Assertions.Equal([]Analyzer.Result{resultsB[1]}, diff.Repositories[0].New, "New findings should equal.")
+	suite.Assertions.Equal([]Analyzer.Result{resultsA[1]}, diff.Repositories[0].Fixed, "Fixed findings should equal.")
+	suite.Assertions.Equal(repositoryDiff{URL: "repo2"}, diff.Repositories[1], "Repository without findings should equal.")
+	suite.Assertions.Equal([]string{"repo3"}, diff.OnlyInA, "Repositories only scanned by the first scan should equal.")
+	suite.Assertions.Equal([]string{"repo4"}, diff.OnlyInB, "Repositories only scanned by the second scan should equal.")
+}
+
+// TestLoadScanResults checks that only the results of the given scan are loaded from the CSV files
+func (suite *SessionModuleTestSuite) TestLoadScanResults() {
+	for scanID, output := range map[int]string{1: "old", 2: "new"} {
+		sink := NewCSVResultSink(suite.fileHelper)
+		suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: scanID}), "Opening the sink should not fail.")
+		suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "repo1", Results: []Analyzer.Result{
+			{TemplateName: "secrets", URL: "repo1", Output: output}}}), "Writing the task should not fail.")
+		suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "repo2"}), "Writing the task should not fail.")
+		suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	}
+
+	results, stats, err := loadScanResults(suite.tempDir, 2)
+	suite.Assertions.NoError(err, "Loading the results should not fail.")
+	suite.Assertions.Len(results, 1, "Only the results of the scan should be loaded.")
+	suite.Assertions.Equal("new", results[0].Output, "Output should equal.")
+	suite.Assertions.Equal("secrets", results[0].TemplateName, "Template name should equal.")
+	suite.Assertions.Len(stats, 2, "Only the stats of the scan should be loaded.")
+	suite.Assertions.Equal(map[string]bool{"repo1": true, "repo2": true}, scannedRepositories(results, stats),
+		"Scanned repositories should equal.")
+}
+
+// TestPrintScanDiffWithoutStats checks that a scan without stats in the selected source can't be compared
+func (suite *SessionModuleTestSuite) TestPrintScanDiffWithoutStats() {
+	for scanID := 1; scanID <= 2; scanID++ {
+		session, err := StartScanSession(suite.tempDir, Analyzer.Config{}, nil)
+		suite.Assertions.NoError(err, "Starting the session should not fail.")
+		suite.Assertions.Equal(scanID, session.ID, "ID of the session should equal.")
+	}
+	// Only the first scan was written to the CSV files
+	sink := NewCSVResultSink(suite.fileHelper)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "repo1"}), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	err := PrintScanDiff(suite.tempDir, "csv", 1, 2)
+	suite.Assertions.EqualError(err, "scan 2 has no scanned repositories in the csv results, select the source it was "+
+		"written to with --source", "Error should equal.")
+	suite.Assertions.Error(PrintScanDiff(suite.tempDir, "jsonl", 1, 2), "Unknown source should fail.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestSessionModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionModuleTestSuite))
+}
@@ -0,0 +1,440 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"database/sql"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// sqlTimeFormat is the format of all timestamps stored by the SQLResultSink
+const sqlTimeFormat = "2006-01-02 15:04:05"
+
+// sqlDialect contains the parts of the SQL statements which differ between the supported databases
+type sqlDialect struct {
+	// Definition of an auto incremented primary key
+	idColumn string
+	// Query counting the tables with the name of the first argument
+	tableExistsQuery string
+	// Query counting the indexes of the table (first argument) starting with the column (second argument)
+	indexExistsQuery string
+	// Statement creating the index on the url column of the crawled repositories
+	repoURLIndex string
+}
+
+// sqlDialects maps the name of the database driver to its dialect
+var sqlDialects = map[string]sqlDialect{
+	"sqlite3": {
+		idColumn:         "INTEGER PRIMARY KEY AUTOINCREMENT",
+		tableExistsQuery: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		indexExistsQuery: "SELECT COUNT(*) FROM pragma_index_list(?) l JOIN pragma_index_info(l.name) i " +
+			"WHERE i.seqno = 0 AND i.name = ?",
+		repoURLIndex: "CREATE INDEX repos_url ON repos (url)",
+	},
+	"mysql": {
+		idColumn:         "INT AUTO_INCREMENT PRIMARY KEY",
+		tableExistsQuery: "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		indexExistsQuery: "SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() " +
+			"AND table_name = ? AND column_name = ? AND seq_in_index = 1",
+		// The prefix allows the index on TEXT columns too
+		repoURLIndex: "CREATE INDEX repos_url ON repos (url(255))",
+	},
+}
+
+// The sqlColumn struct describes a column, which was added to a table after its first version
+type sqlColumn struct {
+	// Name of the table
+	table string
+	// Name of the column
+	column string
+	// Definition of the column
+	definition string
+}
+
+// sqlMigrations contains the columns added to the tables after their first version,
+// they are added to databases written by older versions when the sink is opened
+var sqlMigrations = []sqlColumn{
+	// The scan sessions and their config
+	{"scans", "session_id", "INT NOT NULL DEFAULT 0"},
+	{"scans", "config", "TEXT NULL"},
+	{"scans", "templates", "TEXT NULL"},
+	// The hash of the redacted outputs, the line numbers and the versions of the templates
+	{"findings", "output_hash", "VARCHAR(64) NOT NULL DEFAULT ''"},
+	{"findings", "line", "INT NOT NULL DEFAULT 0"},
+	{"findings", "template_version", "VARCHAR(255) NOT NULL DEFAULT ''"},
+}
+
+// templateStat counts the findings of a template during a scan
+type templateStat struct {
+	// Number of findings of the template
+	findings int
+	// Number of repositories with findings of the template
+	repositories int
+}
+
+// The SQLResultSink struct writes the results into a MySQL/MariaDB or SQLite database.
+// Every run creates a new row in the scans table referencing the scan session,
+// the scanned repositories are stored in the tasks table and their results in the findings table.
+// The stats table contains the number of findings per template.
+// If the database contains the repos table of the crawler, the tasks reference the crawled repository.
+type SQLResultSink struct {
+	// Name of the database driver, either sqlite3 or mysql
+	driver string
+	// Data source name used to open the database
+	dsn string
+	// The opened database
+	db *sql.DB
+	// ID of the scan inside the scans table
+	scanID int64
+	// hasRepos is true if the database contains the repos table of the crawler
+	hasRepos bool
+	// Tasks which are written with the next flush
+	pending []Analyzer.Task
+	// Map template name => stats of the template
+	stats map[string]*templateStat
+}
+
+// NewSQLResultSink is the constructor to create a new SQLResultSink for the given driver (sqlite3 or mysql)
+// and data source name.
+func NewSQLResultSink(driver, dsn string) *SQLResultSink {
+	return &SQLResultSink{driver: driver, dsn: dsn}
+}
+
+// Open opens the database, creates the missing tables and stores the given scan session
+func (ss *SQLResultSink) Open(session Analyzer.ScanSession) error {
+	dialect, found := sqlDialects[ss.driver]
+	if !found {
+		return fmt.Errorf("unsupported database driver: %s", ss.driver)
+	}
+	db, err := sql.Open(ss.driver, ss.dsn)
+	if err != nil {
+		return err
+	}
+	if ss.driver == "sqlite3" {
+		// SQLite only supports a single writer
+		db.SetMaxOpenConns(1)
+	}
+	ss.db = db
+	ss.stats = make(map[string]*templateStat)
+	ss.pending = nil
+
+	// Check if the tasks can reference the repositories of the crawler
+	var count int
+	if err = db.QueryRow(dialect.tableExistsQuery, "repos").Scan(&count); err != nil {
+		db.Close()
+		return err
+	}
+	ss.hasRepos = count > 0
+	repoReference := ""
+	if ss.hasRepos {
+		repoReference = ",\n    FOREIGN KEY (repo_id) REFERENCES repos (id)"
+	}
+
+	// Create the tables
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS scans (
+    id ` + dialect.idColumn + `,
+    session_id INT NOT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    config TEXT NOT NULL,
+    templates TEXT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS tasks (
+    id ` + dialect.idColumn + `,
+    scan_id INT NOT NULL,
+    repo_id INT NULL,
+    url VARCHAR(255) NOT NULL,
+    language VARCHAR(255) NOT NULL,
+    elapsed_time VARCHAR(255) NOT NULL,
+    metadata TEXT NOT NULL,
+    finished_at DATETIME NOT NULL,
+    FOREIGN KEY (scan_id) REFERENCES scans (id)` + repoReference + `)`,
+		`CREATE TABLE IF NOT EXISTS findings (
+    id ` + dialect.idColumn + `,
+    task_id INT NOT NULL,
+    template VARCHAR(255) NOT NULL,
+    commit_hash VARCHAR(255) NOT NULL,
+    timestamp VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    line INT NOT NULL DEFAULT 0,
+    description TEXT NOT NULL,
+    output TEXT NOT NULL,
+    output_hash VARCHAR(64) NOT NULL DEFAULT '',
+    template_version VARCHAR(255) NOT NULL DEFAULT '',
+    FOREIGN KEY (task_id) REFERENCES tasks (id))`,
+		`CREATE TABLE IF NOT EXISTS stats (
+    scan_id INT NOT NULL,
+    template VARCHAR(255) NOT NULL,
+    findings INT NOT NULL,
+    repositories INT NOT NULL,
+    PRIMARY KEY (scan_id, template),
+    FOREIGN KEY (scan_id) REFERENCES scans (id))`,
+	}
+	for _, statement := range statements {
+		if _, err = db.Exec(statement); err != nil {
+			db.Close()
+			return err
+		}
+	}
+	// Databases written by older versions miss the newer columns
+	for _, migration := range sqlMigrations {
+		if err = addMissingColumn(db, migration.table, migration.column, migration.definition); err != nil {
+			db.Close()
+			return err
+		}
+	}
+	// Every task looks up its repository by URL, which needs an index on the large repos table
+	if ss.hasRepos {
+		if err = addRepoURLIndex(db, dialect); err != nil {
+			log.Println("Error creating index on repos.url, looking up the repositories will be slow:", err)
+		}
+	}
+
+	// Start the scan
+	res, err := db.Exec("INSERT INTO scans (session_id, started_at, config, templates) VALUES (?, ?, ?, ?)", session.ID,
+		time.Now().UTC().Format(sqlTimeFormat), session.Config, session.Templates)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	ss.scanID, err = res.LastInsertId()
+	if err != nil {
+		db.Close()
+	}
+	return err
+}
+
+// Write buffers the task until the next flush
+func (ss *SQLResultSink) Write(task Analyzer.Task) error {
+	ss.pending = append(ss.pending, task)
+
+	// Count the findings per template
+	counted := make(map[string]bool)
+	for _, result := range task.Results {
+		stat, found := ss.stats[result.TemplateName]
+		if !found {
+			stat = &templateStat{}
+			ss.stats[result.TemplateName] = stat
+		}
+		stat.findings++
+		if !counted[result.TemplateName] {
+			stat.repositories++
+			counted[result.TemplateName] = true
+		}
+	}
+	return nil
+}
+
+// Flush inserts the buffered tasks with their findings and updates the stats of the scan inside one transaction
+func (ss *SQLResultSink) Flush() error {
+	if len(ss.pending) == 0 {
+		return nil
+	}
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = ss.insertPending(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = ss.replaceStats(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	ss.pending = nil
+	return nil
+}
+
+// insertPending inserts the buffered tasks and their findings
+func (ss *SQLResultSink) insertPending(tx *sql.Tx) error {
+	taskStmt, err := tx.Prepare(`INSERT INTO tasks (scan_id, repo_id, url, language, elapsed_time, metadata, finished_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer taskStmt.Close()
+	findingStmt, err := tx.Prepare(`INSERT INTO findings (task_id, template, commit_hash, timestamp, path, line,
+description, output, output_hash, template_version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer findingStmt.Close()
+
+	for _, task := range ss.pending {
+		repoID, err := ss.repoID(tx, task.URL)
+		if err != nil {
+			return err
+		}
+		metadata, err := task.Metadata.MarshalCSV()
+		if err != nil {
+			return err
+		}
+		res, err := taskStmt.Exec(ss.scanID, repoID, task.URL, task.Language, task.ElapsedTime, metadata,
+			time.Now().UTC().Format(sqlTimeFormat))
+		if err != nil {
+			return err
+		}
+		taskID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, result := range task.Results {
+			if _, err = findingStmt.Exec(taskID, result.TemplateName, result.CommitHash, result.Timestamp, result.Path,
+				result.Line, result.Description, result.Output, result.OutputHash, result.TemplateVersion); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addMissingColumn adds the column with the given definition to the table, if the table does not contain it
+func addMissingColumn(db *sql.DB, table, column, definition string) error {
+	// Selecting a missing column fails
+	rows, err := db.Query("SELECT " + column + " FROM " + table + " LIMIT 0")
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
+
+// addRepoURLIndex creates an index on the url column of the repos table, if no index starts with that column
+func addRepoURLIndex(db *sql.DB, dialect sqlDialect) error {
+	var count int
+	if err := db.QueryRow(dialect.indexExistsQuery, "repos", "url").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := db.Exec(dialect.repoURLIndex)
+	return err
+}
+
+// repoID returns the ID of the crawled repository with the given URL.
+// nil is returned if the repository was not crawled.
+func (ss *SQLResultSink) repoID(tx *sql.Tx, url string) (interface{}, error) {
+	if !ss.hasRepos {
+		return nil, nil
+	}
+	var id int64
+	err := tx.QueryRow("SELECT id FROM repos WHERE url = ?", url).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// replaceStats replaces the stats of the scan with the current counts
+func (ss *SQLResultSink) replaceStats(tx *sql.Tx) error {
+	if _, err := tx.Exec("DELETE FROM stats WHERE scan_id = ?", ss.scanID); err != nil {
+		return err
+	}
+	// Insert the templates sorted by name
+	templateNames := make([]string, 0, len(ss.stats))
+	for templateName := range ss.stats {
+		templateNames = append(templateNames, templateName)
+	}
+	sort.Strings(templateNames)
+	for _, templateName := range templateNames {
+		stat := ss.stats[templateName]
+		if _, err := tx.Exec("INSERT INTO stats (scan_id, template, findings, repositories) VALUES (?, ?, ?, ?)",
+			ss.scanID, templateName, stat.findings, stat.repositories); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close inserts the buffered tasks, marks the scan as finished and closes the database
+func (ss *SQLResultSink) Close() error {
+	if ss.db == nil {
+		return nil
+	}
+	err := ss.Flush()
+	if _, errFinish := ss.db.Exec("UPDATE scans SET finished_at = ? WHERE id = ?",
+		time.Now().UTC().Format(sqlTimeFormat), ss.scanID); err == nil {
+		err = errFinish
+	}
+	if errClose := ss.db.Close(); err == nil {
+		err = errClose
+	}
+	ss.db = nil
+	return err
+}
+
+// mysqlResultDSN returns the data source name of the MySQL/MariaDB database configured in "config.env"
+func mysqlResultDSN() string {
+	dbConfig := loadDBConfig()
+	return dbConfig.DBUser + ":" + dbConfig.DBPassword + "@tcp(" + dbConfig.DBIP + ":" + dbConfig.DBPort + ")/gitanalyzer"
+}
+
+// loadSQLScanResults loads the results and the stats of the repositories scanned by the scan session with the given ID
+// from the database written by the SQLResultSink.
+func loadSQLScanResults(driver, dsn string, sessionID int) ([]Analyzer.Result, []Analyzer.Stat, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	// Load the findings with the metadata of their tasks
+	rows, err := db.Query(`SELECT t.url, t.metadata, f.template, f.commit_hash, f.timestamp, f.path, f.line, f.description,
+f.output, f.output_hash, f.template_version FROM findings f JOIN tasks t ON t.id = f.task_id JOIN scans s ON s.id = t.scan_id WHERE s.session_id = ? ORDER BY f.id`,
+		sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var results []Analyzer.Result
+	for rows.Next() {
+		result := Analyzer.Result{ScanID: sessionID}
+		var metadata string
+		if err = rows.Scan(&result.URL, &metadata, &result.TemplateName, &result.CommitHash, &result.Timestamp,
+			&result.Path, &result.Line, &result.Description, &result.Output, &result.OutputHash,
+			&result.TemplateVersion); err != nil {
+			return nil, nil, err
+		}
+		if err = result.Metadata.UnmarshalCSV(metadata); err != nil {
+			return nil, nil, err
+		}
+		results = append(results, result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Load the scanned repositories
+	taskRows, err := db.Query(`SELECT t.url, t.elapsed_time FROM tasks t JOIN scans s ON s.id = t.scan_id
+WHERE s.session_id = ? ORDER BY t.id`, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer taskRows.Close()
+	var stats []Analyzer.Stat
+	for taskRows.Next() {
+		stat := Analyzer.Stat{ScanID: sessionID}
+		if err = taskRows.Scan(&stat.URL, &stat.ElapsedTime); err != nil {
+			return nil, nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return results, stats, taskRows.Err()
+}
+
+// sqliteResultDSN returns the data source name of the SQLite database inside the results directory
+func sqliteResultDSN(resultsDir string) string {
+	return "file:" + filepath.Join(resultsDir, "results.db") + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on"
+}
@@ -0,0 +1,165 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"database/sql"
+	"github.com/stretchr/testify/suite"
+	"path/filepath"
+	"testing"
+)
+
+// Create test suite for the SQL sink module
+type SQLSinkModuleTestSuite struct {
+	suite.Suite
+	dsn string
+	db  *sql.DB
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *SQLSinkModuleTestSuite) SetupTest() {
+	// Use a SQLite database inside a temporary directory
+	suite.dsn = "file:" + filepath.Join(suite.T().TempDir(), "results.db") + "?_foreign_keys=on"
+	db, err := sql.Open("sqlite3", suite.dsn)
+	suite.Assertions.NoError(err, "Opening the test database should not fail.")
+	suite.db = db
+}
+
+// TearDownTest is run after every test of the test suite to close the database
+func (suite *SQLSinkModuleTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+// count returns the result of the given COUNT query
+func (suite *SQLSinkModuleTestSuite) count(query string, args ...interface{}) int {
+	var count int
+	suite.Assertions.NoError(suite.db.QueryRow(query, args...).Scan(&count), "Counting should not fail.")
+	return count
+}
+
+// TestWrite checks that the tasks are written with the next flush and the stats are counted per template
+func (suite *SQLSinkModuleTestSuite) TestWrite() {
+	sink := NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL1", Results: []Analyzer.Result{
+		{TemplateName: "first", Output: "output1"}, {TemplateName: "first", Output: "output2"},
+		{TemplateName: "second", Output: "output3"}}}), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL2", Results: []Analyzer.Result{
+		{TemplateName: "first", Output: "output4"}}}), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL3"}), "Writing the task should not fail.")
+	suite.Assertions.Equal(0, suite.count("SELECT COUNT(*) FROM tasks"), "Tasks should be buffered.")
+
+	suite.Assertions.NoError(sink.Flush(), "Flushing the sink should not fail.")
+	suite.Assertions.Equal(3, suite.count("SELECT COUNT(*) FROM tasks WHERE scan_id = ?", sink.scanID),
+		"All tasks should be written.")
+	suite.Assertions.Equal(4, suite.count("SELECT COUNT(*) FROM findings"), "All findings should be written.")
+	suite.Assertions.Equal(2, suite.count(`SELECT COUNT(*) FROM findings f JOIN tasks t ON t.id = f.task_id
+WHERE t.url = 'testURL1' AND f.template = 'first'`), "Findings should reference their task.")
+
+	// Check the stats per template
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	var findings, repositories int
+	suite.Assertions.NoError(suite.db.QueryRow("SELECT findings, repositories FROM stats WHERE template = 'first'").
+		Scan(&findings, &repositories), "Loading the stats should not fail.")
+	suite.Assertions.Equal(3, findings, "Findings should equal.")
+	suite.Assertions.Equal(2, repositories, "Repositories should equal.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM scans WHERE finished_at IS NOT NULL"),
+		"Scan should be finished.")
+
+	// A second run creates a new scan
+	sink = NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.Equal(int64(2), sink.scanID, "Scan IDs should equal.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+}
+
+// TestCrawledRepositories checks that the tasks reference the repositories of the crawler
+func (suite *SQLSinkModuleTestSuite) TestCrawledRepositories() {
+	_, err := suite.db.Exec(`CREATE TABLE repos (url VARCHAR(255) NOT NULL, id INT NOT NULL PRIMARY KEY,
+creation_date DATE NOT NULL, fork_count INT NOT NULL, size INT NOT NULL, stars INT NOT NULL,
+update_date DATE NOT NULL, language VARCHAR(255) NOT NULL, commit_count INT NOT NULL);
+INSERT INTO repos VALUES ('git://github.com/test/java1.git', 42, '2020-01-01', 0, 100, 10, '2021-01-01', 'Java', 5);`)
+	suite.Assertions.NoError(err, "Creating the repos table should not fail.")
+
+	sink := NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "git://github.com/test/java1.git",
+		Results: []Analyzer.Result{{TemplateName: "first", Output: "output1"}}}), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "git://github.com/test/unknown.git"}),
+		"Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+
+	// Join the findings with the crawled metadata
+	var stars int
+	suite.Assertions.NoError(suite.db.QueryRow(`SELECT r.stars FROM findings f JOIN tasks t ON t.id = f.task_id
+JOIN repos r ON r.id = t.repo_id WHERE f.output = 'output1'`).Scan(&stars), "Joining the repos should not fail.")
+	suite.Assertions.Equal(10, stars, "Stars should equal.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM tasks WHERE repo_id IS NULL"),
+		"Unknown repository should not be referenced.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'repos_url'"),
+		"Index on the URLs of the repositories should be created.")
+
+	// The index is only created once
+	sink = NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 2}), "Reopening the sink should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+}
+
+// TestAddMissingColumns checks that databases of older versions get the hash and line columns of the findings
+func (suite *SQLSinkModuleTestSuite) TestAddMissingColumns() {
+	_, err := suite.db.Exec(`CREATE TABLE findings (id INTEGER PRIMARY KEY AUTOINCREMENT, task_id INT NOT NULL,
+template VARCHAR(255) NOT NULL, commit_hash VARCHAR(255) NOT NULL, timestamp VARCHAR(255) NOT NULL, path TEXT NOT NULL,
+description TEXT NOT NULL, output TEXT NOT NULL)`)
+	suite.Assertions.NoError(err, "Creating the old table should not fail.")
+
+	sink := NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 1}), "Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL", Results: []Analyzer.Result{
+		{TemplateName: "first", Line: 3, Output: "AKIA****", OutputHash: "abc"}}}), "Writing the task should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM findings WHERE output_hash = 'abc' AND line = 3"),
+		"Hash and line should be written.")
+}
+
+// TestAddMissingScanColumns checks that the scans table of older versions gets the columns of the scan sessions
+func (suite *SQLSinkModuleTestSuite) TestAddMissingScanColumns() {
+	_, err := suite.db.Exec(`CREATE TABLE scans (id INTEGER PRIMARY KEY AUTOINCREMENT, started_at DATETIME NOT NULL,
+finished_at DATETIME NULL); INSERT INTO scans (started_at) VALUES ('2023-01-01 10:00:00')`)
+	suite.Assertions.NoError(err, "Creating the old table should not fail.")
+
+	sink := NewSQLResultSink("sqlite3", suite.dsn)
+	suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: 3, Config: "{}", Templates: "[]"}),
+		"Opening the sink should not fail.")
+	suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM scans WHERE session_id = 3 AND config = '{}'"),
+		"Scan session should be written.")
+	suite.Assertions.Equal(1, suite.count("SELECT COUNT(*) FROM scans WHERE session_id = 0"),
+		"Old scan should be kept.")
+}
+
+// TestLoadSQLScanResults checks that only the results of the given scan session are loaded
+func (suite *SQLSinkModuleTestSuite) TestLoadSQLScanResults() {
+	for sessionID, output := range map[int]string{1: "old", 2: "new"} {
+		sink := NewSQLResultSink("sqlite3", suite.dsn)
+		suite.Assertions.NoError(sink.Open(Analyzer.ScanSession{ID: sessionID}), "Opening the sink should not fail.")
+		suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL1", ElapsedTime: "2s",
+			Metadata: Analyzer.Metadata{"team": "security"}, Results: []Analyzer.Result{
+				{TemplateName: "first", URL: "testURL1", Path: "a.txt", Line: 7, Output: output}}}), "Writing the task should not fail.")
+		suite.Assertions.NoError(sink.Write(Analyzer.Task{URL: "testURL2", ElapsedTime: "1s"}),
+			"Writing the task should not fail.")
+		suite.Assertions.NoError(sink.Close(), "Closing the sink should not fail.")
+	}
+
+	results, stats, err := loadSQLScanResults("sqlite3", suite.dsn, 2)
+	suite.Assertions.NoError(err, "Loading the results should not fail.")
+	suite.Assertions.Equal([]Analyzer.Result{{TemplateName: "first", URL: "testURL1", Path: "a.txt", Line: 7, Output: "new",
+		Metadata: Analyzer.Metadata{"team": "security"}, ScanID: 2}}, results, "Results should equal.")
+	suite.Assertions.Equal([]Analyzer.Stat{{URL: "testURL1", ElapsedTime: "2s", ScanID: 2},
+		{URL: "testURL2", ElapsedTime: "1s", ScanID: 2}}, stats, "Stats should equal.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestSQLSinkModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(SQLSinkModuleTestSuite))
+}
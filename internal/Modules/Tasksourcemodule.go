@@ -0,0 +1,636 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-github/v50/github"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported formats of a task source
+const (
+	// CSV file with or without the header line
+	taskFormatCSV = "csv"
+	// One json object per line
+	taskFormatJSONL = "jsonl"
+	// One URL per line
+	taskFormatList = "list"
+)
+
+// taskColumns are the columns of a CSV file without headers in the order they are expected.
+// Additional columns are stored as metadata named column<index>.
+var taskColumns = []string{"url", "language", "priority", "stars", "size", "update_date"}
+
+// maxTaskLineSize is the maximum size of a single line of a JSONL or URL list task source
+const maxTaskLineSize = 1024 * 1024
+
+// ITaskSource the interface is used to define a source providing the tasks of a scan
+type ITaskSource interface {
+	Tasks() ([]Analyzer.Task, error)
+}
+
+// NewTaskSource creates the task source for the given spec, which is one of:
+//   - "-" to read the tasks from the provided stdin
+//   - "github:org/<name>" or "github:user/<name>" to scan all repositories of a GitHub organization or user
+//   - a glob pattern like "lists/*.csv" to read the tasks from all matching files
+//   - the path of a single CSV, JSONL or URL list file
+func NewTaskSource(spec string, stdin io.Reader) (ITaskSource, error) {
+	switch {
+	case spec == "-":
+		return &ReaderTaskSource{Reader: stdin}, nil
+	case strings.HasPrefix(spec, "github:"):
+		parts := strings.SplitN(strings.TrimPrefix(spec, "github:"), "/", 2)
+		if len(parts) != 2 || parts[1] == "" || (parts[0] != "org" && parts[0] != "user") {
+			return nil, fmt.Errorf("invalid GitHub task source %q, expected github:org/<name> or github:user/<name>", spec)
+		}
+		return NewGitHubTaskSource(parts[0], parts[1], apiToken("GITHUB_API_KEY"), Analyzer.OwnerFilter{}), nil
+	case strings.ContainsAny(spec, "*?["):
+		paths, err := filepath.Glob(spec)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no task files match %q", spec)
+		}
+		// Read the matching files one after another
+		sources := make(MultiTaskSource, 0, len(paths))
+		for _, path := range paths {
+			sources = append(sources, &FileTaskSource{Path: path})
+		}
+		return sources, nil
+	default:
+		return &FileTaskSource{Path: spec}, nil
+	}
+}
+
+// The FileTaskSource struct reads the tasks from a local file.
+// The format is detected by the file extension, or by the content for unknown extensions.
+type FileTaskSource struct {
+	// Path of the file
+	Path string
+}
+
+// Tasks reads all tasks of the file
+func (fs *FileTaskSource) Tasks() ([]Analyzer.Task, error) {
+	file, err := os.Open(fs.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tasks, err := (&ReaderTaskSource{Reader: file, Format: taskFormatByExtension(fs.Path)}).Tasks()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fs.Path, err)
+	}
+	return tasks, nil
+}
+
+// taskFormatByExtension returns the format of a task file by its extension.
+// An empty format is returned for unknown extensions.
+func taskFormatByExtension(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return taskFormatCSV
+	case ".jsonl", ".ndjson":
+		return taskFormatJSONL
+	case ".txt", ".lst", ".list":
+		return taskFormatList
+	default:
+		return ""
+	}
+}
+
+// The ReaderTaskSource struct reads the tasks from a reader e.g. stdin
+type ReaderTaskSource struct {
+	// The reader providing the tasks
+	Reader io.Reader
+	// Format of the tasks, if empty the format is detected by the content
+	Format string
+}
+
+// Tasks reads all tasks of the reader
+func (rs *ReaderTaskSource) Tasks() ([]Analyzer.Task, error) {
+	reader := bufio.NewReaderSize(rs.Reader, maxTaskLineSize)
+	format := rs.Format
+	if format == "" {
+		// Peek into the content to detect the format
+		peeked, err := reader.Peek(maxTaskLineSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		format = detectTaskFormat(peeked)
+	}
+
+	switch format {
+	case taskFormatCSV:
+		return readCSVTasks(reader)
+	case taskFormatJSONL:
+		return readJSONLTasks(reader)
+	case taskFormatList:
+		return readListTasks(reader)
+	default:
+		return nil, fmt.Errorf("unknown task format: %s", format)
+	}
+}
+
+// detectTaskFormat detects the format of the tasks by the first line which is not empty
+func detectTaskFormat(content []byte) string {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if line[0] == '{' {
+			return taskFormatJSONL
+		}
+		if bytes.ContainsRune(line, ',') {
+			return taskFormatCSV
+		}
+		return taskFormatList
+	}
+	return taskFormatList
+}
+
+// readCSVTasks reads the tasks of a CSV file.
+// If the first row contains a "url" column, it is used as header and unknown columns are stored as metadata.
+// Otherwise, the columns are expected in the order of taskColumns.
+func readCSVTasks(reader io.Reader) ([]Analyzer.Task, error) {
+	csvReader := csv.NewReader(reader)
+	// Rows may have a different number of columns
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	var tasks []Analyzer.Task
+	var header []string
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Check if the first row is a header
+		if row == 1 && isTaskHeader(record) {
+			header = make([]string, len(record))
+			for i, column := range record {
+				header[i] = strings.ToLower(strings.TrimSpace(column))
+			}
+			continue
+		}
+
+		var task Analyzer.Task
+		for i, value := range record {
+			if err = setTaskField(&task, csvColumnName(header, i), strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+		}
+		// Skip empty rows
+		if task.URL == "" {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// isTaskHeader checks if the given CSV row is a header containing the url column
+func isTaskHeader(record []string) bool {
+	for _, column := range record {
+		if strings.EqualFold(strings.TrimSpace(column), "url") {
+			return true
+		}
+	}
+	return false
+}
+
+// csvColumnName returns the name of the column at the given index.
+// Without header the names of taskColumns are used.
+func csvColumnName(header []string, index int) string {
+	if header != nil {
+		if index < len(header) {
+			return header[index]
+		}
+	} else if index < len(taskColumns) {
+		return taskColumns[index]
+	}
+	return "column" + strconv.Itoa(index+1)
+}
+
+// readJSONLTasks reads the tasks of a JSONL file, containing one json object per line.
+// Keys which are not fields of the task are stored as metadata.
+func readJSONLTasks(reader io.Reader) ([]Analyzer.Task, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTaskLineSize)
+
+	var tasks []Analyzer.Task
+	for line := 1; scanner.Scan(); line++ {
+		content := bytes.TrimSpace(scanner.Bytes())
+		if len(content) == 0 {
+			continue
+		}
+
+		// Decode the object, keep numbers as they are written
+		var object map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var task Analyzer.Task
+		for key, value := range object {
+			if err := setTaskField(&task, strings.ToLower(key), jsonValueToString(value)); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if task.URL == "" {
+			return nil, fmt.Errorf("line %d: missing url", line)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, scanner.Err()
+}
+
+// jsonValueToString converts a decoded json value into the string stored inside the task
+func jsonValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		// Keep nested objects and arrays as json
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// readListTasks reads the tasks of a file containing one URL per line.
+// Empty lines and lines starting with # are skipped.
+func readListTasks(reader io.Reader) ([]Analyzer.Task, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTaskLineSize)
+
+	var tasks []Analyzer.Task
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tasks = append(tasks, Analyzer.Task{URL: line})
+	}
+	return tasks, scanner.Err()
+}
+
+// setTaskField sets the field of the task with the given column name.
+// Unknown columns are stored as metadata of the task.
+func setTaskField(task *Analyzer.Task, column, value string) error {
+	var err error
+	switch column {
+	case "url":
+		task.URL = value
+	case "language":
+		task.Language = value
+	case "priority":
+		task.Priority, err = parseTaskNumber(column, value)
+	case "stars":
+		task.Stars, err = parseTaskNumber(column, value)
+	case "size":
+		task.Size, err = parseTaskNumber(column, value)
+	case "update_date":
+		task.UpdateDate = value
+	default:
+		// Skip empty metadata
+		if column == "" || value == "" {
+			return nil
+		}
+		if task.Metadata == nil {
+			task.Metadata = make(Analyzer.Metadata)
+		}
+		task.Metadata[column] = value
+	}
+	return err
+}
+
+// parseTaskNumber parses the value of a numeric column, empty values are 0
+func parseTaskNumber(column, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", column, value)
+	}
+	return number, nil
+}
+
+// The MultiTaskSource type combines several task sources, the tasks are returned in the order of the sources
+type MultiTaskSource []ITaskSource
+
+// Tasks reads the tasks of all sources
+func (ms MultiTaskSource) Tasks() ([]Analyzer.Task, error) {
+	var tasks []Analyzer.Task
+	for _, source := range ms {
+		sourceTasks, err := source.Tasks()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, sourceTasks...)
+	}
+	return tasks, nil
+}
+
+// NewOwnerTaskSource creates the task source for the GitHub organization, GitHub user and GitLab group of the config.
+// If none of them is set, nil is returned.
+func NewOwnerTaskSource(config Analyzer.Config) ITaskSource {
+	var sources MultiTaskSource
+	if config.GitHubOrg != "" {
+		sources = append(sources, NewGitHubTaskSource("org", config.GitHubOrg, apiToken("GITHUB_API_KEY"),
+			config.OwnerFilter))
+	}
+	if config.GitHubUser != "" {
+		sources = append(sources, NewGitHubTaskSource("user", config.GitHubUser, apiToken("GITHUB_API_KEY"),
+			config.OwnerFilter))
+	}
+	if config.GitLabGroup != "" {
+		sources = append(sources, NewGitLabTaskSource(config.GitLabURL, config.GitLabGroup, apiToken("GITLAB_API_KEY"),
+			config.OwnerFilter))
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
+
+// IsValidVisibility checks if the given visibility of repositories is supported
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case "", "all", "public", "private", "internal":
+		return true
+	default:
+		return false
+	}
+}
+
+// matchesOwnerFilter checks if a repository with the given properties passes the filter
+func matchesOwnerFilter(filter Analyzer.OwnerFilter, fork, archived bool, visibility string) bool {
+	if fork && !filter.Forks {
+		return false
+	}
+	if archived && !filter.Archived {
+		return false
+	}
+	if filter.Visibility != "" && filter.Visibility != "all" && filter.Visibility != visibility {
+		return false
+	}
+	return true
+}
+
+// The GitHubTaskSource struct enumerates all repositories of a GitHub organization or user
+type GitHubTaskSource struct {
+	// Either org or user
+	Kind string
+	// Name of the organization or user
+	Owner string
+	// Filter for the listed repositories
+	Filter Analyzer.OwnerFilter
+	// True if a token is used, the private repositories of the token owner are only listed for the own account
+	authenticated bool
+	// The client used for the GitHub API
+	client *github.Client
+}
+
+// NewGitHubTaskSource is the constructor to create a new GitHubTaskSource.
+// The token is optional, but without a token only public repositories are listed and the rate limit is low.
+func NewGitHubTaskSource(kind, owner, token string, filter Analyzer.OwnerFilter) *GitHubTaskSource {
+	httpClient := http.DefaultClient
+	if token != "" {
+		httpClient = oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+	return &GitHubTaskSource{Kind: kind, Owner: owner, Filter: filter, authenticated: token != "",
+		client: github.NewClient(httpClient)}
+}
+
+// Tasks lists all repositories of the organization or user, page by page
+func (gs *GitHubTaskSource) Tasks() ([]Analyzer.Task, error) {
+	ctx := context.Background()
+	listOptions := github.ListOptions{PerPage: 100}
+
+	// /users/{user}/repos never returns private repositories, the authenticated user has to list them via /user/repos
+	ownAccount := false
+	if gs.Kind == "user" && gs.authenticated {
+		user, _, err := gs.client.Users.Get(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		ownAccount = strings.EqualFold(user.GetLogin(), gs.Owner)
+	}
+
+	var tasks []Analyzer.Task
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		var err error
+		if gs.Kind == "org" {
+			repos, resp, err = gs.client.Repositories.ListByOrg(ctx, gs.Owner,
+				&github.RepositoryListByOrgOptions{ListOptions: listOptions})
+		} else if ownAccount {
+			// Only the repositories owned by the user, including the private ones
+			repos, resp, err = gs.client.Repositories.List(ctx, "", &github.RepositoryListOptions{
+				Affiliation: "owner", Visibility: githubListVisibility(gs.Filter.Visibility), ListOptions: listOptions})
+		} else {
+			// Only the repositories owned by the user, not the ones the user is a member of
+			repos, resp, err = gs.client.Repositories.List(ctx, gs.Owner,
+				&github.RepositoryListOptions{Type: "owner", ListOptions: listOptions})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range repos {
+			if !matchesOwnerFilter(gs.Filter, repo.GetFork(), repo.GetArchived(), githubVisibility(repo)) {
+				continue
+			}
+			tasks = append(tasks, githubRepositoryToTask(repo))
+		}
+
+		// Check if no new page is available
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
+	}
+	return tasks, nil
+}
+
+// githubListVisibility returns the visibility used to list the repositories of the authenticated user.
+// The API only knows public and private, other visibilities are filtered after listing all repositories.
+func githubListVisibility(visibility string) string {
+	if visibility == "public" || visibility == "private" {
+		return visibility
+	}
+	return "all"
+}
+
+// githubVisibility returns the visibility of a repository.
+// Older GitHub versions only return if the repository is private.
+func githubVisibility(repo *github.Repository) string {
+	if repo.GetVisibility() != "" {
+		return repo.GetVisibility()
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// githubRepositoryToTask converts a repository of the GitHub API into a task
+func githubRepositoryToTask(repo *github.Repository) Analyzer.Task {
+	task := Analyzer.Task{URL: repo.GetCloneURL(), Language: repo.GetLanguage(), Stars: repo.GetStargazersCount(),
+		Size: repo.GetSize(), Metadata: Analyzer.Metadata{"owner": repo.GetOwner().GetLogin(), "name": repo.GetName()}}
+	if repo.UpdatedAt != nil {
+		task.UpdateDate = repo.GetUpdatedAt().Format("2006-01-02")
+	}
+	return task
+}
+
+// gitlabProject contains the fields of a project returned by the GitLab API
+type gitlabProject struct {
+	HTTPURLToRepo     string          `json:"http_url_to_repo"`
+	PathWithNamespace string          `json:"path_with_namespace"`
+	Visibility        string          `json:"visibility"`
+	Archived          bool            `json:"archived"`
+	ForkedFromProject json.RawMessage `json:"forked_from_project"`
+	StarCount         int             `json:"star_count"`
+	LastActivityAt    string          `json:"last_activity_at"`
+}
+
+// The GitLabTaskSource struct enumerates all projects of a GitLab group including its subgroups
+type GitLabTaskSource struct {
+	// Base URL of the GitLab instance e.g. https://gitlab.com
+	BaseURL string
+	// ID or full path of the group
+	Group string
+	// Filter for the listed projects
+	Filter Analyzer.OwnerFilter
+	// The access token, optional for public groups
+	token string
+	// The client used for the GitLab API
+	client *http.Client
+}
+
+// NewGitLabTaskSource is the constructor to create a new GitLabTaskSource.
+// If no base URL is provided, https://gitlab.com is used.
+func NewGitLabTaskSource(baseURL, group, token string, filter Analyzer.OwnerFilter) *GitLabTaskSource {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	return &GitLabTaskSource{BaseURL: strings.TrimSuffix(baseURL, "/"), Group: group, Filter: filter, token: token,
+		client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Tasks lists all projects of the group, page by page
+func (gs *GitLabTaskSource) Tasks() ([]Analyzer.Task, error) {
+	// Let GitLab filter by archived and visibility
+	query := url.Values{"include_subgroups": {"true"}, "per_page": {"100"}}
+	if !gs.Filter.Archived {
+		query.Set("archived", "false")
+	}
+	if gs.Filter.Visibility != "" && gs.Filter.Visibility != "all" {
+		query.Set("visibility", gs.Filter.Visibility)
+	}
+
+	var tasks []Analyzer.Task
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		projects, nextPage, err := gs.listProjects(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			fork := len(project.ForkedFromProject) > 0 && string(project.ForkedFromProject) != "null"
+			if !matchesOwnerFilter(gs.Filter, fork, project.Archived, project.Visibility) {
+				continue
+			}
+			tasks = append(tasks, gitlabProjectToTask(project))
+		}
+		page = nextPage
+	}
+	return tasks, nil
+}
+
+// listProjects requests a single page of projects of the group.
+// Returns the projects and the number of the next page, which is empty on the last page.
+func (gs *GitLabTaskSource) listProjects(query url.Values) ([]gitlabProject, string, error) {
+	request, err := http.NewRequest(http.MethodGet,
+		gs.BaseURL+"/api/v4/groups/"+url.PathEscape(gs.Group)+"/projects?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if gs.token != "" {
+		request.Header.Set("PRIVATE-TOKEN", gs.token)
+	}
+
+	resp, err := gs.client.Do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status from GitLab: %s", resp.Status)
+	}
+
+	var projects []gitlabProject
+	if err = json.NewDecoder(resp.Body).Decode(&projects); err != nil {
+		return nil, "", err
+	}
+	return projects, resp.Header.Get("X-Next-Page"), nil
+}
+
+// gitlabProjectToTask converts a project of the GitLab API into a task
+func gitlabProjectToTask(project gitlabProject) Analyzer.Task {
+	task := Analyzer.Task{URL: project.HTTPURLToRepo, Stars: project.StarCount,
+		Metadata: Analyzer.Metadata{"path": project.PathWithNamespace}}
+	if len(project.LastActivityAt) >= 10 {
+		task.UpdateDate = project.LastActivityAt[:10]
+	}
+	return task
+}
+
+// apiToken returns the API token with the given name from the environment
+// or the local "config.env" file. An empty string is returned if the token is not set.
+func apiToken(name string) string {
+	if token := os.Getenv(name); token != "" {
+		return token
+	}
+	if !Utils.FileExists("config.env") {
+		return ""
+	}
+	viper.SetConfigFile("config.env")
+	if err := viper.ReadInConfig(); err != nil {
+		return ""
+	}
+	return viper.GetString(name)
+}
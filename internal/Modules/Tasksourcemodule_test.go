@@ -0,0 +1,256 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"fmt"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create test suite for the task source module
+type TaskSourceModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *TaskSourceModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+}
+
+// writeFile writes the content into a file with the given name inside the temporary directory
+func (suite *TaskSourceModuleTestSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.tempDir, name)
+	suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Writing test file should not fail.")
+	return path
+}
+
+// readTasks reads the tasks of the given content from a reader with the format detected by the content
+func (suite *TaskSourceModuleTestSuite) readTasks(content string) []Analyzer.Task {
+	source, err := NewTaskSource("-", strings.NewReader(content))
+	suite.Assertions.NoError(err, "Creating the task source should not fail.")
+	tasks, err := source.Tasks()
+	suite.Assertions.NoError(err, "Reading the tasks should not fail.")
+	return tasks
+}
+
+// TestCSVWithoutHeader checks that the columns of a CSV without header are read by position
+func (suite *TaskSourceModuleTestSuite) TestCSVWithoutHeader() {
+	tasks := suite.readTasks("https://github.com/test/a,go\nhttps://github.com/test/b,java,5,10,20,2022-01-01,extra\n")
+
+	expectedTasks := []Analyzer.Task{
+		{URL: "https://github.com/test/a", Language: "go"},
+		{URL: "https://github.com/test/b", Language: "java", Priority: 5, Stars: 10, Size: 20, UpdateDate: "2022-01-01",
+			Metadata: Analyzer.Metadata{"column7": "extra"}},
+	}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+}
+
+// TestCSVWithHeader checks that the columns of a CSV with header are read by name and unknown columns become metadata
+func (suite *TaskSourceModuleTestSuite) TestCSVWithHeader() {
+	tasks := suite.readTasks("team,URL,stars\nsecurity,https://github.com/test/a,3\n,https://github.com/test/b,\n")
+
+	expectedTasks := []Analyzer.Task{
+		{URL: "https://github.com/test/a", Stars: 3, Metadata: Analyzer.Metadata{"team": "security"}},
+		{URL: "https://github.com/test/b"},
+	}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+}
+
+// TestCSVInvalidNumber checks that the row of an invalid number is reported
+func (suite *TaskSourceModuleTestSuite) TestCSVInvalidNumber() {
+	source := &FileTaskSource{Path: suite.writeFile("urls.csv", "https://github.com/test/a,go,high\n")}
+	_, err := source.Tasks()
+	suite.Assertions.ErrorContains(err, "row 1: invalid priority", "Invalid priority should be reported.")
+}
+
+// TestURLList checks that a list of URLs is read, skipping empty lines and comments
+func (suite *TaskSourceModuleTestSuite) TestURLList() {
+	tasks := suite.readTasks("# test repositories\nhttps://github.com/test/a\n\n  https://github.com/test/b  \n")
+
+	expectedTasks := []Analyzer.Task{{URL: "https://github.com/test/a"}, {URL: "https://github.com/test/b"}}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+}
+
+// TestJSONL checks that the objects of a JSONL file are read and unknown keys become metadata
+func (suite *TaskSourceModuleTestSuite) TestJSONL() {
+	tasks := suite.readTasks(`{"url": "https://github.com/test/a", "language": "go", "stars": 7, "team": "security"}
+{"url": "https://github.com/test/b", "archived": true, "owner": {"login": "test"}}
+`)
+
+	expectedTasks := []Analyzer.Task{
+		{URL: "https://github.com/test/a", Language: "go", Stars: 7, Metadata: Analyzer.Metadata{"team": "security"}},
+		{URL: "https://github.com/test/b", Metadata: Analyzer.Metadata{"archived": "true", "owner": `{"login":"test"}`}},
+	}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+}
+
+// TestGlob checks that the tasks of all files matching a glob pattern are read with the format of their extension
+func (suite *TaskSourceModuleTestSuite) TestGlob() {
+	suite.writeFile("a.csv", "https://github.com/test/a,go\n")
+	suite.writeFile("b.txt", "https://github.com/test/b\n")
+	suite.writeFile("c.jsonl", `{"url": "https://github.com/test/c"}`)
+
+	source, err := NewTaskSource(filepath.Join(suite.tempDir, "*"), nil)
+	suite.Assertions.NoError(err, "Creating the task source should not fail.")
+	tasks, err := source.Tasks()
+	suite.Assertions.NoError(err, "Reading the tasks should not fail.")
+
+	expectedTasks := []Analyzer.Task{
+		{URL: "https://github.com/test/a", Language: "go"}, {URL: "https://github.com/test/b"},
+		{URL: "https://github.com/test/c"},
+	}
+	suite.Assertions.Equal(expectedTasks, tasks, "Tasks should equal.")
+
+	// A pattern without matches is an error
+	_, err = NewTaskSource(filepath.Join(suite.tempDir, "*.ndjson"), nil)
+	suite.Assertions.Error(err, "Pattern without matches should fail.")
+}
+
+// TestGitHubSpec checks that invalid GitHub task sources are rejected
+func (suite *TaskSourceModuleTestSuite) TestGitHubSpec() {
+	source, err := NewTaskSource("github:org/gitanalyzer", nil)
+	suite.Assertions.NoError(err, "Valid GitHub source should not fail.")
+	suite.Assertions.Equal("gitanalyzer", source.(*GitHubTaskSource).Owner, "Owners should equal.")
+
+	for _, spec := range []string{"github:gitanalyzer", "github:team/gitanalyzer", "github:org/"} {
+		_, err = NewTaskSource(spec, nil)
+		suite.Assertions.Error(err, "Invalid GitHub source should fail: "+spec)
+	}
+}
+
+// TestGitHubOrg checks that the repositories of an organization are listed page by page and filtered
+func (suite *TaskSourceModuleTestSuite) TestGitHubOrg() {
+	// Stand-in for the GitHub API serving two pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Assertions.Equal("/orgs/gitanalyzer/repos", r.URL.Path, "Paths should equal.")
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"name": "fork", "clone_url": "https://github.com/gitanalyzer/fork.git", "fork": true},
+{"name": "secret", "clone_url": "https://github.com/gitanalyzer/secret.git", "private": true}]`)
+			return
+		}
+		w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `[{"name": "app", "clone_url": "https://github.com/gitanalyzer/app.git", "language": "Go",
+"stargazers_count": 3, "size": 42, "updated_at": "2023-01-02T10:00:00Z", "owner": {"login": "gitanalyzer"}},
+{"name": "old", "clone_url": "https://github.com/gitanalyzer/old.git", "archived": true}]`)
+	}))
+	defer server.Close()
+
+	// listURLs lists the repositories with the given filter and returns their URLs
+	listURLs := func(filter Analyzer.OwnerFilter) []string {
+		source := NewGitHubTaskSource("org", "gitanalyzer", "", filter)
+		source.client.BaseURL, _ = url.Parse(server.URL + "/")
+		tasks, err := source.Tasks()
+		suite.Assertions.NoError(err, "Listing repositories should not fail.")
+		var urls []string
+		for _, task := range tasks {
+			urls = append(urls, task.URL)
+		}
+		return urls
+	}
+
+	// Forks and archived repositories are excluded by default
+	suite.Assertions.Equal([]string{"https://github.com/gitanalyzer/app.git", "https://github.com/gitanalyzer/secret.git"},
+		listURLs(Analyzer.OwnerFilter{}), "URLs should equal.")
+	suite.Assertions.Equal([]string{"https://github.com/gitanalyzer/app.git", "https://github.com/gitanalyzer/old.git",
+		"https://github.com/gitanalyzer/fork.git"},
+		listURLs(Analyzer.OwnerFilter{Forks: true, Archived: true, Visibility: "public"}), "URLs should equal.")
+	suite.Assertions.Equal([]string{"https://github.com/gitanalyzer/secret.git"},
+		listURLs(Analyzer.OwnerFilter{Visibility: "private"}), "URLs should equal.")
+
+	// Check the metadata of the first repository
+	source := NewGitHubTaskSource("org", "gitanalyzer", "", Analyzer.OwnerFilter{})
+	source.client.BaseURL, _ = url.Parse(server.URL + "/")
+	tasks, _ := source.Tasks()
+	expectedTask := Analyzer.Task{URL: "https://github.com/gitanalyzer/app.git", Language: "Go", Stars: 3, Size: 42,
+		UpdateDate: "2023-01-02", Metadata: Analyzer.Metadata{"owner": "gitanalyzer", "name": "app"}}
+	suite.Assertions.Equal(expectedTask, tasks[0], "Tasks should equal.")
+}
+
+// TestGitHubUser checks that the private repositories are listed for the authenticated user
+func (suite *TaskSourceModuleTestSuite) TestGitHubUser() {
+	// Stand-in for the GitHub API of the authenticated user gitanalyzer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			fmt.Fprint(w, `{"login": "GitAnalyzer"}`)
+		case "/user/repos":
+			suite.Assertions.Equal("owner", r.URL.Query().Get("affiliation"), "Affiliations should equal.")
+			suite.Assertions.Equal("private", r.URL.Query().Get("visibility"), "Visibilities should equal.")
+			fmt.Fprint(w, `[{"name": "secret", "clone_url": "https://github.com/gitanalyzer/secret.git", "private": true}]`)
+		case "/users/other/repos":
+			suite.Assertions.Equal("owner", r.URL.Query().Get("type"), "Types should equal.")
+			fmt.Fprint(w, `[{"name": "app", "clone_url": "https://github.com/other/app.git"}]`)
+		default:
+			suite.Failf("Unexpected request", "Path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// listURLs lists the repositories of the given user and returns their URLs
+	listURLs := func(user string) []string {
+		source := NewGitHubTaskSource("user", user, "token", Analyzer.OwnerFilter{Visibility: "private"})
+		source.client.BaseURL, _ = url.Parse(server.URL + "/")
+		tasks, err := source.Tasks()
+		suite.Assertions.NoError(err, "Listing repositories should not fail.")
+		var urls []string
+		for _, task := range tasks {
+			urls = append(urls, task.URL)
+		}
+		return urls
+	}
+
+	// The own private repositories are listed, other users only have public repositories
+	suite.Assertions.Equal([]string{"https://github.com/gitanalyzer/secret.git"}, listURLs("gitanalyzer"),
+		"URLs should equal.")
+	suite.Assertions.Empty(listURLs("other"), "Public repositories should be filtered.")
+}
+
+// TestGitLabGroup checks that the projects of a group are listed page by page and filtered
+func (suite *TaskSourceModuleTestSuite) TestGitLabGroup() {
+	// Stand-in for the GitLab API serving two pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/groups/gitanalyzer/tools/projects" {
+			http.NotFound(w, r)
+			return
+		}
+		suite.Assertions.Equal("secret", r.Header.Get("PRIVATE-TOKEN"), "Tokens should equal.")
+		suite.Assertions.Equal("false", r.URL.Query().Get("archived"), "Archived projects should be excluded.")
+		suite.Assertions.Equal("true", r.URL.Query().Get("include_subgroups"), "Subgroups should be included.")
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"http_url_to_repo": "https://gitlab.com/gitanalyzer/tools/fork.git",
+"forked_from_project": {"id": 1}}]`)
+			return
+		}
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"http_url_to_repo": "https://gitlab.com/gitanalyzer/tools/app.git", "star_count": 5,
+"path_with_namespace": "gitanalyzer/tools/app", "last_activity_at": "2023-03-04T05:06:07Z", "forked_from_project": null}]`)
+	}))
+	defer server.Close()
+
+	source := NewGitLabTaskSource(server.URL, "gitanalyzer/tools", "secret", Analyzer.OwnerFilter{})
+	tasks, err := source.Tasks()
+	suite.Assertions.NoError(err, "Listing projects should not fail.")
+
+	expectedTasks := []Analyzer.Task{{URL: "https://gitlab.com/gitanalyzer/tools/app.git", Stars: 5,
+		UpdateDate: "2023-03-04", Metadata: Analyzer.Metadata{"path": "gitanalyzer/tools/app"}}}
+	suite.Assertions.Equal(expectedTasks, tasks, "Forks should be excluded.")
+
+	// Errors of the API are reported
+	source = NewGitLabTaskSource(server.URL, "unknown", "secret", Analyzer.OwnerFilter{})
+	_, err = source.Tasks()
+	suite.Assertions.Error(err, "Unknown group should fail.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestTaskSourceModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskSourceModuleTestSuite))
+}
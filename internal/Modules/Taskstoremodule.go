@@ -0,0 +1,404 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// taskStoreBatchSize is the number of tasks loaded from the task store at once
+const taskStoreBatchSize = 1000
+
+// taskOrders maps the supported orders of the tasks to the ORDER BY clause used to sort tasks with the same priority.
+// The random order is sorted by id and shuffled afterwards.
+var taskOrders = map[string]string{
+	"file":    "id",
+	"stars":   "stars DESC, id",
+	"size":    "size ASC, id",
+	"updated": "update_date DESC, id",
+	"random":  "id",
+}
+
+// taskStoreColumns are the columns added after the first version of the tasks table,
+// they are added to existing task stores when opened.
+var taskStoreColumns = map[string]string{
+	"priority":    "INTEGER NOT NULL DEFAULT 0",
+	"stars":       "INTEGER NOT NULL DEFAULT 0",
+	"size":        "INTEGER NOT NULL DEFAULT 0",
+	"update_date": "TEXT NOT NULL DEFAULT ''",
+	"metadata":    "TEXT NOT NULL DEFAULT ''",
+}
+
+// IsValidTaskOrder checks if the given order of tasks is supported
+func IsValidTaskOrder(order string) bool {
+	_, found := taskOrders[order]
+	return found
+}
+
+// The TaskStore struct persists the state of all tasks of a scan inside an embedded SQLite database.
+// It is used to schedule the tasks and to resume an interrupted scan.
+type TaskStore struct {
+	// The database connection
+	db *sql.DB
+}
+
+// OpenTaskStore opens or creates the task store at the given path.
+// If the tasks table is not found, it will be created
+func OpenTaskStore(path string) (*TaskStore, error) {
+	// Open the database, use WAL so other processes can read the progress during a scan
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only supports a single writer
+	db.SetMaxOpenConns(1)
+
+	// Create the table
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL UNIQUE,
+    language TEXT NOT NULL DEFAULT '',
+    state TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    elapsed_time TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP);
+CREATE INDEX IF NOT EXISTS tasks_state ON tasks (state);`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	taskStore := &TaskStore{db: db}
+	// Add missing columns to task stores of older versions
+	if err = taskStore.addMissingColumns(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return taskStore, nil
+}
+
+// addMissingColumns adds all columns of taskStoreColumns which are not found in the tasks table
+func (ts *TaskStore) addMissingColumns() error {
+	// Load the existing columns
+	rows, err := ts.db.Query("SELECT name FROM pragma_table_info('tasks')")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	// Add the missing columns
+	for column, definition := range taskStoreColumns {
+		if existing[column] {
+			continue
+		}
+		if _, err = ts.db.Exec("ALTER TABLE tasks ADD COLUMN " + column + " " + definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database of the task store
+func (ts *TaskStore) Close() error {
+	return ts.db.Close()
+}
+
+// AddTasks inserts the given tasks as queued tasks.
+// For tasks with an URL which is already known, only the priority and metadata are updated.
+// Returns the number of inserted tasks.
+func (ts *TaskStore) AddTasks(tasks []Analyzer.Task) (int, error) {
+	// Count the tasks before the insert
+	before, err := ts.count()
+	if err != nil {
+		return 0, err
+	}
+
+	// Insert all tasks inside one transaction, which is a lot faster for large task lists
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO tasks (url, language, priority, stars, size, update_date, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (url) DO UPDATE SET priority = excluded.priority, stars = excluded.stars, size = excluded.size,
+update_date = excluded.update_date, metadata = excluded.metadata`)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, task := range tasks {
+		// Store the metadata as json
+		metadata, errMetadata := task.Metadata.MarshalCSV()
+		if errMetadata != nil {
+			tx.Rollback()
+			return 0, errMetadata
+		}
+		_, errExec := stmt.Exec(task.URL, task.Language, task.Priority, task.Stars, task.Size, task.UpdateDate, metadata)
+		if errExec != nil {
+			tx.Rollback()
+			return 0, errExec
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// Count the inserted tasks
+	after, err := ts.count()
+	return after - before, err
+}
+
+// count returns the number of all tasks
+func (ts *TaskStore) count() (int, error) {
+	var count int
+	err := ts.db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&count)
+	return count, err
+}
+
+// ResetUnfinishedTasks queues all tasks again, which were interrupted during a previous scan.
+// The failed tasks are only queued again if retryFailed is set. Tasks which were already started maxAttempts
+// times are marked as failed instead, so a repository which crashes every scan is not tried forever.
+// Returns the number of tasks which were queued again.
+func (ts *TaskStore) ResetUnfinishedTasks(retryFailed bool, maxAttempts int) (int64, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	states := "'cloning', 'running'"
+	if retryFailed {
+		states += ", 'failed'"
+	}
+	_, err := ts.db.Exec(`UPDATE tasks SET state = 'failed', updated_at = CURRENT_TIMESTAMP
+WHERE state IN ('cloning', 'running') AND attempts >= ?`, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	res, err := ts.db.Exec(`UPDATE tasks SET state = 'queued', updated_at = CURRENT_TIMESTAMP
+WHERE state IN (`+states+`) AND attempts < ?`, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RequeueTasks queues the tasks with the given URLs again, regardless of their state, e.g. to scan the
+// repositories of an earlier scan session again. Their attempts are reset.
+// Returns the number of tasks which were queued again.
+func (ts *TaskStore) RequeueTasks(tasks []Analyzer.Task) (int64, error) {
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(`UPDATE tasks SET state = 'queued', attempts = 0, updated_at = CURRENT_TIMESTAMP
+WHERE url = ? AND state != 'queued'`)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var requeued int64
+	for _, task := range tasks {
+		res, errExec := stmt.Exec(task.URL)
+		if errExec != nil {
+			tx.Rollback()
+			return 0, errExec
+		}
+		affected, errAffected := res.RowsAffected()
+		if errAffected != nil {
+			tx.Rollback()
+			return 0, errAffected
+		}
+		requeued += affected
+	}
+	return requeued, tx.Commit()
+}
+
+// QueuedTaskIDs returns the IDs of all queued tasks in the order they should be scanned.
+// Tasks with a higher priority are always returned first, tasks with the same priority are sorted
+// by the given order. The seed is used to shuffle the tasks for the random order.
+func (ts *TaskStore) QueuedTaskIDs(order string, seed int64) ([]int64, error) {
+	if order == "" {
+		order = "file"
+	}
+	orderBy, found := taskOrders[order]
+	if !found {
+		return nil, fmt.Errorf("unknown task order: %s", order)
+	}
+
+	rows, err := ts.db.Query("SELECT id, priority FROM tasks WHERE state = 'queued' ORDER BY priority DESC, " + orderBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var priorities []int
+	for rows.Next() {
+		var id int64
+		var priority int
+		if err = rows.Scan(&id, &priority); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		priorities = append(priorities, priority)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if order == "random" {
+		shuffleWithinPriorities(ids, priorities, seed)
+	}
+	return ids, nil
+}
+
+// shuffleWithinPriorities shuffles the given IDs using the seed,
+// while IDs with a higher priority stay in front of IDs with a lower priority.
+// The ids must be sorted by the corresponding priorities.
+func shuffleWithinPriorities(ids []int64, priorities []int, seed int64) {
+	random := rand.New(rand.NewSource(seed))
+	start := 0
+	for end := 1; end <= len(ids); end++ {
+		// Find the end of the current priority
+		if end < len(ids) && priorities[end] == priorities[start] {
+			continue
+		}
+		// Shuffle the IDs of the current priority
+		group := ids[start:end]
+		random.Shuffle(len(group), func(i, j int) {
+			group[i], group[j] = group[j], group[i]
+		})
+		start = end
+	}
+}
+
+// GetTasksByIDs loads the tasks with the given IDs.
+// The tasks are returned in the order of the given IDs.
+func (ts *TaskStore) GetTasksByIDs(ids []int64) ([]Analyzer.Task, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// Build the placeholders for the IN clause
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	tasks, err := ts.queryTasks("WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore the order of the given IDs
+	tasksByID := make(map[int64]Analyzer.Task, len(tasks))
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+	ordered := make([]Analyzer.Task, 0, len(tasks))
+	for _, id := range ids {
+		if task, found := tasksByID[id]; found {
+			ordered = append(ordered, task)
+		}
+	}
+	return ordered, nil
+}
+
+// GetTasksByState loads all tasks with the given state
+func (ts *TaskStore) GetTasksByState(state string) ([]Analyzer.Task, error) {
+	return ts.queryTasks("WHERE state = ? ORDER BY id", state)
+}
+
+// queryTasks loads all tasks matching the given where clause and arguments
+func (ts *TaskStore) queryTasks(where string, args ...interface{}) ([]Analyzer.Task, error) {
+	rows, err := ts.db.Query(`SELECT id, url, language, priority, stars, size, update_date, metadata, state, attempts,
+elapsed_time, updated_at FROM tasks `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []Analyzer.Task
+	for rows.Next() {
+		var task Analyzer.Task
+		var metadata string
+		if err = rows.Scan(&task.ID, &task.URL, &task.Language, &task.Priority, &task.Stars, &task.Size,
+			&task.UpdateDate, &metadata, &task.State, &task.Attempts, &task.ElapsedTime, &task.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err = task.Metadata.UnmarshalCSV(metadata); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// UpdateState persists the state of the given task.
+// The attempts of the task are incremented every time a task starts cloning.
+func (ts *TaskStore) UpdateState(task Analyzer.Task) error {
+	_, err := ts.db.Exec(`UPDATE tasks SET state = ?, elapsed_time = ?,
+attempts = attempts + CASE WHEN ? = 'cloning' THEN 1 ELSE 0 END, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		task.State, task.ElapsedTime, task.State, task.ID)
+	return err
+}
+
+// CountByState returns a map state => number of tasks with that state
+func (ts *TaskStore) CountByState() (map[string]int, error) {
+	rows, err := ts.db.Query("SELECT state, COUNT(*) FROM tasks GROUP BY state")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var state string
+		var count int
+		if err = rows.Scan(&state, &count); err != nil {
+			return nil, err
+		}
+		counts[state] = count
+	}
+	return counts, rows.Err()
+}
+
+// feedQueuedTasks sends the tasks with the given IDs in batches to the provided channel.
+// The channel is closed once all tasks were sent.
+func (ts *TaskStore) feedQueuedTasks(ids []int64, queue chan<- Analyzer.Task) error {
+	defer close(queue)
+	for start := 0; start < len(ids); start += taskStoreBatchSize {
+		end := start + taskStoreBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		// Load the next batch
+		tasks, err := ts.GetTasksByIDs(ids[start:end])
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			// Set state to queued
+			task.State = "queued"
+			queue <- task
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"os"
+	"testing"
+)
+
+// Create test suite for the task store module
+type TaskStoreModuleTestSuite struct {
+	suite.Suite
+	tempDir   string
+	taskStore *TaskStore
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *TaskStoreModuleTestSuite) SetupTest() {
+	// Create and set a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	// Open a task store inside the temporary directory
+	taskStore, err := OpenTaskStore(suite.tempDir + string(os.PathSeparator) + "tasks.db")
+	if err != nil {
+		log.Fatalln("Error opening test task store:", err)
+	}
+	suite.taskStore = taskStore
+}
+
+// TearDownTest is run after every test of the test suite to close the task store
+func (suite *TaskStoreModuleTestSuite) TearDownTest() {
+	suite.taskStore.Close()
+}
+
+// TestAddTasks checks that tasks are added once and can be loaded in order
+func (suite *TaskStoreModuleTestSuite) TestAddTasks() {
+	tasks := []Analyzer.Task{{URL: "testURL1", Language: "go"},
+		{URL: "testURL2", Metadata: Analyzer.Metadata{"team": "security"}}, {URL: "testURL1"}}
+
+	// Call AddTasks twice, the known tasks should be ignored
+	added, err := suite.taskStore.AddTasks(tasks)
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	suite.Assertions.Equal(2, added, "Duplicated URLs should be ignored.")
+	added, err = suite.taskStore.AddTasks(tasks)
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	suite.Assertions.Equal(0, added, "Known URLs should be ignored.")
+
+	// Load the queued tasks
+	ids, err := suite.taskStore.QueuedTaskIDs("", 0)
+	suite.Assertions.NoError(err, "Loading queued tasks should not fail.")
+	gotTasks, err := suite.taskStore.GetTasksByIDs([]int64{ids[1], ids[0]})
+	suite.Assertions.NoError(err, "Loading tasks should not fail.")
+
+	// Check that the tasks are returned in the order of the IDs
+	suite.Assertions.Len(gotTasks, 2, "Should return 2 tasks.")
+	suite.Assertions.Equal("testURL2", gotTasks[0].URL, "URLs should equal.")
+	suite.Assertions.Equal(Analyzer.Metadata{"team": "security"}, gotTasks[0].Metadata, "Metadata should equal.")
+	suite.Assertions.Equal("testURL1", gotTasks[1].URL, "URLs should equal.")
+	suite.Assertions.Equal("go", gotTasks[1].Language, "Languages should equal.")
+	suite.Assertions.Equal("queued", gotTasks[1].State, "States should equal.")
+}
+
+// TestResume checks that the state of tasks is persisted and unfinished tasks are queued again
+func (suite *TaskStoreModuleTestSuite) TestResume() {
+	_, err := suite.taskStore.AddTasks([]Analyzer.Task{{URL: "testURL1"}, {URL: "testURL2"}, {URL: "testURL3"}})
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	ids, _ := suite.taskStore.QueuedTaskIDs("", 0)
+
+	// Finish the first task and interrupt the second one while running
+	updates := []Analyzer.Task{
+		{ID: ids[0], State: "cloning"}, {ID: ids[0], State: "running"}, {ID: ids[0], State: "finished", ElapsedTime: "1s"},
+		{ID: ids[1], State: "cloning"}, {ID: ids[1], State: "running"},
+	}
+	for _, update := range updates {
+		suite.Assertions.NoError(suite.taskStore.UpdateState(update), "Updating state should not fail.")
+	}
+
+	// Check the counts per state
+	counts, err := suite.taskStore.CountByState()
+	suite.Assertions.NoError(err, "Counting tasks should not fail.")
+	suite.Assertions.Equal(map[string]int{"finished": 1, "running": 1, "queued": 1}, counts, "Counts should equal.")
+
+	// Reopen the store and resume the scan
+	suite.taskStore.Close()
+	suite.taskStore, err = OpenTaskStore(suite.tempDir + string(os.PathSeparator) + "tasks.db")
+	suite.Assertions.NoError(err, "Reopening the task store should not fail.")
+	resumed, err := suite.taskStore.ResetUnfinishedTasks(false, 3)
+	suite.Assertions.NoError(err, "Resetting tasks should not fail.")
+	suite.Assertions.Equal(int64(1), resumed, "Running task should be queued again.")
+
+	// Check that only the unfinished tasks are queued
+	queuedIDs, _ := suite.taskStore.QueuedTaskIDs("", 0)
+	suite.Assertions.Equal([]int64{ids[1], ids[2]}, queuedIDs, "Queued tasks should equal.")
+	queuedTasks, _ := suite.taskStore.GetTasksByIDs(queuedIDs)
+	suite.Assertions.Equal(1, queuedTasks[0].Attempts, "Attempts should be persisted.")
+	finishedTasks, _ := suite.taskStore.GetTasksByState("finished")
+	suite.Assertions.Equal("1s", finishedTasks[0].ElapsedTime, "Elapsed time should be persisted.")
+}
+
+// TestResume_FailedTasks checks that failed tasks are only queued again on request and the attempts are limited
+func (suite *TaskStoreModuleTestSuite) TestResume_FailedTasks() {
+	_, err := suite.taskStore.AddTasks([]Analyzer.Task{{URL: "testURL1"}, {URL: "testURL2"}, {URL: "testURL3"}})
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	ids, _ := suite.taskStore.QueuedTaskIDs("", 0)
+
+	// Fail the first task, the second one is interrupted twice and the third one fails twice
+	updates := []Analyzer.Task{
+		{ID: ids[0], State: "cloning"}, {ID: ids[0], State: "failed"},
+		{ID: ids[1], State: "cloning"}, {ID: ids[1], State: "cloning"}, {ID: ids[1], State: "running"},
+		{ID: ids[2], State: "cloning"}, {ID: ids[2], State: "cloning"}, {ID: ids[2], State: "failed"},
+	}
+	for _, update := range updates {
+		suite.Assertions.NoError(suite.taskStore.UpdateState(update), "Updating state should not fail.")
+	}
+
+	// Without retry, only the interrupted task is queued again
+	resumed, err := suite.taskStore.ResetUnfinishedTasks(false, 3)
+	suite.Assertions.NoError(err, "Resetting tasks should not fail.")
+	suite.Assertions.Equal(int64(1), resumed, "Interrupted task should be queued again.")
+	counts, _ := suite.taskStore.CountByState()
+	suite.Assertions.Equal(map[string]int{"failed": 2, "queued": 1}, counts, "Counts should equal.")
+
+	// With retry, only the failed task below the maximum attempts is queued again
+	suite.Assertions.NoError(suite.taskStore.UpdateState(Analyzer.Task{ID: ids[1], State: "running"}),
+		"Updating state should not fail.")
+	resumed, err = suite.taskStore.ResetUnfinishedTasks(true, 2)
+	suite.Assertions.NoError(err, "Resetting tasks should not fail.")
+	suite.Assertions.Equal(int64(1), resumed, "Failed task should be queued again.")
+	queuedIDs, _ := suite.taskStore.QueuedTaskIDs("", 0)
+	suite.Assertions.Equal([]int64{ids[0]}, queuedIDs, "Queued tasks should equal.")
+	failedTasks, _ := suite.taskStore.GetTasksByState("failed")
+	suite.Assertions.Len(failedTasks, 2, "Tasks with the maximum attempts should be failed.")
+}
+
+// TestRequeueTasks checks that the tasks of an earlier scan session are queued again for a rescan
+func (suite *TaskStoreModuleTestSuite) TestRequeueTasks() {
+	tasks := []Analyzer.Task{{URL: "testURL1"}, {URL: "testURL2"}, {URL: "testURL3"}}
+	_, err := suite.taskStore.AddTasks(tasks)
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	ids, _ := suite.taskStore.QueuedTaskIDs("", 0)
+	updates := []Analyzer.Task{
+		{ID: ids[0], State: "cloning"}, {ID: ids[0], State: "finished"},
+		{ID: ids[1], State: "cloning"}, {ID: ids[1], State: "failed"},
+	}
+	for _, update := range updates {
+		suite.Assertions.NoError(suite.taskStore.UpdateState(update), "Updating state should not fail.")
+	}
+
+	// Adding the same tasks again keeps their state
+	added, err := suite.taskStore.AddTasks(tasks)
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+	suite.Assertions.Equal(0, added, "Known tasks should not be added.")
+	queuedIDs, _ := suite.taskStore.QueuedTaskIDs("", 0)
+	suite.Assertions.Equal([]int64{ids[2]}, queuedIDs, "Queued tasks should equal.")
+
+	// The rescan queues the finished and failed tasks again
+	requeued, err := suite.taskStore.RequeueTasks(tasks)
+	suite.Assertions.NoError(err, "Requeueing tasks should not fail.")
+	suite.Assertions.Equal(int64(2), requeued, "Scanned tasks should be queued again.")
+	queuedIDs, _ = suite.taskStore.QueuedTaskIDs("", 0)
+	suite.Assertions.Equal(ids, queuedIDs, "All tasks should be queued.")
+	queuedTasks, _ := suite.taskStore.GetTasksByIDs(queuedIDs)
+	suite.Assertions.Equal(0, queuedTasks[0].Attempts, "Attempts should be reset.")
+}
+
+// TestQueuedTaskIDs_Order checks that tasks are ordered by priority and the given order
+func (suite *TaskStoreModuleTestSuite) TestQueuedTaskIDs_Order() {
+	_, err := suite.taskStore.AddTasks([]Analyzer.Task{
+		{URL: "testURL1", Stars: 5, Size: 300, UpdateDate: "2021-01-01"},
+		{URL: "testURL2", Stars: 50, Size: 100, UpdateDate: "2020-01-01"},
+		{URL: "testURL3", Stars: 10, Size: 200, UpdateDate: "2023-01-01"},
+		{URL: "testURL4", Priority: 1, Stars: 1, Size: 400, UpdateDate: "2019-01-01"},
+	})
+	suite.Assertions.NoError(err, "Adding tasks should not fail.")
+
+	// Set the expected orders of the URLs
+	expectedOrders := map[string][]string{
+		"file":    {"testURL4", "testURL1", "testURL2", "testURL3"},
+		"stars":   {"testURL4", "testURL2", "testURL3", "testURL1"},
+		"size":    {"testURL4", "testURL2", "testURL3", "testURL1"},
+		"updated": {"testURL4", "testURL3", "testURL1", "testURL2"},
+	}
+	for order, expectedURLs := range expectedOrders {
+		// Call QueuedTaskIDs with the current order
+		ids, errIDs := suite.taskStore.QueuedTaskIDs(order, 0)
+		suite.Assertions.NoError(errIDs, "Loading queued tasks should not fail.")
+		tasks, _ := suite.taskStore.GetTasksByIDs(ids)
+		var gotURLs []string
+		for _, task := range tasks {
+			gotURLs = append(gotURLs, task.URL)
+		}
+		// Check that the expected and actual orders are equal
+		suite.Assertions.Equal(expectedURLs, gotURLs, "Order should equal: "+order)
+	}
+
+	// Check that the random order is reproducible and keeps the priority
+	firstIDs, _ := suite.taskStore.QueuedTaskIDs("random", 42)
+	secondIDs, _ := suite.taskStore.QueuedTaskIDs("random", 42)
+	fileIDs, _ := suite.taskStore.QueuedTaskIDs("file", 0)
+	suite.Assertions.Equal(firstIDs, secondIDs, "Same seed should return the same order.")
+	suite.Assertions.Equal(fileIDs[0], firstIDs[0], "Task with the highest priority should be first.")
+	suite.Assertions.ElementsMatch(fileIDs, firstIDs, "Random order should contain all tasks.")
+
+	// Check that unknown orders are rejected
+	_, err = suite.taskStore.QueuedTaskIDs("unknown", 0)
+	suite.Assertions.Error(err, "Unknown order should fail.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestTaskStoreModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskStoreModuleTestSuite))
+}
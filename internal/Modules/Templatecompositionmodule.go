@@ -0,0 +1,351 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regexLibrarySuffix is the file ending of the regex library files, which are included by templates and not loaded
+// as templates themselves
+const regexLibrarySuffix = ".lib.yaml"
+
+// templateVariable matches the variables ${name} of the templates, $${name} escapes a literal ${name}
+var templateVariable = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// The regexSource struct locates a regular expression of a composed template inside the file defining it
+type regexSource struct {
+	// Path of the template or library file
+	file string
+	// Node tree of the file
+	root *yaml.Node
+	// Index of the regular expression inside the regex list of the file
+	index int
+}
+
+// The templateFile struct contains a template file and the template composed from its base template and includes
+type templateFile struct {
+	path    string
+	content []byte
+	root    *yaml.Node
+	// The template as it is defined in the file
+	template Analyzer.Template
+	// Problems of the file itself like syntax errors and unknown keys
+	problems []TemplateError
+	// The composed template, the variables are not substituted yet
+	composed Analyzer.Template
+	// Sources of the regular expressions of the composed template
+	sources []regexSource
+	// Content of the file, its base templates and includes, used to identify the version of the template
+	hashInput []byte
+	// State of the composition, used to detect cycles
+	resolving, resolved, failed bool
+	// If incomplete is set, the base template or an include couldn't be added
+	incomplete bool
+}
+
+// The regexLibraryFile struct contains a regex library file and the regular expressions of its includes
+type regexLibraryFile struct {
+	path    string
+	content []byte
+	root    *yaml.Node
+	library Analyzer.RegexLibrary
+	// The regular expressions of the library and its includes with their sources
+	regexes []Analyzer.Regex
+	sources []regexSource
+	// The variables of the library and its includes
+	variables map[string]string
+	hashInput []byte
+	// State of the composition, used to detect cycles
+	resolving, resolved, failed bool
+}
+
+// The templateLoader struct composes the templates of a directory
+type templateLoader struct {
+	// Map template name => file defining the template
+	names map[string]*templateFile
+	// Map absolute path => regex library file
+	libraries map[string]*regexLibraryFile
+	// Problems of the regex library files
+	problems []TemplateError
+}
+
+// newTemplateLoader is the constructor to create a new templateLoader
+func newTemplateLoader() *templateLoader {
+	return &templateLoader{names: make(map[string]*templateFile), libraries: make(map[string]*regexLibraryFile)}
+}
+
+// isRegexLibrary returns true if the file is a regex library file
+func isRegexLibrary(path string) bool {
+	return strings.HasSuffix(path, regexLibrarySuffix)
+}
+
+// resolve composes the template of the file from its base template and includes.
+// Returns false if the template or one of its base templates and includes is invalid.
+func (tl *templateLoader) resolve(file *templateFile, chain []string) bool {
+	if file.resolved {
+		return !file.failed
+	}
+	file.resolving = true
+	chain = append(chain, file.template.Name)
+	// fail adds a problem at the line of the field with the given path
+	fail := func(message string, field ...interface{}) {
+		file.problems = append(file.problems, TemplateError{File: file.path, Line: nodeLine(file.root, field...),
+			Message: message})
+		file.incomplete = true
+	}
+
+	composed := file.template
+	var regexes []Analyzer.Regex
+	var sources []regexSource
+	variables := make(map[string]string)
+	var hashInput []byte
+
+	// Start with the composed base template
+	if file.template.Extends != "" {
+		base, found := tl.names[file.template.Extends]
+		switch {
+		case !found:
+			fail(fmt.Sprintf("base template %q not found", file.template.Extends), "extends")
+		case base.resolving:
+			fail("extends cycle: "+strings.Join(append(chain, base.template.Name), " -> "), "extends")
+		case !tl.resolve(base, chain):
+			fail(fmt.Sprintf("base template %q is invalid", file.template.Extends), "extends")
+		default:
+			composed = inheritTemplate(file.template, base.composed, file.root)
+			regexes = append(regexes, base.composed.Regex...)
+			sources = append(sources, base.sources...)
+			mergeVariables(variables, base.composed.Variables)
+			hashInput = append(hashInput, base.hashInput...)
+		}
+	}
+
+	// Add the regular expressions of the regex libraries
+	for i, include := range file.template.Include {
+		library, problem := tl.loadLibrary(filepath.Join(filepath.Dir(file.path), include), nil)
+		if problem != "" {
+			fail(problem, "include", i)
+			continue
+		}
+		regexes = append(regexes, library.regexes...)
+		sources = append(sources, library.sources...)
+		mergeVariables(variables, library.variables)
+		hashInput = append(hashInput, library.hashInput...)
+	}
+
+	// Add the own regular expressions and variables, they override the ones of the base template and includes
+	for i, regex := range file.template.Regex {
+		regexes = append(regexes, regex)
+		sources = append(sources, regexSource{file: file.path, root: file.root, index: i})
+	}
+	mergeVariables(variables, file.template.Variables)
+	composed.Regex = regexes
+	composed.Variables = variables
+
+	file.composed, file.sources = composed, sources
+	file.hashInput = append(hashInput, file.content...)
+	file.failed = len(file.problems) > 0
+	file.resolving, file.resolved = false, true
+	return !file.failed
+}
+
+// loadLibrary loads the regex library file and its includes.
+// Returns a description of the problem if the library can't be loaded or is invalid.
+func (tl *templateLoader) loadLibrary(path string, chain []string) (*regexLibraryFile, string) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if library, found := tl.libraries[absolutePath]; found {
+		if library.resolving {
+			return nil, "include cycle: " + strings.Join(append(chain, path), " -> ")
+		}
+		if library.failed {
+			return nil, fmt.Sprintf("included library %s is invalid", path)
+		}
+		return library, ""
+	}
+	if !isRegexLibrary(path) {
+		return nil, fmt.Sprintf("included file %s is no regex library (%s)", path, regexLibrarySuffix)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	library := &regexLibraryFile{path: path, content: content, variables: make(map[string]string), resolving: true}
+	tl.libraries[absolutePath] = library
+	chain = append(chain, path)
+	root, problems := decodeStrict(path, content, &library.library)
+	library.root = root
+	if root == nil {
+		// Libraries with syntax errors can't be composed
+		library.library = Analyzer.RegexLibrary{}
+	}
+
+	// Add the regular expressions of the included libraries before the own ones
+	for i, include := range library.library.Include {
+		included, problem := tl.loadLibrary(filepath.Join(filepath.Dir(path), include), chain)
+		if problem != "" {
+			problems = append(problems, TemplateError{File: path, Line: nodeLine(root, "include", i), Message: problem})
+			continue
+		}
+		library.regexes = append(library.regexes, included.regexes...)
+		library.sources = append(library.sources, included.sources...)
+		mergeVariables(library.variables, included.variables)
+		library.hashInput = append(library.hashInput, included.hashInput...)
+	}
+	for i, regex := range library.library.Regex {
+		library.regexes = append(library.regexes, regex)
+		library.sources = append(library.sources, regexSource{file: path, root: root, index: i})
+	}
+	mergeVariables(library.variables, library.library.Variables)
+	library.hashInput = append(library.hashInput, content...)
+
+	tl.problems = append(tl.problems, problems...)
+	library.failed = len(problems) > 0
+	library.resolving, library.resolved = false, true
+	if library.failed {
+		return nil, fmt.Sprintf("included library %s is invalid", path)
+	}
+	return library, ""
+}
+
+// inheritTemplate returns the template with all fields its file doesn't set taken from the base template.
+// Nested structs like match or meta are inherited field by field. The name, the composition fields and the
+// regular expressions are not inherited, they are composed by the templateLoader.
+func inheritTemplate(template, base Analyzer.Template, root *yaml.Node) Analyzer.Template {
+	composed := template
+	inheritFields(reflect.ValueOf(&composed).Elem(), reflect.ValueOf(base), documentNode(root))
+	composed.Name, composed.Hash = template.Name, template.Hash
+	composed.Extends, composed.Abstract, composed.Include = template.Extends, template.Abstract, template.Include
+	return composed
+}
+
+// inheritFields sets all fields of the struct, whose keys are missing in the mapping node, to the fields of the
+// base struct. Nested structs are merged field by field, so false or 0 set in the file override the base struct.
+func inheritFields(value, base reflect.Value, node *yaml.Node) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		key := yamlKey(value.Type().Field(i))
+		if !field.CanSet() || key == "-" {
+			continue
+		}
+		child := mappingValue(node, key)
+		switch {
+		case child == nil:
+			field.Set(base.Field(i))
+		case field.Kind() == reflect.Struct:
+			inheritFields(field, base.Field(i), child)
+		}
+	}
+}
+
+// yamlKey returns the key of the struct field inside the yaml files
+func yamlKey(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if key == "" {
+		return strings.ToLower(field.Name)
+	}
+	return key
+}
+
+// documentNode returns the top level node of the document, nil stays nil
+func documentNode(root *yaml.Node) *yaml.Node {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		return root.Content[0]
+	}
+	return root
+}
+
+// mappingValue returns the value of the key inside the mapping node, nil if the key is missing
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; node != nil && node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mergeVariables copies the variables into the map, existing variables are overridden
+func mergeVariables(variables, override map[string]string) {
+	for name, value := range override {
+		variables[name] = value
+	}
+}
+
+// substituteVariables returns the template with its variables substituted in all fields except the code of the
+// scripts, where ${name} is a variable of the shell. The names of the undefined variables are returned sorted,
+// they are kept in the template.
+func substituteVariables(template Analyzer.Template) (Analyzer.Template, []string) {
+	undefined := make(map[string]bool)
+	replace := func(text string) string {
+		return templateVariable.ReplaceAllStringFunc(text, func(match string) string {
+			// $${name} is an escaped variable
+			if strings.HasPrefix(match, "$$") {
+				return match[1:]
+			}
+			name := match[2 : len(match)-1]
+			value, found := template.Variables[name]
+			if !found {
+				undefined[name] = true
+				return match
+			}
+			return value
+		})
+	}
+	// Keep the code of the scripts unchanged
+	codes := []string{template.Script.Code, template.PreScript.Code, template.PostScript.Code}
+	template.Script.Code, template.PreScript.Code, template.PostScript.Code = "", "", ""
+	substituteValue(reflect.ValueOf(&template).Elem(), replace)
+	template.Script.Code, template.PreScript.Code, template.PostScript.Code = codes[0], codes[1], codes[2]
+
+	var names []string
+	for name := range undefined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return template, names
+}
+
+// substituteValue replaces all strings of the value, slices are copied so the base templates and libraries
+// sharing them are not modified
+func substituteValue(value reflect.Value, replace func(string) string) {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(replace(value.String()))
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Field(i).CanSet() {
+				substituteValue(value.Field(i), replace)
+			}
+		}
+	case reflect.Slice:
+		if value.IsNil() {
+			return
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(copied, value)
+		for i := 0; i < copied.Len(); i++ {
+			substituteValue(copied.Index(i), replace)
+		}
+		value.Set(copied)
+	}
+}
+
+// variableLine returns the first line of the content using the variable, 0 if the variable isn't used in it
+func variableLine(content []byte, name string) int {
+	index := strings.Index(string(content), "${"+name+"}")
+	if index < 0 {
+		return 0
+	}
+	return strings.Count(string(content[:index]), "\n") + 1
+}
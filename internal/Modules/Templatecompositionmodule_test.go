@@ -0,0 +1,215 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Create test suite for the template composition module
+type TemplateCompositionModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *TemplateCompositionModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+}
+
+// writeFile writes the file into the temporary directory and returns its path
+func (suite *TemplateCompositionModuleTestSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.tempDir, name)
+	suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+	suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Writing the file should not fail.")
+	return path
+}
+
+// TestComposeTemplate checks that base templates, regex libraries and variables are composed
+func (suite *TemplateCompositionModuleTestSuite) TestComposeTemplate() {
+	suite.writeFile("base.yaml", `name: "Base"
+abstract: true
+type: "Full"
+variables:
+  files: ".env"
+  severity: "low"
+match:
+  exclude: ["node_modules"]
+meta:
+  severity: "${severity}"
+  mitigation: "Remove the ${kind} from the ${files} files."
+regex:
+  - description: "Base ${kind}"
+    expression: "${kind}=(\\w+)"
+    group: 1
+    file_endings: ["${files}"]
+`)
+	suite.writeFile("lib/shared.lib.yaml", `variables:
+  prefix: "sk"
+regex:
+  - description: "Shared key"
+    expression: "${prefix}_(\\w+)"
+    group: 1
+    file_endings: ["${files}"]
+`)
+	suite.writeFile("child.yaml", `name: "Child"
+extends: "Base"
+include: ["lib/shared.lib.yaml"]
+variables:
+  kind: "token"
+  severity: "high"
+match:
+  filename: ["config"]
+meta:
+  impact: "Costs $${amount}"
+regex:
+  - description: "Own"
+    expression: "own=(\\w+)"
+    group: 1
+    filename: ["config"]
+`)
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Empty(problems, "Composed template should be valid.")
+	suite.Assertions.Len(templates, 1, "Abstract template should not be loaded.")
+
+	template := templates[0]
+	suite.Assertions.Equal("Child", template.Name, "Template name should equal.")
+	suite.Assertions.False(template.Abstract, "Abstract flag should not be inherited.")
+	suite.Assertions.Equal("Full", template.Type, "Type should be inherited.")
+	suite.Assertions.Equal(Analyzer.Match{Filename: []string{"config"}, Exclude: []string{"node_modules"}},
+		template.Match, "Match should be inherited field by field.")
+	suite.Assertions.Equal(Analyzer.Meta{Severity: "high", Impact: "Costs ${amount}",
+		Mitigation: "Remove the token from the .env files."}, template.Meta, "Variables should be substituted.")
+	suite.Assertions.Equal(map[string]string{"files": ".env", "severity": "high", "kind": "token", "prefix": "sk"},
+		template.Variables, "Variables should be merged.")
+
+	var descriptions, expressions []string
+	for _, regex := range template.Regex {
+		descriptions = append(descriptions, regex.Description)
+		expressions = append(expressions, regex.Expression)
+		suite.Assertions.NotNil(regex.Compiled, "Expression should be compiled.")
+	}
+	suite.Assertions.Equal([]string{"Base token", "Shared key", "Own"}, descriptions,
+		"Regexes of the base template and library should be added first.")
+	suite.Assertions.Equal([]string{"token=(\\w+)", "sk_(\\w+)", "own=(\\w+)"}, expressions,
+		"Variables of the expressions should be substituted.")
+	suite.Assertions.Equal([]string{".env"}, template.Regex[1].FileEndings, "Library should use the template variables.")
+	suite.Assertions.Len(template.Hash, 12, "Template version should be set.")
+
+	// The version changes if a base template or library changes
+	hash := template.Hash
+	suite.writeFile("lib/shared.lib.yaml", "regex:\n  - expression: \"sk_(\\\\w+)\"\n    group: 1\n    file_endings: [\".env\"]\n")
+	templates, _ = ValidateTemplates(suite.tempDir)
+	suite.Assertions.NotEqual(hash, templates[0].Hash, "Template version should change with the library.")
+}
+
+// TestCompositionCycles checks that cycles and missing base templates and libraries are reported
+func (suite *TemplateCompositionModuleTestSuite) TestCompositionCycles() {
+	first := suite.writeFile("a.yaml", "name: \"A\"\nextends: \"B\"\nscript:\n  code: \"ls\"\n")
+	second := suite.writeFile("b.yaml", "name: \"B\"\nextends: \"A\"\nscript:\n  code: \"ls\"\n")
+	missing := suite.writeFile("c.yaml", "name: \"C\"\nextends: \"Unknown\"\nscript:\n  code: \"ls\"\n")
+	includes := suite.writeFile("d.yaml", "name: \"D\"\nscript:\n  code: \"ls\"\ninclude:\n  - \"one.lib.yaml\"\n"+
+		"  - \"missing.lib.yaml\"\n  - \"e.yaml\"\n")
+	one := suite.writeFile("one.lib.yaml", "include: [\"two.lib.yaml\"]\n")
+	two := suite.writeFile("two.lib.yaml", "include: [\"one.lib.yaml\"]\n")
+	variable := suite.writeFile("e.yaml", "name: \"E\"\nscript:\n  code: \"echo ls\"\nmeta:\n  impact: \"${unknown}\"\n")
+
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Empty(templates, "Invalid templates should not be loaded.")
+	suite.Assertions.Equal([]TemplateError{
+		{File: first, Line: 2, Message: `base template "B" is invalid`},
+		{File: second, Line: 2, Message: "extends cycle: A -> B -> A"},
+		{File: missing, Line: 2, Message: `base template "Unknown" not found`},
+		{File: includes, Line: 5, Message: "included library " + one + " is invalid"},
+		{File: includes, Line: 6, Message: "open " + filepath.Join(suite.tempDir, "missing.lib.yaml") +
+			": no such file or directory"},
+		{File: includes, Line: 7, Message: "included file " + variable + " is no regex library (.lib.yaml)"},
+		{File: variable, Line: 5, Message: "variable ${unknown} is not defined"},
+		{File: one, Line: 1, Message: "included library " + two + " is invalid"},
+		{File: two, Line: 1, Message: "include cycle: " + one + " -> " + two + " -> " + one},
+	}, problems, "Problems should equal.")
+}
+
+// TestScriptVariables checks that ${name} inside the code of the scripts is kept for the shell
+func (suite *TemplateCompositionModuleTestSuite) TestScriptVariables() {
+	suite.writeFile("a.yaml", `name: "A"
+variables:
+  dir: "src"
+meta:
+  impact: "${dir}"
+pre_script:
+  code: "echo ${HOME}"
+script:
+  code: "ls ${dir} ${unknown}"
+`)
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Empty(problems, "Variables of the shell should not be reported.")
+	suite.Assertions.Len(templates, 1, "Template should be loaded.")
+	suite.Assertions.Equal("src", templates[0].Meta.Impact, "Variables should be substituted.")
+	suite.Assertions.Equal("ls ${dir} ${unknown}", templates[0].Script.Code, "Script should be kept.")
+	suite.Assertions.Equal("echo ${HOME}", templates[0].PreScript.Code, "Pre script should be kept.")
+}
+
+// TestInheritSetFields checks that false and 0 set by the template override the values of the base template
+func (suite *TemplateCompositionModuleTestSuite) TestInheritSetFields() {
+	suite.writeFile("base.yaml", `name: "Base"
+abstract: true
+max_commits: 10
+output:
+  uniq: true
+when:
+  min_stars: 100
+script:
+  code: "ls"
+`)
+	suite.writeFile("child.yaml", `name: "Child"
+extends: "Base"
+max_commits: 0
+output:
+  uniq: false
+`)
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Empty(problems, "Composed template should be valid.")
+	suite.Assertions.Len(templates, 1, "Abstract template should not be loaded.")
+	suite.Assertions.Equal(0, templates[0].MaxCommits, "Set max commits should override the base template.")
+	suite.Assertions.False(templates[0].Output.Unique, "Set uniq should override the base template.")
+	suite.Assertions.Equal(100, templates[0].When.MinStars, "Missing fields should be inherited.")
+	suite.Assertions.Equal("ls", templates[0].Script.Code, "Script should be inherited.")
+}
+
+// TestLibraryProblems checks that the problems of a library are reported once inside the library
+func (suite *TemplateCompositionModuleTestSuite) TestLibraryProblems() {
+	library := suite.writeFile("shared.lib.yaml", "regex:\n  - description: \"Key\"\n    expression: \"key=(\\\\w+\"\n"+
+		"    file_endings: [\".env\"]\n")
+	suite.writeFile("a.yaml", "name: \"A\"\ninclude: [\"shared.lib.yaml\"]\n")
+	suite.writeFile("b.yaml", "name: \"B\"\ninclude: [\"shared.lib.yaml\"]\n")
+
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Empty(templates, "Templates with invalid library should not be loaded.")
+	suite.Assertions.Equal([]TemplateError{{File: library, Line: 3,
+		Message: "regex 1 (Key): invalid expression: error parsing regexp: missing closing ): `key=(\\w+`"}},
+		problems, "Problem should be reported once inside the library.")
+}
+
+// TestBundledCompositions checks the composition of the bundled templates
+func (suite *TemplateCompositionModuleTestSuite) TestBundledCompositions() {
+	templates, problems := ValidateTemplates("../../templates")
+	suite.Assertions.Empty(problems, "Bundled templates should be valid.")
+	byName := make(map[string]Analyzer.Template)
+	for _, template := range templates {
+		byName[template.Name] = template
+	}
+	suite.Assertions.NotContains(byName, "Dependency-Audit", "Abstract template should not be loaded.")
+	suite.Assertions.Len(byName["Leaking-Credentials"].Regex, 11, "Regex libraries should be included.")
+	suite.Assertions.Equal("Most vulnerabilities can be fixed by running: npm audit fix.",
+		byName["NPM-Audit"].Meta.Mitigation, "Variables should be substituted.")
+	suite.Assertions.Equal("Flat", byName["Python-Audit"].Type, "Type should be inherited.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestTemplateCompositionModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(TemplateCompositionModuleTestSuite))
+}
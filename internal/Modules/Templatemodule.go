@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"gopkg.in/yaml.v3"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -46,56 +46,41 @@ func NewTemplateHandlerWithMocks(repoHelper IRepoHelper, fileHelper IFileHelper,
 	return templateHandler
 }
 
-// LoadTemplates loads all templates which can be found in the given directory
+// LoadTemplates loads all templates which can be found in the given directory.
+// All problems of the templates are reported with file and line. Depending on Config.InvalidTemplates,
+// the run is stopped (default) or only the valid templates are loaded.
 func (th *TemplateHandler) LoadTemplates(path string) {
-	// Walk over all files in the directory
-	filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			log.Fatalln("Error loading templates:", err)
-		}
-
-		// Return if not a yaml file
-		if !strings.Contains(path, ".yaml") {
-			return nil
-		}
-
-		// Get filename
-		filePath, _ := filepath.Abs(path)
-		fileName := filepath.Base(filePath)
-		//Don't load the base template
-		if fileName == "template.yaml" {
-			return nil
-		}
-		yamlFile, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Fatalln("Error reading template yaml:", err)
-		}
+	templates, problems := ValidateTemplates(path)
+	for _, problem := range problems {
+		log.Println("Invalid template:", problem.Error())
+	}
+	if len(problems) > 0 && th.Config.InvalidTemplates != InvalidTemplatesSkip {
+		log.Fatalln("Found", len(problems), "problems in the templates, fix them or skip the invalid templates with "+
+			"--invalid-templates skip")
+	}
 
-		// Load template from file
-		var template Analyzer.Template
-		err = yaml.Unmarshal(yamlFile, &template)
-		if err != nil {
-			log.Fatalln("Error while unmarshalling yaml:", err.Error())
-		}
+	for _, template := range templates {
 		th.templates = append(th.templates, template)
-
 		if th.Config.Verbose {
-			fmt.Printf("Loaded Template: %s\n", info.Name())
+			fmt.Printf("Loaded Template: %s\n", template.Name)
 		}
-		return nil
-	})
+	}
 }
 
-// FilterTemplates uses the provided keywords, language and excluded names, to filter the loaded templates
-// and return a subset of them.
+// FilterTemplates uses the provided filter expression, language and excluded names, to filter the loaded templates
+// and return a subset of them. Every template is returned once, even if several terms of the filter match it.
+// Excluded names may contain glob patterns.
 func (th *TemplateHandler) FilterTemplates(keywords, language, excludedTemplateNames string) (filteredTemplates []Analyzer.Template) {
-	// If no keyword is provided, append misc templates and language specific templates
-	if keywords == "" {
-		keywords = "misc," + language
+	// If no filter is provided, select misc templates and language specific templates
+	matcher := defaultTemplateFilter(language)
+	if strings.TrimSpace(keywords) != "" {
+		var err error
+		matcher, err = parseTemplateFilter(keywords)
+		if err != nil {
+			log.Fatalln("Invalid template filter:", err)
+		}
 	}
 
-	// Split keywords by , into words
-	words := strings.Split(keywords, ",")
 	// Split excluded names by ,
 	excludedNames := strings.Split(excludedTemplateNames, ",")
 
@@ -110,7 +95,7 @@ func (th *TemplateHandler) FilterTemplates(keywords, language, excludedTemplateN
 		for _, excludedName := range excludedNames {
 			excludedName = strings.TrimSpace(excludedName)
 			excludedName = strings.ToLower(excludedName)
-			if name == excludedName {
+			if excludedName != "" && (name == excludedName || matchesFold(excludedName, name)) {
 				// If names equal, template is excluded
 				excludedFound = true
 			}
@@ -119,31 +104,26 @@ func (th *TemplateHandler) FilterTemplates(keywords, language, excludedTemplateN
 			// If the template is excluded continue with the next template
 			continue
 		}
-		// Iterate over tags of the template
-		for _, tag := range template.Tags {
-			// Format the current tag
-			tag = strings.TrimSpace(tag)
-			tag = strings.ToLower(tag)
-			// Iterate over loaded keywords
-			for _, word := range words {
-				// Format the current keywords
-				word = strings.TrimSpace(word)
-				word = strings.ToLower(word)
-				if word == tag {
-					// If keyword and tag match, add the template to the filtered templates
-					filteredTemplates = append(filteredTemplates, template)
-				}
-			}
+		// Add the template once, if the filter selects it
+		if matcher(template) {
+			filteredTemplates = append(filteredTemplates, template)
 		}
 	}
 	return filteredTemplates
 }
 
-// executeTemplate executes the given template on the given repository and provided commit hash
+// executeTemplate executes the given template on the given repository and the commit of the provided context
+// The regexResults are the results of the regular expressions of the template, which are searched for all templates
+// of the commit at once.
 // A slice of Analyzer.Results is returned, containing all results from the given template
-func (th *TemplateHandler) executeTemplate(template Analyzer.Template, repo *git.Repository, commitHash string) []Analyzer.Result {
+func (th *TemplateHandler) executeTemplate(template Analyzer.Template, repo *git.Repository,
+	context Analyzer.ScriptContext, regexResults []Analyzer.Result) []Analyzer.Result {
 	// Initialize result slice
 	var results []Analyzer.Result
+	// Hash of the checked out commit
+	commitHash := context.Hash
+	// The scripts get the name of their template
+	context.Template = template.Name
 
 	// Get the path of the repository
 	repoPath := th.RepoHelper.GetPathOfRepository(repo)
@@ -152,11 +132,10 @@ func (th *TemplateHandler) executeTemplate(template Analyzer.Template, repo *git
 		fmt.Println("Executing template:", template.Name, " for repository:", repoPath)
 	}
 
-	// Search trough files using regular expressions from the template
-	regexResults := th.FileHelper.SearchFilesByRegex(repoPath, template)
 	// Append results of the regex search
 	for _, result := range regexResults {
 		result = th.processResult(result, repo, commitHash)
+		result.TemplateVersion = template.Version
 		results = append(results, result)
 	}
 
@@ -166,14 +145,14 @@ func (th *TemplateHandler) executeTemplate(template Analyzer.Template, repo *git
 		return nil
 	}
 	// Generate map of command => path
-	commandToPathMap := th.prepareCommands(template, commitHash, dirToFilesMap)
+	commandToPathMap := th.prepareCommands(template, context, dirToFilesMap)
 
 	// For each path slice of Commands
 	for path := range commandToPathMap {
 		// Iterate over all commands for a path
 		for _, cmd := range commandToPathMap[path] {
 			// Run the command
-			output := th.CommandHelper.RunCommand(cmd, path, template.Script.Language)
+			output := th.CommandHelper.RunCommand(cmd.code, path, template.Script.Language, cmd.context)
 			if output == "" {
 				// If there is no output continue with the next command
 				continue
@@ -222,45 +201,93 @@ func (th *TemplateHandler) CheckRequirements() {
 	}
 }
 
-// prepareCommands prepares the commands of a given template by replacing the template variables
-func (th *TemplateHandler) prepareCommands(template Analyzer.Template, commitHash string, pathAndFiles map[string][]string) map[string][]string {
-	var result = make(map[string][]string)
+// The preparedCommand struct contains a command of a template and the context its placeholders were replaced with
+type preparedCommand struct {
+	// The code of the script with the replaced placeholders
+	code string
+	// The context passed to the script
+	context Analyzer.ScriptContext
+}
+
+// prepareCommands prepares the commands of a given template by replacing the placeholders with the values of the
+// context. Scripts using the {{File}} placeholder are prepared once per file of a path.
+func (th *TemplateHandler) prepareCommands(template Analyzer.Template, context Analyzer.ScriptContext,
+	pathAndFiles map[string][]string) map[string][]preparedCommand {
+	var result = make(map[string][]preparedCommand)
 
 	// Iterate over all paths
 	for path := range pathAndFiles {
 		// Get all files for the current path
 		files := pathAndFiles[path]
-		// Get the command from the template
-		cmd := template.Script.Code
-		// Replace HASH placeholder with current commit hash
-		cmd = strings.Replace(cmd, "{{Hash}}", commitHash, -1)
-		if files != nil && strings.Contains(cmd, "{{File}}") {
-			cmdCopy := cmd
-			// Replace {{file}} placeholder with path to file
+		// The script is run inside the path and gets all matched files of it
+		pathContext := context
+		pathContext.Dir = path
+		for _, file := range files {
+			pathContext.Files = append(pathContext.Files, "./"+file)
+		}
+		// Prepare one command per file if the script uses the file, otherwise one command per path
+		var contexts []Analyzer.ScriptContext
+		if files != nil && usesFilePlaceholder(template.Script.Code) {
 			for _, file := range files {
-				cmd = strings.Replace(cmdCopy, "{{File}}", "./"+file, -1)
-				result[path] = append(result[path], cmd)
+				fileContext := pathContext
+				fileContext.File = "./" + file
+				contexts = append(contexts, fileContext)
 			}
 		} else {
-			result[path] = append(result[path], cmd)
+			contexts = append(contexts, pathContext)
+		}
+		for _, commandContext := range contexts {
+			// Replace the placeholders with the quoted values of the context
+			cmd, err := renderScript(template.Script.Code, template.Script.Language, commandContext)
+			if err != nil {
+				log.Println("Error preparing script of template", template.Name+":", err)
+				continue
+			}
+			result[path] = append(result[path], preparedCommand{code: cmd, context: commandContext})
 		}
 	}
 
 	return result
 }
 
+// resultsDir returns the absolute path of the results directory, so scripts can write into it from any directory
+func (th *TemplateHandler) resultsDir() string {
+	resultsDir, err := filepath.Abs(th.Config.ResultsDir)
+	if err != nil {
+		return th.Config.ResultsDir
+	}
+	return resultsDir
+}
+
 // RunAllTemplates runs all steps necessary to execute the selected template by the given Analyzer.Task for
 // the given repo. The cTasks is used to send updates of the progress.
 func (th *TemplateHandler) RunAllTemplates(task Analyzer.Task, repo *git.Repository, cTasks chan<- Analyzer.Task) {
 	// Get the timestamp of the start
 	start := time.Now()
+	// Detect the languages of the repository, if the task source doesn't provide them.
+	// The walk of the checkout is reused by the templates of the HEAD commit.
+	var headFiles []Analyzer.CheckoutFile
+	if task.Language == "" {
+		repoPath := th.RepoHelper.GetPathOfRepository(repo)
+		headFiles = walkCheckout(repoPath)
+		task.Language, task.SecondaryLanguages = detectLanguages(repoPath, headFiles)
+		if th.Config.Verbose && task.Language != "" {
+			fmt.Println("Detected languages:", languageKeywords(task), " for repository:", task.URL)
+		}
+	}
 	// Filter the templates
-	filteredTemplates := th.FilterTemplates(th.Config.Tags, task.Language, th.Config.Excluded)
+	filteredTemplates := th.FilterTemplates(th.Config.Tags, languageKeywords(task), th.Config.Excluded)
+	// Drop the templates whose conditions don't match the repository
+	filteredTemplates = th.filterTemplatesForRepository(filteredTemplates, task)
 	// Update state to running
 	task.State = "running"
 	cTasks <- task
 	// Run the filtered templates for the repository
-	results := th.runTemplatesForRepository(filteredTemplates, repo)
+	results := th.runTemplatesForRepository(filteredTemplates, repo, task, headFiles)
+	// Carry the metadata of the task through to the results
+	for i := range results {
+		results[i].Metadata = task.Metadata
+	}
 	// Get the finished timestamp
 	elapsedTime := time.Since(start)
 	// Round time to milliseconds
@@ -310,8 +337,18 @@ func (th *TemplateHandler) preScript() {
 		}
 		// Load language of command
 		language := template.PreScript.Language
+		// Replace the placeholders of the command
+		context := Analyzer.ScriptContext{Template: template.Name, Dir: preScriptPath, ResultsDir: th.resultsDir()}
+		if dir, errDir := filepath.Abs(preScriptPath); errDir == nil {
+			context.Dir = dir
+		}
+		cmd, err = renderScript(cmd, language, context)
+		if err != nil {
+			log.Println("Error preparing pre_script of template", template.Name+":", err)
+			continue
+		}
 		// Execute command
-		th.CommandHelper.RunCommand(cmd, preScriptPath, language)
+		th.CommandHelper.RunCommand(cmd, preScriptPath, language, context)
 	}
 }
 
@@ -345,12 +382,15 @@ func (th *TemplateHandler) processOutput(path string, template Analyzer.Template
 	}
 
 	// Return the final result
-	return Analyzer.Result{TemplateName: template.Name, URL: url, CommitHash: commitHash,
-		Timestamp: timeStamp, Path: path, Description: "", Output: output}
+	return Analyzer.Result{TemplateName: template.Name, TemplateVersion: template.Version, URL: url,
+		CommitHash: commitHash, Timestamp: timeStamp, Path: path, Description: "", Output: output}
 }
 
-// runTemplatesForRepository executes the given templates on the given repository
-func (th *TemplateHandler) runTemplatesForRepository(templates []Analyzer.Template, repo *git.Repository) []Analyzer.Result {
+// runTemplatesForRepository executes the given templates on the given repository of the task.
+// The files of the walk of the HEAD commit are reused, if it is the first checked out commit, as no script can
+// have modified the working tree before.
+func (th *TemplateHandler) runTemplatesForRepository(templates []Analyzer.Template, repo *git.Repository,
+	task Analyzer.Task, headFiles []Analyzer.CheckoutFile) []Analyzer.Result {
 	if templates == nil {
 		return nil
 	}
@@ -360,12 +400,14 @@ func (th *TemplateHandler) runTemplatesForRepository(templates []Analyzer.Templa
 	if err != nil {
 		return nil
 	}
+	// The context of the repository passed to the scripts
+	context := th.repositoryContext(repo, task)
 
 	var results []Analyzer.Result
 	// Get the templateTasks
 	templateTask := th.RepoHelper.GetTemplateTasks(repo, templates)
 	// Iterate over all templateTasks
-	for _, tTask := range templateTask {
+	for i, tTask := range templateTask {
 		// Checkout the current commit
 		errCheckout := th.RepoHelper.Checkout(repo, &git.CheckoutOptions{
 			Hash:  plumbing.NewHash(tTask.CommitHash),
@@ -374,14 +416,184 @@ func (th *TemplateHandler) runTemplatesForRepository(templates []Analyzer.Templa
 		if errCheckout != nil {
 			continue
 		}
-		// Execute template
-		for _, template := range tTask.Templates {
-			templateResults := th.executeTemplate(template, repo, tTask.CommitHash)
-			results = append(results, templateResults...)
+		// Execute templates
+		tTask.Context = commitContext(context, repo, tTask.CommitHash)
+		var files []Analyzer.CheckoutFile
+		if i == 0 && tTask.CommitHash == headCommit.Hash.String() {
+			files = headFiles
 		}
+		results = append(results, th.runTemplatesForCommit(tTask, repo, files)...)
 	}
 	// Reset the repository to the newest commit
 	defer th.RepoHelper.ResetRepository(repo, headCommit)
 
 	return results
 }
+
+// repositoryContext returns the context of the repository of the task, which is passed to the scripts.
+// The branch is the one checked out after cloning, as the commits are checked out detached.
+func (th *TemplateHandler) repositoryContext(repo *git.Repository, task Analyzer.Task) Analyzer.ScriptContext {
+	context := Analyzer.ScriptContext{RepoURL: task.URL, RepoPath: th.RepoHelper.GetPathOfRepository(repo),
+		Language: task.Language, ResultsDir: th.resultsDir()}
+	if context.RepoURL == "" {
+		context.RepoURL = th.RepoHelper.GetGitHubURLOfRepository(repo)
+	}
+	if head, err := repo.Head(); err == nil && head.Name().IsBranch() {
+		context.Branch = head.Name().Short()
+	}
+	return context
+}
+
+// commitContext returns the context of the repository completed with the hash, date and author of the commit
+func commitContext(context Analyzer.ScriptContext, repo *git.Repository, commitHash string) Analyzer.ScriptContext {
+	context.Hash = commitHash
+	commit, err := repo.CommitObject(plumbing.NewHash(commitHash))
+	if err != nil {
+		return context
+	}
+	context.CommitDate = commit.Committer.When.Format(time.RFC3339)
+	context.Author = fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email)
+	return context
+}
+
+// languageKeywords returns the primary and secondary languages of the task, comma separated like the tags
+func languageKeywords(task Analyzer.Task) string {
+	if task.Language == "" {
+		return ""
+	}
+	return strings.Join(append([]string{task.Language}, task.SecondaryLanguages...), ",")
+}
+
+// filterTemplatesForRepository returns the templates whose when conditions match the repository of the task
+func (th *TemplateHandler) filterTemplatesForRepository(templates []Analyzer.Template,
+	task Analyzer.Task) []Analyzer.Template {
+	var matching []Analyzer.Template
+	for _, template := range templates {
+		template, matches := matchesRepository(template, task)
+		if !matches {
+			if th.Config.Verbose {
+				fmt.Println("Skipping template:", template.Name, " for repository:", task.URL)
+			}
+			continue
+		}
+		matching = append(matching, template)
+	}
+	return matching
+}
+
+// filterTemplatesForCommit returns the templates whose when conditions match the files of the checked out commit.
+// The facts are only collected if one of the templates has a condition depending on them.
+func (th *TemplateHandler) filterTemplatesForCommit(templates []Analyzer.Template, repoPath string,
+	files []Analyzer.CheckoutFile) []Analyzer.Template {
+	var facts *commitFacts
+	var matching []Analyzer.Template
+	for _, template := range templates {
+		if needsCommitFacts(template.When) {
+			if facts == nil {
+				collected := collectCommitFacts(repoPath, files)
+				facts = &collected
+			}
+			if !matchesCommit(template.When, *facts) {
+				if th.Config.Verbose {
+					fmt.Println("Skipping template:", template.Name, " for commit of repository:", repoPath)
+				}
+				continue
+			}
+		}
+		matching = append(matching, template)
+	}
+	return matching
+}
+
+// needsExclusiveCheckout checks if the given template must run alone on the checked out commit.
+// Templates with a script may modify the working tree, while templates with only
+// regular expressions just read files and can share the checkout with other templates.
+func needsExclusiveCheckout(template Analyzer.Template) bool {
+	return strings.TrimSpace(template.Script.Code) != ""
+}
+
+// needsCheckoutFiles returns true if the conditions or the regular expressions of one of the templates need the
+// files of the checkout
+func needsCheckoutFiles(templates []Analyzer.Template) bool {
+	for _, template := range templates {
+		if needsCommitFacts(template.When) || len(template.Regex) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// runTemplatesForCommit executes the templates of the given templateTask on the already checked out commit.
+// Templates which don't need an exclusive checkout are run in parallel, all other templates are run one
+// after another. The results are returned in the order of the templates.
+func (th *TemplateHandler) runTemplatesForCommit(tTask Analyzer.TemplateTask, repo *git.Repository,
+	files []Analyzer.CheckoutFile) []Analyzer.Result {
+	// Walk the checkout once before any script can modify the working tree, if the conditions or the regular
+	// expressions of a template need the files and no walk of the commit is given
+	repoPath := th.RepoHelper.GetPathOfRepository(repo)
+	if files == nil && needsCheckoutFiles(tTask.Templates) {
+		files = walkCheckout(repoPath)
+	}
+	// Drop the templates whose conditions don't match the files of the commit
+	tTask.Templates = th.filterTemplatesForCommit(tTask.Templates, repoPath, files)
+	// The context of the commit passed to the scripts
+	context := tTask.Context
+	context.Hash = tTask.CommitHash
+	// Results per template, so the order stays the same as the order of the templates
+	resultsPerTemplate := make([][]Analyzer.Result, len(tTask.Templates))
+
+	// Search the files with the regular expressions of all templates
+	regexResults := th.FileHelper.SearchFilesByTemplates(repoPath, files, tTask.Templates)
+
+	// Start all templates which can share the checkout
+	var wg sync.WaitGroup
+	for i, template := range tTask.Templates {
+		if needsExclusiveCheckout(template) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, template Analyzer.Template) {
+			defer wg.Done()
+			resultsPerTemplate[i] = th.executeTemplate(template, repo, context, regexResults[i])
+		}(i, template)
+	}
+	// Wait for the parallel templates before running the exclusive ones
+	wg.Wait()
+
+	// Run all templates which need an exclusive checkout one after another
+	for i, template := range tTask.Templates {
+		if !needsExclusiveCheckout(template) {
+			continue
+		}
+		resultsPerTemplate[i] = th.executeTemplate(template, repo, context, regexResults[i])
+	}
+
+	// Merge the results, the paths are stored relative to the repository e.g. src/a.go instead of
+	// repos/<owner>/<name>/src/a.go, so they can be linked on the forge
+	var results []Analyzer.Result
+	for _, templateResults := range resultsPerTemplate {
+		for _, result := range templateResults {
+			result.Path = repositoryRelativePath(repoPath, result.Path)
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// repositoryRelativePath returns the path relative to the root directory of the repository with forward slashes.
+// Paths outside the repository are returned unchanged.
+func repositoryRelativePath(repoPath, path string) string {
+	if repoPath == "" || path == "" {
+		return path
+	}
+	absRepoPath, errRepo := filepath.Abs(repoPath)
+	absPath, errPath := filepath.Abs(path)
+	if errRepo != nil || errPath != nil {
+		return path
+	}
+	relative, err := filepath.Rel(absRepoPath, absPath)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(relative)
+}
@@ -10,6 +10,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
@@ -91,8 +92,14 @@ func (suite *TemplateModuleTestSuite) TestExecuteTemplate() {
 
 	var firstCommit = suite.commits[0]
 
-	// Set expected values
-	expectedCmd := "git test " + "./" + testFile + " " + firstCommit.Hash.String()
+	// Set expected values, the placeholders are replaced with quoted values
+	context := Analyzer.ScriptContext{Hash: firstCommit.Hash.String(), RepoURL: "https://github.com/gitanalyzer/test"}
+	expectedContext := context
+	expectedContext.Dir = suite.tempDir
+	expectedContext.File = "./" + testFile
+	expectedContext.Files = []string{"./" + testFile}
+	expectedContext.Template = template.Name
+	expectedCmd := "git test " + "'./" + testFile + "' '" + firstCommit.Hash.String() + "'"
 	expectedURL := "https://github.com/gitanalyzer/test"
 	expectedOutput := "Mock Result"
 	expectedTimeStamp := time.Now().Format("01-02-2006")
@@ -109,16 +116,15 @@ func (suite *TemplateModuleTestSuite) TestExecuteTemplate() {
 
 	// Set expected return values for mocks
 	suite.mockRepoHandler.On("GetPathOfRepository", suite.repo).Return(suite.tempDir)
-	suite.mockFileHelper.On("SearchFilesByRegex", suite.tempDir, template).Return(nil)
 	suite.mockFileHelper.On("FindFilesForCommands", suite.tempDir, template).Return(pathsMap)
-	suite.mockCommandHelper.On("RunCommand", expectedCmd, suite.tempDir, "").Return(expectedOutput)
+	suite.mockCommandHelper.On("RunCommand", expectedCmd, suite.tempDir, "", expectedContext).Return(expectedOutput)
 	suite.mockRepoHandler.On("GetGitHubURLOfRepository", suite.repo).Return(expectedURL)
 
 	// Call executeTemplate
-	gotResult := suite.templateHandler.executeTemplate(template, suite.repo, firstCommit.Hash.String())
+	gotResult := suite.templateHandler.executeTemplate(template, suite.repo, context, nil)
 
 	// Check that runCommand has been called
-	suite.mockCommandHelper.AssertCalled(suite.T(), "RunCommand", expectedCmd, suite.tempDir, "")
+	suite.mockCommandHelper.AssertCalled(suite.T(), "RunCommand", expectedCmd, suite.tempDir, "", expectedContext)
 
 	// Check that the expected and actual results are the same
 	suite.Assertions.Equal(expectedResult, gotResult, "Results should equal.")
@@ -219,21 +225,36 @@ func (suite *TemplateModuleTestSuite) TestPrepareCommands() {
 	}
 
 	// Create and set expected values
+	context := Analyzer.ScriptContext{Hash: firstCommit.Hash.String()}
+	hash := "'" + firstCommit.Hash.String() + "'"
 	dirs := []string{"dir1", "dir2"}
 	files := []string{"file1", "file2"}
 	pathAndFiles := make(map[string][]string)
 	pathAndFiles[dirs[0]] = files
 	pathAndFiles[dirs[1]] = []string{files[0]}
-	expectedCommandMap := make(map[string][]string)
-	expectedCommandMap[dirs[0]] = []string{"git test ./file1 " + firstCommit.Hash.String(),
-		"git test ./file2 " + firstCommit.Hash.String()}
-	expectedCommandMap[dirs[1]] = []string{"git test ./file1 " + firstCommit.Hash.String()}
+	dir1Files := []string{"./file1", "./file2"}
+	expectedCommandMap := make(map[string][]preparedCommand)
+	expectedCommandMap[dirs[0]] = []preparedCommand{
+		{code: "git test './file1' " + hash, context: Analyzer.ScriptContext{Hash: context.Hash, Dir: "dir1",
+			File: "./file1", Files: dir1Files}},
+		{code: "git test './file2' " + hash, context: Analyzer.ScriptContext{Hash: context.Hash, Dir: "dir1",
+			File: "./file2", Files: dir1Files}}}
+	expectedCommandMap[dirs[1]] = []preparedCommand{
+		{code: "git test './file1' " + hash, context: Analyzer.ScriptContext{Hash: context.Hash, Dir: "dir2",
+			File: "./file1", Files: []string{"./file1"}}}}
 
 	// Call prepareCommands
-	gotCommandMap := suite.templateHandler.prepareCommands(template, firstCommit.Hash.String(), pathAndFiles)
+	gotCommandMap := suite.templateHandler.prepareCommands(template, context, pathAndFiles)
 
 	// Check if expected value and actual value match
 	suite.Assertions.Equal(expectedCommandMap, gotCommandMap, "CommandMaps should equal.")
+
+	// Scripts without the file placeholder are prepared once per path
+	template.Script = Analyzer.Script{Code: "echo {{Dir}}", Language: "python"}
+	gotCommandMap = suite.templateHandler.prepareCommands(template, context, map[string][]string{"dir1": files})
+	suite.Assertions.Equal(map[string][]preparedCommand{"dir1": {{code: `echo "dir1"`,
+		context: Analyzer.ScriptContext{Hash: context.Hash, Dir: "dir1", Files: dir1Files}}}}, gotCommandMap,
+		"CommandMap without files should equal.")
 }
 
 // TestCheckRequirements check if all requirements are tested correctly
@@ -315,6 +336,8 @@ func (suite *TemplateModuleTestSuite) TestLoadTemplates() {
 		Script: Analyzer.Script{
 			Code: "git test {{File}} {{Hash}}",
 		},
+		Regex: []Analyzer.Regex{{Expression: "key=(.*)", Group: 1, FileEndings: []string{".env"}},
+			{Type: RegexTypeEntropy, FileEndings: []string{".env"}}},
 	}
 
 	// Serialize test template into file
@@ -335,6 +358,66 @@ func (suite *TemplateModuleTestSuite) TestLoadTemplates() {
 	// Check that the loaded and created test template are equal
 	suite.Assertions.Equal(template.Name, gotTemplate.Name, "Loaded template names should equal")
 	suite.Assertions.Equal(template.Script.Code, gotTemplate.Script.Code, "Loaded template code should equal")
+	// Check that the expressions are compiled once while loading, entropy rules get their built-in expression
+	suite.Assertions.Equal("key=(.*)", gotTemplate.Regex[0].Compiled.String(), "Compiled expression should equal.")
+	suite.Assertions.Equal(entropyExpression, gotTemplate.Regex[1].Compiled.String(), "Compiled expression should equal.")
+}
+
+// TestRunTemplatesForCommit checks that parallel and exclusive templates are executed
+// and their results are returned in the order of the templates
+func (suite *TemplateModuleTestSuite) TestRunTemplatesForCommit() {
+	var firstCommit = suite.commits[0]
+	// Create regex templates which can share the checkout and a script template which needs it exclusively
+	var templates []Analyzer.Template
+	for i := 1; i < 4; i++ {
+		template := Analyzer.Template{
+			Name:    "TestTemplate" + strconv.Itoa(i),
+			Version: "pack@3f2a1b9c0d4e/" + strconv.Itoa(i),
+		}
+		if i == 2 {
+			template.Script = Analyzer.Script{Code: "git test"}
+		}
+		templates = append(templates, template)
+	}
+	tTask := Analyzer.TemplateTask{CommitHash: firstCommit.Hash.String(), Templates: templates}
+
+	// Set expected values
+	expectedURL := "https://github.com/gitanalyzer/test"
+	expectedTimeStamp := time.Now().Format("01-02-2006")
+	var expectedResults []Analyzer.Result
+	var regexResults [][]Analyzer.Result
+	for _, template := range templates {
+		regexResult := Analyzer.Result{TemplateName: template.Name, Output: "Output " + template.Name,
+			Path: filepath.Join(suite.tempDir, "src", "a.txt")}
+		regexResults = append(regexResults, []Analyzer.Result{regexResult})
+		// The paths are stored relative to the repository
+		regexResult.Path = "src/a.txt"
+		suite.mockFileHelper.On("FindFilesForCommands", suite.tempDir, template).Return(map[string][]string{})
+
+		regexResult.URL = expectedURL
+		regexResult.CommitHash = firstCommit.Hash.String()
+		regexResult.Timestamp = expectedTimeStamp
+		regexResult.TemplateVersion = template.Version
+		expectedResults = append(expectedResults, regexResult)
+	}
+
+	// Set expected return values for mocks, the given walk of the commit is reused
+	files := walkCheckout(suite.tempDir)
+	suite.mockFileHelper.On("SearchFilesByTemplates", suite.tempDir, files, templates).Return(regexResults)
+	suite.mockRepoHandler.On("GetPathOfRepository", suite.repo).Return(suite.tempDir)
+	suite.mockRepoHandler.On("GetGitHubURLOfRepository", suite.repo).Return(expectedURL)
+
+	// Call runTemplatesForCommit
+	gotResults := suite.templateHandler.runTemplatesForCommit(tTask, suite.repo, files)
+
+	// Check that the files were searched once for all templates
+	suite.mockFileHelper.AssertNumberOfCalls(suite.T(), "SearchFilesByTemplates", 1)
+	suite.mockFileHelper.AssertNumberOfCalls(suite.T(), "FindFilesForCommands", 3)
+	// Check that the results are ordered like the templates
+	suite.Assertions.Equal(expectedResults, gotResults, "Results should equal.")
+	// Check that only the script template needs an exclusive checkout
+	suite.Assertions.False(needsExclusiveCheckout(templates[0]), "Regex template should not need an exclusive checkout.")
+	suite.Assertions.True(needsExclusiveCheckout(templates[1]), "Script template should need an exclusive checkout.")
 }
 
 // This functions runs the test suite add a 'go test' command
@@ -0,0 +1,359 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TemplateLockFile is the name of the lockfile inside the templates directory
+const TemplateLockFile = "templates.lock"
+
+// TemplatePacksDir is the directory inside the templates directory the template packs are installed into
+const TemplatePacksDir = "packs"
+
+// shortHash matches abbreviated commit hashes
+var shortHash = regexp.MustCompile(`^[0-9a-fA-F]{4,39}$`)
+
+// packRefSpecs are the references fetched when a template pack is updated
+var packRefSpecs = []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}
+
+// The TemplatePackManager struct installs and updates the template packs of a templates directory
+type TemplatePackManager struct {
+	// The gitHelper used to clone and open the repositories of the packs
+	gitHelper IGitHelper
+	// Path of the templates directory
+	templatesPath string
+}
+
+// NewTemplatePackManager is the constructor to create a new TemplatePackManager for the given templates directory
+func NewTemplatePackManager(helper IGitHelper, templatesPath string) *TemplatePackManager {
+	return &TemplatePackManager{gitHelper: helper, templatesPath: templatesPath}
+}
+
+// LoadTemplateLock loads the lockfile of the templates directory, an empty lock is returned if there is none
+func LoadTemplateLock(templatesPath string) (Analyzer.TemplateLock, error) {
+	var lock Analyzer.TemplateLock
+	content, err := os.ReadFile(filepath.Join(templatesPath, TemplateLockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+	err = yaml.Unmarshal(content, &lock)
+	return lock, err
+}
+
+// saveTemplateLock writes the lockfile of the templates directory
+func saveTemplateLock(templatesPath string, lock Analyzer.TemplateLock) error {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(templatesPath, TemplateLockFile), content, 0644)
+}
+
+// packForPath returns the installed template pack containing the file of the templates directory
+func packForPath(templatesPath, filePath string, lock Analyzer.TemplateLock) (Analyzer.TemplatePack, bool) {
+	relativePath, err := filepath.Rel(templatesPath, filePath)
+	if err != nil {
+		return Analyzer.TemplatePack{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(relativePath), "/")
+	if len(parts) < 3 || parts[0] != TemplatePacksDir {
+		return Analyzer.TemplatePack{}, false
+	}
+	for _, pack := range lock.Packs {
+		if pack.Name == parts[1] {
+			return pack, true
+		}
+	}
+	return Analyzer.TemplatePack{}, false
+}
+
+// packTemplateDirs returns the map pack directory => templates directory of the installed packs declaring the
+// directory of their templates
+func packTemplateDirs(templatesPath string, lock Analyzer.TemplateLock) map[string]string {
+	dirs := make(map[string]string)
+	for _, pack := range lock.Packs {
+		if pack.Path != "" {
+			packDir := filepath.Join(templatesPath, TemplatePacksDir, pack.Name)
+			dirs[packDir] = filepath.Join(packDir, filepath.FromSlash(pack.Path))
+		}
+	}
+	return dirs
+}
+
+// outsidePackTemplates returns true if the path is inside a template pack, but not inside the templates directory
+// declared by the pack. Directories containing the templates directory are not outside of it.
+func outsidePackTemplates(path string, isDir bool, templateDirs map[string]string) bool {
+	for packDir, templatesDir := range templateDirs {
+		if !isWithinDir(path, packDir) {
+			continue
+		}
+		return !isWithinDir(path, templatesDir) && !(isDir && isWithinDir(templatesDir, path))
+	}
+	return false
+}
+
+// isWithinDir returns true if the path is the directory or inside of it
+func isWithinDir(path, dir string) bool {
+	relativePath, err := filepath.Rel(dir, path)
+	return err == nil && relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// templateVersion returns the version of the template stored with its results
+func templateVersion(hash string, pack Analyzer.TemplatePack, installed bool) string {
+	if !installed {
+		return hash
+	}
+	commit := pack.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return fmt.Sprintf("%s@%s/%s", pack.Name, commit, hash)
+}
+
+// List returns the installed template packs
+func (tpm *TemplatePackManager) List() ([]Analyzer.TemplatePack, error) {
+	lock, err := LoadTemplateLock(tpm.templatesPath)
+	return lock.Packs, err
+}
+
+// PackDir returns the directory the template pack is installed into
+func (tpm *TemplatePackManager) PackDir(name string) string {
+	return filepath.Join(tpm.templatesPath, TemplatePacksDir, name)
+}
+
+// Install clones the git repository of the template pack and checks out the given branch, tag or commit.
+// The name defaults to the name of the repository, the ref to its default branch. Only the templates inside the
+// directory templatesDir of the repository are loaded, all templates of the repository if it is empty.
+func (tpm *TemplatePackManager) Install(url, name, ref, templatesDir string) (Analyzer.TemplatePack, error) {
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(filepath.ToSlash(strings.TrimRight(url, "/"))), ".git")
+	}
+	if name == "" || name == "." || strings.ContainsAny(name, `/\`) {
+		return Analyzer.TemplatePack{}, fmt.Errorf("invalid pack name %q", name)
+	}
+	if templatesDir != "" {
+		templatesDir = path.Clean(filepath.ToSlash(templatesDir))
+		if path.IsAbs(templatesDir) || templatesDir == ".." || strings.HasPrefix(templatesDir, "../") {
+			return Analyzer.TemplatePack{}, fmt.Errorf("invalid templates path %q, it has to be inside the pack",
+				templatesDir)
+		}
+		if templatesDir == "." {
+			templatesDir = ""
+		}
+	}
+	lock, err := LoadTemplateLock(tpm.templatesPath)
+	if err != nil {
+		return Analyzer.TemplatePack{}, err
+	}
+	for _, pack := range lock.Packs {
+		if pack.Name == name {
+			return Analyzer.TemplatePack{}, fmt.Errorf("template pack %s is already installed from %s", name, pack.URL)
+		}
+	}
+
+	// Never overwrite or remove a directory, which wasn't created by the installation
+	if _, err = os.Lstat(tpm.PackDir(name)); err == nil {
+		return Analyzer.TemplatePack{}, fmt.Errorf("directory %s already exists, remove it or choose another name",
+			tpm.PackDir(name))
+	} else if !os.IsNotExist(err) {
+		return Analyzer.TemplatePack{}, err
+	}
+
+	// The pack directory didn't exist, so it is removed if the installation fails
+	pack := Analyzer.TemplatePack{Name: name, URL: url, Ref: ref, Path: templatesDir}
+	repo, err := tpm.gitHelper.Clone(tpm.PackDir(name), url)
+	if err != nil {
+		os.RemoveAll(tpm.PackDir(name))
+		return pack, err
+	}
+	if pack.Ref == "" {
+		// Follow the default branch of the repository
+		head, err := repo.Head()
+		if err != nil {
+			os.RemoveAll(tpm.PackDir(name))
+			return pack, err
+		}
+		pack.Ref = head.Name().Short()
+	}
+	if pack.Commit, err = checkoutRevision(repo, pack.Ref); err != nil {
+		os.RemoveAll(tpm.PackDir(name))
+		return pack, err
+	}
+	templatesPath := filepath.Join(tpm.PackDir(name), filepath.FromSlash(pack.Path))
+	if info, err := os.Stat(templatesPath); err != nil || !info.IsDir() {
+		os.RemoveAll(tpm.PackDir(name))
+		return pack, fmt.Errorf("templates path %s not found in the pack", pack.Path)
+	}
+
+	lock.Packs = append(lock.Packs, pack)
+	return pack, saveTemplateLock(tpm.templatesPath, lock)
+}
+
+// Update fetches the template packs with the given names, all packs if no name is given, and checks out the
+// latest commit of their refs. Pinned packs and packs missing in the templates directory are checked out at the
+// commit of the lockfile, so the lockfile can be used to restore the installed templates.
+func (tpm *TemplatePackManager) Update(names []string) ([]Analyzer.TemplatePack, error) {
+	lock, err := LoadTemplateLock(tpm.templatesPath)
+	if err != nil {
+		return nil, err
+	}
+	var updated []Analyzer.TemplatePack
+	for _, name := range names {
+		if _, err = findPack(lock, name); err != nil {
+			return nil, err
+		}
+	}
+	for i, pack := range lock.Packs {
+		if len(names) > 0 && !Utils.Contains(names, pack.Name) {
+			continue
+		}
+		repo, cloned, err := tpm.openPack(pack)
+		if err != nil {
+			return updated, fmt.Errorf("template pack %s: %w", pack.Name, err)
+		}
+		revision := pack.Ref
+		if pack.Pinned || cloned {
+			revision = pack.Commit
+		} else if err = fetchPack(repo); err != nil {
+			return updated, fmt.Errorf("template pack %s: %w", pack.Name, err)
+		}
+		commit, err := checkoutRevision(repo, revision)
+		if err != nil {
+			return updated, fmt.Errorf("template pack %s: %w", pack.Name, err)
+		}
+		lock.Packs[i].Commit = commit
+		updated = append(updated, lock.Packs[i])
+	}
+	return updated, saveTemplateLock(tpm.templatesPath, lock)
+}
+
+// Pin checks out the template pack at the given branch, tag or commit, the installed commit if the revision is
+// empty, and keeps the pack at this commit on updates. If unpin is set, the pack follows its ref again.
+func (tpm *TemplatePackManager) Pin(name, revision string, unpin bool) (Analyzer.TemplatePack, error) {
+	lock, err := LoadTemplateLock(tpm.templatesPath)
+	if err != nil {
+		return Analyzer.TemplatePack{}, err
+	}
+	i, err := findPack(lock, name)
+	if err != nil {
+		return Analyzer.TemplatePack{}, err
+	}
+	if unpin {
+		lock.Packs[i].Pinned = false
+		return lock.Packs[i], saveTemplateLock(tpm.templatesPath, lock)
+	}
+
+	repo, _, err := tpm.openPack(lock.Packs[i])
+	if err != nil {
+		return lock.Packs[i], err
+	}
+	if revision == "" {
+		revision = lock.Packs[i].Commit
+	}
+	commit, err := checkoutRevision(repo, revision)
+	if err != nil {
+		return lock.Packs[i], err
+	}
+	lock.Packs[i].Commit, lock.Packs[i].Pinned = commit, true
+	return lock.Packs[i], saveTemplateLock(tpm.templatesPath, lock)
+}
+
+// openPack opens the repository of the installed template pack, it is cloned again if it is missing
+func (tpm *TemplatePackManager) openPack(pack Analyzer.TemplatePack) (*git.Repository, bool, error) {
+	repo, err := tpm.gitHelper.Open(tpm.PackDir(pack.Name))
+	if err == git.ErrRepositoryNotExists {
+		repo, err = tpm.gitHelper.Clone(tpm.PackDir(pack.Name), pack.URL)
+		return repo, true, err
+	}
+	return repo, false, err
+}
+
+// findPack returns the index of the template pack with the given name inside the lockfile
+func findPack(lock Analyzer.TemplateLock, name string) (int, error) {
+	for i, pack := range lock.Packs {
+		if pack.Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("template pack %s is not installed", name)
+}
+
+// fetchPack fetches all branches and tags of the repository of the template pack
+func fetchPack(repo *git.Repository) error {
+	err := repo.Fetch(&git.FetchOptions{RefSpecs: packRefSpecs, Force: true})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return err
+}
+
+// checkoutRevision checks out the branch, tag or commit and returns the hash of the checked out commit.
+// Branches are resolved by their remote tracking branch, so updates of the remote are checked out.
+func checkoutRevision(repo *git.Repository, revision string) (string, error) {
+	var hash *plumbing.Hash
+	var err error
+	for _, candidate := range []string{"refs/remotes/origin/" + revision, "refs/tags/" + revision, revision} {
+		if hash, err = repo.ResolveRevision(plumbing.Revision(candidate)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		// Abbreviated commit hashes are not resolved by go-git
+		if hash, err = resolveShortHash(repo, revision); err != nil {
+			return "", fmt.Errorf("unknown revision %s: %w", revision, err)
+		}
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	if err = worktree.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+// resolveShortHash returns the commit whose hash starts with the abbreviated hash, the hash has to be unique
+func resolveShortHash(repo *git.Repository, prefix string) (*plumbing.Hash, error) {
+	if !shortHash.MatchString(prefix) {
+		return nil, plumbing.ErrReferenceNotFound
+	}
+	commits, err := repo.CommitObjects()
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+	var found *plumbing.Hash
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if !strings.HasPrefix(commit.Hash.String(), strings.ToLower(prefix)) {
+			return nil
+		}
+		if found != nil {
+			return fmt.Errorf("ambiguous commit hash %s", prefix)
+		}
+		hash := commit.Hash
+		found = &hash
+		return nil
+	})
+	if err == nil && found == nil {
+		err = plumbing.ErrReferenceNotFound
+	}
+	return found, err
+}
@@ -0,0 +1,201 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Create test suite for the template pack module
+type TemplatePackModuleTestSuite struct {
+	suite.Suite
+	tempDir       string
+	templatesPath string
+	remote        string
+	worktree      *git.Worktree
+	repo          *git.Repository
+	manager       *TemplatePackManager
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *TemplatePackModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+	suite.templatesPath = filepath.Join(suite.tempDir, "templates")
+	suite.Assertions.NoError(os.MkdirAll(suite.templatesPath, 0755), "Creating the templates directory should not fail.")
+	suite.manager = NewTemplatePackManager(&GitHelper{}, suite.templatesPath)
+
+	// Create a bare repository as source of the pack and a repository to push the templates into it
+	suite.remote = filepath.Join(suite.tempDir, "pack.git")
+	_, err := git.PlainInit(suite.remote, true)
+	suite.Assertions.NoError(err, "Creating the bare repository should not fail.")
+	suite.repo, err = git.PlainInit(filepath.Join(suite.tempDir, "work"), false)
+	suite.Assertions.NoError(err, "Creating the repository should not fail.")
+	_, err = suite.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{suite.remote}})
+	suite.Assertions.NoError(err, "Creating the remote should not fail.")
+	suite.worktree, err = suite.repo.Worktree()
+	suite.Assertions.NoError(err, "Getting the worktree should not fail.")
+}
+
+// pushTemplate commits the template with the given description and pushes it into the bare repository
+func (suite *TemplatePackModuleTestSuite) pushTemplate(description string) string {
+	content := "name: \"Pack-Template\"\ndescription: \"" + description + "\"\nscript:\n  code: \"ls\"\n"
+	return suite.pushFiles(description, map[string]string{"pack.yaml": content})
+}
+
+// pushFiles commits the files with the given message and pushes them into the bare repository
+func (suite *TemplatePackModuleTestSuite) pushFiles(message string, files map[string]string) string {
+	for name, content := range files {
+		path := filepath.Join(suite.tempDir, "work", filepath.FromSlash(name))
+		suite.Assertions.NoError(os.MkdirAll(filepath.Dir(path), 0755), "Creating the directory should not fail.")
+		suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Writing the file should not fail.")
+		_, err := suite.worktree.Add(name)
+		suite.Assertions.NoError(err, "Adding the file should not fail.")
+	}
+	commit, err := suite.worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{Name: "John Doe",
+		Email: "john@doe.org", When: time.Now()}})
+	suite.Assertions.NoError(err, "Committing the template should not fail.")
+	suite.Assertions.NoError(suite.repo.Push(&git.PushOptions{}), "Pushing the template should not fail.")
+	return commit.String()
+}
+
+// loadedTemplate returns the template of the pack loaded from the templates directory
+func (suite *TemplatePackModuleTestSuite) loadedTemplate() Analyzer.Template {
+	templates, problems := ValidateTemplates(suite.templatesPath)
+	suite.Assertions.Empty(problems, "Templates should be valid.")
+	suite.Assertions.Len(templates, 1, "Template of the pack should be loaded.")
+	return templates[0]
+}
+
+// TestInstallAndUpdate checks that packs are installed, recorded in the lockfile, versioned and updated
+func (suite *TemplatePackModuleTestSuite) TestInstallAndUpdate() {
+	first := suite.pushTemplate("First")
+	pack, err := suite.manager.Install(suite.remote, "", "", "")
+	suite.Assertions.NoError(err, "Installing the pack should not fail.")
+	suite.Assertions.Equal(Analyzer.TemplatePack{Name: "pack", URL: suite.remote, Ref: "master", Commit: first}, pack,
+		"Installed pack should equal.")
+
+	lock, err := LoadTemplateLock(suite.templatesPath)
+	suite.Assertions.NoError(err, "Loading the lockfile should not fail.")
+	suite.Assertions.Equal([]Analyzer.TemplatePack{pack}, lock.Packs, "Pack should be recorded in the lockfile.")
+	template := suite.loadedTemplate()
+	suite.Assertions.Equal("First", template.Description, "Installed template should be loaded.")
+	suite.Assertions.Equal("pack@"+first[:12]+"/"+template.Hash, template.Version,
+		"Version should contain the commit of the pack.")
+
+	_, err = suite.manager.Install(suite.remote, "pack", "", "")
+	suite.Assertions.Error(err, "Installing the pack twice should fail.")
+
+	// Update checks out the latest commit of the branch
+	second := suite.pushTemplate("Second")
+	packs, err := suite.manager.Update(nil)
+	suite.Assertions.NoError(err, "Updating the packs should not fail.")
+	suite.Assertions.Equal(second, packs[0].Commit, "Pack should be updated.")
+	suite.Assertions.Equal("Second", suite.loadedTemplate().Description, "Updated template should be loaded.")
+
+	_, err = suite.manager.Update([]string{"unknown"})
+	suite.Assertions.EqualError(err, "template pack unknown is not installed", "Unknown pack should be reported.")
+}
+
+// TestPin checks that pinned packs keep their commit on updates
+func (suite *TemplatePackModuleTestSuite) TestPin() {
+	first := suite.pushTemplate("First")
+	_, err := suite.manager.Install(suite.remote, "community", "", "")
+	suite.Assertions.NoError(err, "Installing the pack should not fail.")
+	second := suite.pushTemplate("Second")
+	_, err = suite.manager.Update([]string{"community"})
+	suite.Assertions.NoError(err, "Updating the pack should not fail.")
+
+	// Pin the pack to the first commit
+	pack, err := suite.manager.Pin("community", first[:8], false)
+	suite.Assertions.NoError(err, "Pinning the pack should not fail.")
+	suite.Assertions.True(pack.Pinned, "Pack should be pinned.")
+	suite.Assertions.Equal(first, pack.Commit, "Pinned commit should equal.")
+	suite.pushTemplate("Third")
+	packs, err := suite.manager.Update(nil)
+	suite.Assertions.NoError(err, "Updating the packs should not fail.")
+	suite.Assertions.Equal(first, packs[0].Commit, "Pinned pack should not be updated.")
+	suite.Assertions.Equal("First", suite.loadedTemplate().Description, "Pinned template should be loaded.")
+
+	// Unpinned packs follow their branch again
+	_, err = suite.manager.Pin("community", "", true)
+	suite.Assertions.NoError(err, "Unpinning the pack should not fail.")
+	packs, _ = suite.manager.Update(nil)
+	suite.Assertions.NotEqual(second, packs[0].Commit, "Unpinned pack should be updated.")
+	suite.Assertions.Equal("Third", suite.loadedTemplate().Description, "Latest template should be loaded.")
+}
+
+// TestRestoreFromLock checks that missing packs are restored at the commit of the lockfile
+func (suite *TemplatePackModuleTestSuite) TestRestoreFromLock() {
+	first := suite.pushTemplate("First")
+	_, err := suite.manager.Install(suite.remote, "", "", "")
+	suite.Assertions.NoError(err, "Installing the pack should not fail.")
+	suite.pushTemplate("Second")
+
+	suite.Assertions.NoError(os.RemoveAll(suite.manager.PackDir("pack")), "Removing the pack should not fail.")
+	packs, err := suite.manager.Update(nil)
+	suite.Assertions.NoError(err, "Restoring the pack should not fail.")
+	suite.Assertions.Equal(first, packs[0].Commit, "Pack should be restored at the locked commit.")
+	suite.Assertions.Equal("First", suite.loadedTemplate().Description, "Locked template should be loaded.")
+}
+
+// TestPackTemplatesPath checks that only the declared templates directory of a pack is loaded and hidden
+// directories like .github are skipped
+func (suite *TemplatePackModuleTestSuite) TestPackTemplatesPath() {
+	workflow := "name: CI\non: [push]\njobs:\n  test:\n    runs-on: ubuntu-latest\n"
+	suite.pushFiles("Templates", map[string]string{
+		"templates/pack.yaml":       "name: \"Pack-Template\"\ndescription: \"First\"\nscript:\n  code: \"ls\"\n",
+		"examples/example.yaml":     "name: \"Example\"\n",
+		".github/workflows/ci.yaml": workflow,
+		"templates/.hidden/ci.yaml": workflow,
+	})
+
+	_, err := suite.manager.Install(suite.remote, "", "", "missing")
+	suite.Assertions.EqualError(err, "templates path missing not found in the pack", "Missing path should fail.")
+	_, err = suite.manager.Install(suite.remote, "", "", "../other")
+	suite.Assertions.Error(err, "Path outside of the pack should fail.")
+
+	pack, err := suite.manager.Install(suite.remote, "", "", "./templates/")
+	suite.Assertions.NoError(err, "Installing the pack should not fail.")
+	suite.Assertions.Equal("templates", pack.Path, "Path should be recorded in the lockfile.")
+	suite.Assertions.Equal("First", suite.loadedTemplate().Description, "Template of the path should be loaded.")
+
+	// Without a path all templates of the pack are loaded, except the ones inside hidden directories
+	lock, err := LoadTemplateLock(suite.templatesPath)
+	suite.Assertions.NoError(err, "Loading the lockfile should not fail.")
+	lock.Packs[0].Path = ""
+	suite.Assertions.NoError(saveTemplateLock(suite.templatesPath, lock), "Saving the lockfile should not fail.")
+	_, problems := ValidateTemplates(suite.templatesPath)
+	suite.Assertions.Len(problems, 1, "Only the example should be loaded besides the template.")
+	suite.Assertions.Equal(filepath.Join(suite.manager.PackDir("pack"), "examples", "example.yaml"), problems[0].File,
+		"Example should be loaded.")
+}
+
+// TestInstallExistingDirectory checks that an existing pack directory is neither overwritten nor removed
+func (suite *TemplatePackModuleTestSuite) TestInstallExistingDirectory() {
+	suite.pushTemplate("First")
+	localTemplate := filepath.Join(suite.manager.PackDir("pack"), "local.yaml")
+	suite.Assertions.NoError(os.MkdirAll(filepath.Dir(localTemplate), 0755), "Creating the directory should not fail.")
+	suite.Assertions.NoError(os.WriteFile(localTemplate, []byte("name: \"Local\"\n"), 0644),
+		"Writing the template should not fail.")
+
+	_, err := suite.manager.Install(suite.remote, "", "", "")
+	suite.Assertions.Error(err, "Installing into an existing directory should fail.")
+	suite.Assertions.FileExists(localTemplate, "Existing directory should be kept.")
+
+	// A failed installation removes the directory it created
+	_, err = suite.manager.Install(suite.remote, "other", "", "missing")
+	suite.Assertions.Error(err, "Missing path should fail.")
+	suite.Assertions.NoDirExists(suite.manager.PackDir("other"), "Created directory should be removed.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestTemplatePackModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(TemplatePackModuleTestSuite))
+}
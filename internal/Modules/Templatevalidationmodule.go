@@ -0,0 +1,342 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/pkg/Utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The options how invalid templates are handled by the run
+const (
+	// InvalidTemplatesFail refuses to start the run if a template is invalid
+	InvalidTemplatesFail = "fail"
+	// InvalidTemplatesSkip reports the invalid templates and runs only the valid ones
+	InvalidTemplatesSkip = "skip"
+)
+
+// templateTypes are the supported types of the templates, empty means Flat
+var templateTypes = []string{"Flat", "Full", "Deep"}
+
+// scriptLanguages are the supported languages of the scripts, empty means cli
+var scriptLanguages = []string{"cli", "bash", "python"}
+
+// yamlErrorLine matches the line of the errors of the yaml decoder e.g. "line 3: field foo not found"
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// TemplateError describes a problem of a template file, Line is 0 if the problem has no position
+type TemplateError struct {
+	// Path of the template file
+	File string
+	// Line of the problem inside the file
+	Line int
+	// Description of the problem
+	Message string
+}
+
+// Error returns the problem in the format file:line: message
+func (e TemplateError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return e.File + ": " + e.Message
+}
+
+// IsValidInvalidTemplatesOption returns true if the given handling of invalid templates is supported,
+// empty means the default handling
+func IsValidInvalidTemplatesOption(option string) bool {
+	return option == "" || option == InvalidTemplatesFail || option == InvalidTemplatesSkip
+}
+
+// ValidateTemplates loads and validates all templates which can be found in the given directory.
+// The templates are composed from their base templates and included regex libraries and their variables are
+// substituted. The valid templates are returned with their expressions compiled, all problems of the invalid
+// templates are returned with file and line. The base template template.yaml, abstract templates and regex
+// libraries are not returned. Templates of installed template packs are versioned with the commit of their pack.
+func ValidateTemplates(path string) (templates []Analyzer.Template, problems []TemplateError) {
+	root := path
+	lock, err := LoadTemplateLock(root)
+	if err != nil {
+		problems = append(problems, TemplateError{File: filepath.Join(root, TemplateLockFile), Message: err.Error()})
+	}
+	templateDirs := packTemplateDirs(root, lock)
+	loader := newTemplateLoader()
+	var files []*templateFile
+	err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			problems = append(problems, TemplateError{File: path, Message: err.Error()})
+			return nil
+		}
+		// Skip hidden directories like .git or .github of the template packs
+		if info.IsDir() && path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		// Skip the files of the template packs outside of their templates directory
+		if outsidePackTemplates(path, info.IsDir(), templateDirs) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Skip everything except yaml files, the base template and the regex libraries
+		if info.IsDir() || !strings.Contains(path, ".yaml") || info.Name() == "template.yaml" || isRegexLibrary(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, TemplateError{File: path, Message: err.Error()})
+			return nil
+		}
+
+		file := parseTemplate(path, content)
+		if other, found := loader.names[file.template.Name]; found && file.template.Name != "" {
+			file.problems = append(file.problems, TemplateError{File: path, Line: 1,
+				Message: fmt.Sprintf("template name %q is already used by %s", file.template.Name, other.path)})
+		} else if file.root != nil && file.template.Name != "" {
+			loader.names[file.template.Name] = file
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		problems = append(problems, TemplateError{File: path, Message: err.Error()})
+	}
+
+	for _, file := range files {
+		// Templates with syntax errors can't be composed, incomplete ones are not validated any further
+		valid := file.root != nil && loader.resolve(file, nil)
+		problems = append(problems, file.problems...)
+		if file.root == nil || file.incomplete {
+			continue
+		}
+		template, templateProblems := composeTemplate(file)
+		problems = append(problems, templateProblems...)
+		if !valid || len(templateProblems) > 0 || template.Abstract {
+			continue
+		}
+
+		// Compile the expressions once, the defaults of the entropy rules are filled
+		for i, regex := range template.Regex {
+			regex = prepareRegex(regex)
+			regex.Compiled = regexp.MustCompile(regexExpression(regex))
+			template.Regex[i] = regex
+		}
+		// Identify the version of the template by its content and the content of its base templates and includes
+		hash := sha256.Sum256(file.hashInput)
+		template.Hash = hex.EncodeToString(hash[:])[:12]
+		pack, installed := packForPath(root, file.path, lock)
+		template.Version = templateVersion(template.Hash, pack, installed)
+		templates = append(templates, template)
+	}
+	problems = append(problems, loader.problems...)
+	problems = uniqueTemplateErrors(problems)
+	sortTemplateErrors(problems)
+	return templates, problems
+}
+
+// parseTemplate decodes the template file, the root of the file is nil if the file has syntax errors
+func parseTemplate(path string, content []byte) *templateFile {
+	file := &templateFile{path: path, content: content}
+	file.root, file.problems = decodeStrict(path, content, &file.template)
+	return file
+}
+
+// composeTemplate substitutes the variables of the composed template of the file and validates it.
+// Abstract templates are only validated partially, their regular expressions and variables are validated within
+// the templates extending them.
+func composeTemplate(file *templateFile) (Analyzer.Template, []TemplateError) {
+	template, undefined := substituteVariables(file.composed)
+	if template.Abstract {
+		template.Regex, undefined = nil, nil
+	}
+	problems := validateTemplate(file.path, template, file.root, file.sources)
+	for _, name := range undefined {
+		problems = append(problems, TemplateError{File: file.path, Line: variableLine(file.content, name),
+			Message: fmt.Sprintf("variable ${%s} is not defined", name)})
+	}
+	return template, problems
+}
+
+// decodeStrict decodes the yaml file into the given value.
+// Unknown keys are reported by the strict decoding, the known fields are decoded anyway to validate them.
+// The returned node tree is used to locate the fields of the problems, it is nil if the file has syntax errors.
+func decodeStrict(path string, content []byte, value interface{}) (*yaml.Node, []TemplateError) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, []TemplateError{yamlError(path, err.Error())}
+	}
+
+	var problems []TemplateError
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(value); err != nil && err != io.EOF {
+		typeError, ok := err.(*yaml.TypeError)
+		if !ok {
+			return nil, []TemplateError{yamlError(path, err.Error())}
+		}
+		for _, message := range typeError.Errors {
+			problems = append(problems, yamlError(path, message))
+		}
+	}
+	return &root, problems
+}
+
+// yamlError converts an error message of the yaml decoder into a TemplateError with the line of the message
+func yamlError(path, message string) TemplateError {
+	if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return TemplateError{File: path, Line: line, Message: match[2]}
+	}
+	return TemplateError{File: path, Message: strings.TrimPrefix(message, "yaml: ")}
+}
+
+// validateTemplate checks the required fields, the known values and the regular expressions of the template.
+// The problems of the regular expressions are reported inside the files defining them, given by their sources.
+func validateTemplate(path string, template Analyzer.Template, root *yaml.Node,
+	sources []regexSource) (problems []TemplateError) {
+	// report adds a problem at the line of the field with the given path
+	report := func(message string, field ...interface{}) {
+		problems = append(problems, TemplateError{File: path, Line: nodeLine(root, field...), Message: message})
+	}
+
+	if strings.TrimSpace(template.Name) == "" {
+		report("name is required", "name")
+	}
+	if !template.Abstract && len(template.Regex) == 0 && strings.TrimSpace(template.Script.Code) == "" {
+		report("template needs a regex or a script")
+	}
+	if template.Type != "" && !Utils.Contains(templateTypes, template.Type) {
+		report(fmt.Sprintf("unknown type %q (valid: %s)", template.Type, strings.Join(templateTypes, ", ")), "type")
+	}
+	if template.Script.Language != "" && !Utils.Contains(scriptLanguages, template.Script.Language) {
+		report(fmt.Sprintf("unknown script language %q (valid: %s)", template.Script.Language,
+			strings.Join(scriptLanguages, ", ")), "script", "language")
+	}
+	if template.PreScript.Language != "" && !Utils.Contains(scriptLanguages, template.PreScript.Language) {
+		report(fmt.Sprintf("unknown script language %q (valid: %s)", template.PreScript.Language,
+			strings.Join(scriptLanguages, ", ")), "pre_script", "language")
+	}
+	if err := validateScript(template.Script.Code, template.Script.Language); err != nil {
+		report("script: "+scriptError(err), "script", "code")
+	}
+	if err := validateScript(template.PreScript.Code, template.PreScript.Language); err != nil {
+		report("pre_script: "+scriptError(err), "pre_script", "code")
+	}
+	if !IsValidRedactPolicy(template.Output.Redact) {
+		report(fmt.Sprintf("unknown redact policy %q (valid: partial, hash, none)", template.Output.Redact),
+			"output", "redact")
+	}
+	if template.Meta.Severity != "" && !Utils.Contains(severities, strings.ToLower(template.Meta.Severity)) {
+		report(fmt.Sprintf("unknown severity %q (valid: %s)", template.Meta.Severity, strings.Join(severities, ", ")),
+			"meta", "severity")
+	}
+	validateWhen(template.When, report)
+
+	for i, regex := range template.Regex {
+		source := sources[i]
+		// report adds a problem at the line of the field of the regular expression inside its file
+		report := func(message string, field ...interface{}) {
+			problems = append(problems, TemplateError{File: source.file,
+				Line: nodeLine(source.root, append([]interface{}{"regex", source.index}, field...)...), Message: message})
+		}
+		name := fmt.Sprintf("regex %d", source.index+1)
+		if regex.Description != "" {
+			name += fmt.Sprintf(" (%s)", regex.Description)
+		}
+		if !IsValidRegexType(regex.Type) {
+			report(fmt.Sprintf("%s: unknown type %q (valid: regex, entropy)", name, regex.Type), "type")
+		}
+		if !IsValidRegexMode(regex.Mode) {
+			report(fmt.Sprintf("%s: unknown mode %q (valid: line, multiline, file)", name, regex.Mode),
+				"mode")
+		}
+		if !IsValidRedactPolicy(regex.Redact) {
+			report(fmt.Sprintf("%s: unknown redact policy %q (valid: partial, hash, none)", name, regex.Redact),
+				"redact")
+		}
+		if len(regex.Filename) == 0 && len(regex.FileEndings) == 0 {
+			report(name + ": filename or file_endings is required, otherwise no file is scanned")
+		}
+
+		// Check the expression with the defaults of its type
+		regex = prepareRegex(regex)
+		if strings.TrimSpace(regex.Expression) == "" {
+			report(name+": expression is required", "expression")
+			continue
+		}
+		expression, err := regexp.Compile(regexExpression(regex))
+		if err != nil {
+			report(fmt.Sprintf("%s: invalid expression: %s", name, err.Error()), "expression")
+			continue
+		}
+		if regex.Group < 0 || regex.Group > expression.NumSubexp() {
+			report(fmt.Sprintf("%s: group %d exceeds the %d capture groups of the expression", name, regex.Group,
+				expression.NumSubexp()), "group")
+		}
+	}
+	return problems
+}
+
+// nodeLine returns the line of the value at the given path of mapping keys and sequence indexes.
+// Missing fields are reported at the line of their deepest existing parent.
+func nodeLine(root *yaml.Node, path ...interface{}) int {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := node.Line
+	for _, key := range path {
+		var next *yaml.Node
+		switch key := key.(type) {
+		case string:
+			for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					next = node.Content[i+1]
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && key < len(node.Content) {
+				next = node.Content[key]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, line = next, next.Line
+	}
+	return line
+}
+
+// uniqueTemplateErrors removes duplicated problems, e.g. of a regex library included by several templates
+func uniqueTemplateErrors(problems []TemplateError) []TemplateError {
+	seen := make(map[TemplateError]bool)
+	var unique []TemplateError
+	for _, problem := range problems {
+		if !seen[problem] {
+			seen[problem] = true
+			unique = append(unique, problem)
+		}
+	}
+	return unique
+}
+
+// sortTemplateErrors sorts the problems by file and line
+func sortTemplateErrors(problems []TemplateError) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+}
@@ -0,0 +1,99 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Create test suite for the template validation module
+type TemplateValidationModuleTestSuite struct {
+	suite.Suite
+	tempDir string
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *TemplateValidationModuleTestSuite) SetupTest() {
+	suite.tempDir = suite.T().TempDir()
+}
+
+// writeTemplate writes the template file into the temporary directory and returns its path
+func (suite *TemplateValidationModuleTestSuite) writeTemplate(name, content string) string {
+	path := filepath.Join(suite.tempDir, name)
+	suite.Assertions.NoError(os.WriteFile(path, []byte(content), 0644), "Writing the template should not fail.")
+	return path
+}
+
+// TestValidateTemplate checks that all problems of a template are reported with their lines
+func (suite *TemplateValidationModuleTestSuite) TestValidateTemplate() {
+	path := suite.writeTemplate("broken.yaml", `name: "Broken"
+type: "Shallow"
+tags: ["misc"]
+regex:
+  - description: "Token"
+    expression: "token=(\\w+"
+    file_endings: [".env"]
+  - description: "Key"
+    expression: "key=(\\w+)"
+    group: 2
+    filename: ["config"]
+  - expresion: "secret"
+    file_ending: [".env"]
+script:
+  language: "ruby"
+  code: "ls"
+`)
+	templates, problems := ValidateTemplates(suite.tempDir)
+
+	suite.Assertions.Empty(templates, "Invalid template should not be loaded.")
+	suite.Assertions.Equal([]TemplateError{
+		{File: path, Line: 2, Message: `unknown type "Shallow" (valid: Flat, Full, Deep)`},
+		{File: path, Line: 6, Message: "regex 1 (Token): invalid expression: error parsing regexp: missing closing ): `token=(\\w+`"},
+		{File: path, Line: 10, Message: "regex 2 (Key): group 2 exceeds the 1 capture groups of the expression"},
+		{File: path, Line: 12, Message: "field expresion not found in type Analyzer.Regex"},
+		{File: path, Line: 12, Message: "regex 3: filename or file_endings is required, otherwise no file is scanned"},
+		{File: path, Line: 12, Message: "regex 3: expression is required"},
+		{File: path, Line: 13, Message: "field file_ending not found in type Analyzer.Regex"},
+		{File: path, Line: 15, Message: `unknown script language "ruby" (valid: cli, bash, python)`},
+	}, problems, "Problems should equal.")
+	suite.Assertions.Equal(path+":2: unknown type \"Shallow\" (valid: Flat, Full, Deep)", problems[0].Error(),
+		"Error should contain file and line.")
+}
+
+// TestValidateTemplatesSkipsInvalid checks that valid templates are loaded next to invalid ones
+func (suite *TemplateValidationModuleTestSuite) TestValidateTemplatesSkipsInvalid() {
+	suite.writeTemplate("a.yaml", "name: \"Secrets\"\nregex:\n  - expression: \"key=(.*)\"\n    group: 1\n"+
+		"    file_endings: [\".env\"]\n")
+	duplicate := suite.writeTemplate("b.yaml", "name: \"Secrets\"\nscript:\n  code: \"ls\"\n")
+	syntax := suite.writeTemplate("c.yaml", "name: \"Syntax\"\nscript:\n  code: \"ls\n")
+	missing := suite.writeTemplate("d.yaml", "description: \"No name\"\n")
+
+	templates, problems := ValidateTemplates(suite.tempDir)
+	suite.Assertions.Len(templates, 1, "Valid template should be loaded.")
+	suite.Assertions.Equal("Secrets", templates[0].Name, "Template name should equal.")
+	suite.Assertions.NotNil(templates[0].Regex[0].Compiled, "Expression should be compiled.")
+	suite.Assertions.Len(templates[0].Hash, 12, "Template version should be set.")
+
+	suite.Assertions.Len(problems, 4, "Problems should be reported.")
+	suite.Assertions.Equal(duplicate, problems[0].File, "Duplicate name should be reported.")
+	suite.Assertions.Contains(problems[0].Message, "already used by", "Duplicate name should be reported.")
+	suite.Assertions.Equal(syntax, problems[1].File, "Syntax error should be reported.")
+	suite.Assertions.Equal(3, problems[1].Line, "Syntax error line should equal.")
+	suite.Assertions.Equal([]TemplateError{{File: missing, Line: 1, Message: "name is required"},
+		{File: missing, Line: 1, Message: "template needs a regex or a script"}}, problems[2:],
+		"Missing fields should be reported.")
+}
+
+// TestValidateBundledTemplates checks that all templates of the repository are valid
+func (suite *TemplateValidationModuleTestSuite) TestValidateBundledTemplates() {
+	templates, problems := ValidateTemplates("../../templates")
+	suite.Assertions.Empty(problems, "Bundled templates should be valid.")
+	suite.Assertions.NotEmpty(templates, "Bundled templates should be loaded.")
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestTemplateValidationModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(TemplateValidationModuleTestSuite))
+}
@@ -0,0 +1,282 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// workerRetryDelay is the time a worker waits before asking the coordinator again
+// if no task was available or the coordinator could not be reached.
+var workerRetryDelay = 5 * time.Second
+
+// workerMaxLeaseErrors is the number of failed lease requests in a row, after which the worker assumes the
+// coordinator is gone and stops
+var workerMaxLeaseErrors = 12
+
+// ErrLeaseExpired is returned by the CoordinatorClient if the coordinator doesn't know the lease anymore.
+var ErrLeaseExpired = errors.New("lease expired")
+
+// The CoordinatorClient struct is used by a worker to talk to the coordinator of a distributed scan
+type CoordinatorClient struct {
+	// Base address of the coordinator e.g. http://127.0.0.1:9090
+	address string
+	// The http client used for all requests
+	client *http.Client
+}
+
+// NewCoordinatorClient is the constructor to create a new CoordinatorClient for the given address.
+// If the address has no scheme, http is used.
+func NewCoordinatorClient(address string) *CoordinatorClient {
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = "http://" + address
+	}
+	return &CoordinatorClient{address: strings.TrimSuffix(address, "/"), client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Lease asks the coordinator for the next task.
+// lease is nil if no task is available at the moment. finished is true if all tasks of the scan are done.
+func (cc *CoordinatorClient) Lease(worker string) (lease *Analyzer.Lease, finished bool, err error) {
+	resp, err := cc.client.Post(cc.address+"/lease?worker="+url.QueryEscape(worker), "application/json", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusGone:
+		return nil, true, nil
+	case http.StatusNoContent:
+		return nil, false, nil
+	case http.StatusOK:
+		lease = &Analyzer.Lease{}
+		err = json.NewDecoder(resp.Body).Decode(lease)
+		return lease, false, err
+	default:
+		return nil, false, fmt.Errorf("unexpected status from coordinator: %s", resp.Status)
+	}
+}
+
+// Heartbeat renews the lease with the given ID
+func (cc *CoordinatorClient) Heartbeat(id string) error {
+	return cc.post("/heartbeat", Analyzer.LeaseUpdate{ID: id})
+}
+
+// Complete sends the state and results of the given task to the coordinator and ends the lease with the given ID
+func (cc *CoordinatorClient) Complete(id string, task Analyzer.Task) error {
+	return cc.post("/complete", Analyzer.LeaseUpdate{ID: id, Task: task})
+}
+
+// post sends the given lease update as json to the provided endpoint of the coordinator
+func (cc *CoordinatorClient) post(endpoint string, update Analyzer.LeaseUpdate) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	resp, err := cc.client.Post(cc.address+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrLeaseExpired
+	default:
+		return fmt.Errorf("unexpected status from coordinator: %s", resp.Status)
+	}
+}
+
+// workerLeases keeps track of the leases a worker is currently working on
+type workerLeases struct {
+	// mutex protects the leases
+	mutex sync.Mutex
+	// Map task URL => IDs of the leases for the URL
+	leases map[string][]string
+}
+
+// add stores the lease ID for the given URL
+func (wl *workerLeases) add(url, id string) {
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+	wl.leases[url] = append(wl.leases[url], id)
+}
+
+// remove deletes and returns the oldest lease ID for the given URL
+func (wl *workerLeases) remove(url string) (string, bool) {
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+	ids := wl.leases[url]
+	if len(ids) == 0 {
+		return "", false
+	}
+	if len(ids) == 1 {
+		delete(wl.leases, url)
+	} else {
+		wl.leases[url] = ids[1:]
+	}
+	return ids[0], true
+}
+
+// ids returns all lease IDs
+func (wl *workerLeases) ids() (ids []string) {
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+	for _, leaseIDs := range wl.leases {
+		ids = append(ids, leaseIDs...)
+	}
+	return ids
+}
+
+// count returns the number of leases
+func (wl *workerLeases) count() int {
+	return len(wl.ids())
+}
+
+// heartbeat renews all leases of the worker every interval until the stop channel is closed
+func heartbeat(client *CoordinatorClient, leases *workerLeases, interval time.Duration, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+			for _, id := range leases.ids() {
+				if err := client.Heartbeat(id); err != nil {
+					fmt.Println("Error renewing lease:", err)
+				}
+			}
+		}
+	}
+}
+
+// RunWorker is the main function to start a worker of a distributed scan.
+// The worker leases tasks from the coordinator set in the provided config, runs the templates
+// on them like the run command and sends the results back to the coordinator.
+// The worker stops once all tasks are done or the coordinator can't be reached anymore.
+func RunWorker(gitHelper IGitHelper, config Analyzer.Config) {
+	// Initialize handlers
+	repoHandler := NewRepoHandler(gitHelper, config)
+	templateHandler := NewTemplateHandler(repoHandler, config)
+
+	// Load templates
+	templateHandler.LoadTemplates(config.TemplatesPath)
+
+	// Check if all requirements are met
+	templateHandler.CheckRequirements()
+
+	// Run preScripts
+	templateHandler.preScript()
+
+	// Name of the worker used by the coordinator for logging
+	hostname, _ := os.Hostname()
+	worker := hostname + "-" + strconv.Itoa(os.Getpid())
+
+	client := NewCoordinatorClient(config.CoordinatorAddress)
+	leases := &workerLeases{leases: make(map[string][]string)}
+
+	// The worker leases at most as many tasks as it can clone and scan at the same time
+	capacity := config.CloneWorkers + config.ScanWorkers
+	slots := make(chan struct{}, capacity)
+
+	// Initialize necessary channels and start the clone and scan worker pools
+	tasks := make(chan Analyzer.Task)
+	cTasks := make(chan Analyzer.Task, capacity)
+	cloneRepoAndRunTemplates(repoHandler, templateHandler, config, tasks, cTasks)
+
+	// Lease tasks until the coordinator reports that all tasks are done
+	leasingDone := make(chan struct{})
+	heartbeatInterval := make(chan time.Duration, 1)
+	go func() {
+		defer close(leasingDone)
+		defer close(tasks)
+		leaseErrors := 0
+		for {
+			// Wait for a free slot
+			slots <- struct{}{}
+			lease, finished, err := client.Lease(worker)
+			if err != nil || lease == nil {
+				// Free the slot again
+				<-slots
+				if err != nil {
+					fmt.Println("Error leasing task:", err)
+					leaseErrors++
+				} else {
+					leaseErrors = 0
+				}
+				if finished {
+					return
+				}
+				if leaseErrors >= workerMaxLeaseErrors {
+					fmt.Println("Coordinator not reachable after", leaseErrors, "attempts, stopping worker")
+					return
+				}
+				time.Sleep(workerRetryDelay)
+				continue
+			}
+			leaseErrors = 0
+			// Renew leases three times per lease timeout
+			select {
+			case heartbeatInterval <- time.Duration(lease.TimeoutSeconds) * time.Second / 3:
+			default:
+			}
+			leases.add(lease.Task.URL, lease.ID)
+			tasks <- lease.Task
+		}
+	}()
+
+	// Start renewing the leases once the first lease tells the lease timeout
+	stopHeartbeat := make(chan struct{})
+	go func() {
+		select {
+		case interval := <-heartbeatInterval:
+			if interval <= 0 {
+				interval = time.Second
+			}
+			heartbeat(client, leases, interval, stopHeartbeat)
+		case <-stopHeartbeat:
+		}
+	}()
+
+	// Read all updates from the cTasks channel and send the done tasks to the coordinator
+	for {
+		select {
+		case task := <-cTasks:
+			if task.State != "finished" && task.State != "failed" {
+				continue
+			}
+			// Free the slot of the task, even if its lease is unknown
+			<-slots
+			id, found := leases.remove(task.URL)
+			if !found {
+				continue
+			}
+			// The coordinator still accepts the results of an expired lease, if no other worker finished the task
+			if err := client.Complete(id, task); err != nil {
+				fmt.Println("Error completing task:", task.URL, err)
+			}
+			continue
+		case <-leasingDone:
+			if leases.count() > 0 {
+				// Wait for the remaining tasks
+				time.Sleep(time.Millisecond * 200)
+				continue
+			}
+		}
+		break
+	}
+	close(stopHeartbeat)
+
+	fmt.Println("Finished")
+}
@@ -0,0 +1,158 @@
+// Package Modules contains all business logic modules/components of the application.
+package Modules
+
+import (
+	"GitAnalyzer/api/Analyzer"
+	"GitAnalyzer/internal/mocks"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Create test suite for the worker module
+type WorkerModuleTestSuite struct {
+	suite.Suite
+	tempDir       string
+	config        Analyzer.Config
+	mockGitHelper *mocks.IGitHelper
+	resultSink    *CSVResultSink
+}
+
+// SetupTest is run before every test of the test suite to initialize a clear state
+func (suite *WorkerModuleTestSuite) SetupTest() {
+	// The worker clones into ./repos, so run it inside a temporary directory
+	suite.tempDir = suite.T().TempDir()
+	workDir, err := os.Getwd()
+	suite.Assertions.NoError(err, "Getting the working directory should not fail.")
+	suite.Assertions.NoError(os.Chdir(suite.tempDir), "Changing the working directory should not fail.")
+	suite.T().Cleanup(func() { _ = os.Chdir(workDir) })
+
+	templatesPath := filepath.Join(suite.tempDir, "templates")
+	suite.Assertions.NoError(os.MkdirAll(templatesPath, 0755), "Creating the templates dir should not fail.")
+	suite.config = Analyzer.Config{TemplatesPath: templatesPath, ResultsDir: suite.tempDir,
+		CloneWorkers: 1, ScanWorkers: 1}
+	suite.mockGitHelper = mocks.NewIGitHelper(suite.T())
+
+	// Retry faster and give up after a few errors
+	retryDelay, maxLeaseErrors := workerRetryDelay, workerMaxLeaseErrors
+	workerRetryDelay, workerMaxLeaseErrors = time.Millisecond*10, 3
+	suite.T().Cleanup(func() { workerRetryDelay, workerMaxLeaseErrors = retryDelay, maxLeaseErrors })
+}
+
+// startCoordinator creates a coordinator for the given number of tasks and serves it on localhost
+func (suite *WorkerModuleTestSuite) startCoordinator(numberOfTasks, maxAttempts int) (*Coordinator, *httptest.Server) {
+	var tasks []Analyzer.Task
+	for i := 0; i < numberOfTasks; i++ {
+		tasks = append(tasks, Analyzer.Task{URL: "https://github.com/gitanalyzer/test" + strconv.Itoa(i)})
+	}
+	suite.resultSink = NewCSVResultSink(&FileHandler{Analyzer.Config{ResultsDir: suite.config.ResultsDir}})
+	suite.Assertions.NoError(suite.resultSink.Open(Analyzer.ScanSession{ID: 1}), "Opening the result sink should not fail.")
+	coordinator := NewCoordinator(Analyzer.CoordinatorConfig{LeaseTimeout: time.Minute, MaxAttempts: maxAttempts},
+		suite.resultSink, tasks)
+	server := httptest.NewServer(coordinator.Handler())
+	suite.T().Cleanup(server.Close)
+	suite.config.CoordinatorAddress = server.URL
+	return coordinator, server
+}
+
+// initRepository creates a repository with one commit inside the given directory, as a clone would do
+func initRepository(dir string) (*git.Repository, error) {
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	_, err = worktree.Commit("Initial commit", &git.CommitOptions{AllowEmptyCommits: true,
+		Author: &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Now()}})
+	return repo, err
+}
+
+// runWorker runs the worker in the background and returns a channel, which is closed once the worker stopped
+func (suite *WorkerModuleTestSuite) runWorker() <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		RunWorker(suite.mockGitHelper, suite.config)
+	}()
+	return stopped
+}
+
+// waitForWorker fails the test if the worker doesn't stop in time
+func (suite *WorkerModuleTestSuite) waitForWorker(stopped <-chan struct{}) {
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 20):
+		suite.FailNow("Worker should stop.")
+	}
+}
+
+// TestRunWorker checks that the worker scans all tasks, also the failed ones release their slot
+func (suite *WorkerModuleTestSuite) TestRunWorker() {
+	coordinator, _ := suite.startCoordinator(4, 1)
+	suite.mockGitHelper.On("Clone", mock.Anything, "https://github.com/gitanalyzer/test0").
+		Return(nil, git.ErrRepositoryNotExists)
+	suite.mockGitHelper.On("Clone", mock.Anything, mock.Anything).Return(func(dir, url string) (*git.Repository, error) {
+		return initRepository(dir)
+	})
+
+	suite.waitForWorker(suite.runWorker())
+
+	stats := coordinator.Stats()
+	suite.Assertions.Equal(int32(3), stats.FinishedScans, "Cloned tasks should be finished.")
+	suite.Assertions.Equal(int32(1), stats.FailedScans, "Broken task should be failed.")
+	suite.Assertions.NoError(coordinator.closeResultSink(), "Closing the result sink should not fail.")
+}
+
+// TestLostLease checks that the results of a task are kept, even though its lease expired during the scan
+func (suite *WorkerModuleTestSuite) TestLostLease() {
+	coordinator, _ := suite.startCoordinator(1, 1)
+	cloning := make(chan struct{})
+	release := make(chan struct{})
+	suite.mockGitHelper.On("Clone", mock.Anything, mock.Anything).Return(func(dir, url string) (*git.Repository, error) {
+		close(cloning)
+		<-release
+		return initRepository(dir)
+	})
+
+	stopped := suite.runWorker()
+	<-cloning
+	// Let the lease expire while the worker is still cloning, the task fails after its only attempt
+	coordinator.mutex.Lock()
+	coordinator.expireLeases(time.Now().Add(time.Hour))
+	coordinator.mutex.Unlock()
+	suite.Assertions.Equal(int32(1), coordinator.Stats().FailedScans, "Expired task should be failed.")
+	close(release)
+	suite.waitForWorker(stopped)
+
+	// The late results replace the failure
+	stats := coordinator.Stats()
+	suite.Assertions.Equal(int32(1), stats.FinishedScans, "Late task should be finished.")
+	suite.Assertions.Equal(int32(0), stats.FailedScans, "Late task should not be failed.")
+	suite.Assertions.NoError(coordinator.closeResultSink(), "Closing the result sink should not fail.")
+	fileHelper := &FileHandler{Analyzer.Config{ResultsDir: suite.config.ResultsDir}}
+	suite.Assertions.Len(fileHelper.UnMarshallStats(fileHelper.GetResultCSVPath("checked")), 1,
+		"Late task should be marked as checked.")
+}
+
+// TestCoordinatorShutdown checks that the worker stops, once the coordinator can't be reached anymore
+func (suite *WorkerModuleTestSuite) TestCoordinatorShutdown() {
+	_, server := suite.startCoordinator(1, 1)
+	server.Close()
+
+	suite.waitForWorker(suite.runWorker())
+}
+
+// This functions runs the test suite add a 'go test' command
+func TestWorkerModuleTestSuite(t *testing.T) {
+	suite.Run(t, new(WorkerModuleTestSuite))
+}
@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	Analyzer "GitAnalyzer/api/Analyzer"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // ICommandHelper is an autogenerated mock type for the ICommandHelper type
 type ICommandHelper struct {
@@ -143,13 +147,13 @@ func (_c *ICommandHelper_CheckRequiredTools_Call) RunAndReturn(run func(string)
 	return _c
 }
 
-// RunCommand provides a mock function with given fields: command, path, language
-func (_m *ICommandHelper) RunCommand(command string, path string, language string) string {
-	ret := _m.Called(command, path, language)
+// RunCommand provides a mock function with given fields: command, path, language, context
+func (_m *ICommandHelper) RunCommand(command string, path string, language string, context Analyzer.ScriptContext) string {
+	ret := _m.Called(command, path, language, context)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
-		r0 = rf(command, path, language)
+	if rf, ok := ret.Get(0).(func(string, string, string, Analyzer.ScriptContext) string); ok {
+		r0 = rf(command, path, language, context)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
@@ -166,13 +170,14 @@ type ICommandHelper_RunCommand_Call struct {
 //   - command string
 //   - path string
 //   - language string
-func (_e *ICommandHelper_Expecter) RunCommand(command interface{}, path interface{}, language interface{}) *ICommandHelper_RunCommand_Call {
-	return &ICommandHelper_RunCommand_Call{Call: _e.mock.On("RunCommand", command, path, language)}
+//   - context Analyzer.ScriptContext
+func (_e *ICommandHelper_Expecter) RunCommand(command interface{}, path interface{}, language interface{}, context interface{}) *ICommandHelper_RunCommand_Call {
+	return &ICommandHelper_RunCommand_Call{Call: _e.mock.On("RunCommand", command, path, language, context)}
 }
 
-func (_c *ICommandHelper_RunCommand_Call) Run(run func(command string, path string, language string)) *ICommandHelper_RunCommand_Call {
+func (_c *ICommandHelper_RunCommand_Call) Run(run func(command string, path string, language string, context Analyzer.ScriptContext)) *ICommandHelper_RunCommand_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(string))
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(Analyzer.ScriptContext))
 	})
 	return _c
 }
@@ -182,7 +187,7 @@ func (_c *ICommandHelper_RunCommand_Call) Return(_a0 string) *ICommandHelper_Run
 	return _c
 }
 
-func (_c *ICommandHelper_RunCommand_Call) RunAndReturn(run func(string, string, string) string) *ICommandHelper_RunCommand_Call {
+func (_c *ICommandHelper_RunCommand_Call) RunAndReturn(run func(string, string, string, Analyzer.ScriptContext) string) *ICommandHelper_RunCommand_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -141,13 +141,13 @@ func (_c *IFileHelper_GetResultCSVPath_Call) RunAndReturn(run func(string) strin
 	return _c
 }
 
-// GetTasks provides a mock function with given fields: urlsCSVPath, checkedCSVPath
-func (_m *IFileHelper) GetTasks(urlsCSVPath string, checkedCSVPath string) []Analyzer.Task {
-	ret := _m.Called(urlsCSVPath, checkedCSVPath)
+// GetTasks provides a mock function with given fields: source, checkedCSVPath
+func (_m *IFileHelper) GetTasks(source string, checkedCSVPath string) []Analyzer.Task {
+	ret := _m.Called(source, checkedCSVPath)
 
 	var r0 []Analyzer.Task
 	if rf, ok := ret.Get(0).(func(string, string) []Analyzer.Task); ok {
-		r0 = rf(urlsCSVPath, checkedCSVPath)
+		r0 = rf(source, checkedCSVPath)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]Analyzer.Task)
@@ -163,13 +163,13 @@ type IFileHelper_GetTasks_Call struct {
 }
 
 // GetTasks is a helper method to define mock.On call
-//   - urlsCSVPath string
+//   - source string
 //   - checkedCSVPath string
-func (_e *IFileHelper_Expecter) GetTasks(urlsCSVPath interface{}, checkedCSVPath interface{}) *IFileHelper_GetTasks_Call {
-	return &IFileHelper_GetTasks_Call{Call: _e.mock.On("GetTasks", urlsCSVPath, checkedCSVPath)}
+func (_e *IFileHelper_Expecter) GetTasks(source interface{}, checkedCSVPath interface{}) *IFileHelper_GetTasks_Call {
+	return &IFileHelper_GetTasks_Call{Call: _e.mock.On("GetTasks", source, checkedCSVPath)}
 }
 
-func (_c *IFileHelper_GetTasks_Call) Run(run func(urlsCSVPath string, checkedCSVPath string)) *IFileHelper_GetTasks_Call {
+func (_c *IFileHelper_GetTasks_Call) Run(run func(source string, checkedCSVPath string)) *IFileHelper_GetTasks_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(string), args[1].(string))
 	})
@@ -318,47 +318,48 @@ func (_c *IFileHelper_PrepareResultsFolder_Call) RunAndReturn(run func(string))
 	return _c
 }
 
-// SearchFilesByRegex provides a mock function with given fields: rootDir, template
-func (_m *IFileHelper) SearchFilesByRegex(rootDir string, template Analyzer.Template) []Analyzer.Result {
-	ret := _m.Called(rootDir, template)
+// SearchFilesByTemplates provides a mock function with given fields: rootDir, files, templates
+func (_m *IFileHelper) SearchFilesByTemplates(rootDir string, files []Analyzer.CheckoutFile, templates []Analyzer.Template) [][]Analyzer.Result {
+	ret := _m.Called(rootDir, files, templates)
 
-	var r0 []Analyzer.Result
-	if rf, ok := ret.Get(0).(func(string, Analyzer.Template) []Analyzer.Result); ok {
-		r0 = rf(rootDir, template)
+	var r0 [][]Analyzer.Result
+	if rf, ok := ret.Get(0).(func(string, []Analyzer.CheckoutFile, []Analyzer.Template) [][]Analyzer.Result); ok {
+		r0 = rf(rootDir, files, templates)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]Analyzer.Result)
+			r0 = ret.Get(0).([][]Analyzer.Result)
 		}
 	}
 
 	return r0
 }
 
-// IFileHelper_SearchFilesByRegex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchFilesByRegex'
-type IFileHelper_SearchFilesByRegex_Call struct {
+// IFileHelper_SearchFilesByTemplates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SearchFilesByTemplates'
+type IFileHelper_SearchFilesByTemplates_Call struct {
 	*mock.Call
 }
 
-// SearchFilesByRegex is a helper method to define mock.On call
+// SearchFilesByTemplates is a helper method to define mock.On call
 //   - rootDir string
-//   - template Analyzer.Template
-func (_e *IFileHelper_Expecter) SearchFilesByRegex(rootDir interface{}, template interface{}) *IFileHelper_SearchFilesByRegex_Call {
-	return &IFileHelper_SearchFilesByRegex_Call{Call: _e.mock.On("SearchFilesByRegex", rootDir, template)}
+//   - files []Analyzer.CheckoutFile
+//   - templates []Analyzer.Template
+func (_e *IFileHelper_Expecter) SearchFilesByTemplates(rootDir interface{}, files interface{}, templates interface{}) *IFileHelper_SearchFilesByTemplates_Call {
+	return &IFileHelper_SearchFilesByTemplates_Call{Call: _e.mock.On("SearchFilesByTemplates", rootDir, files, templates)}
 }
 
-func (_c *IFileHelper_SearchFilesByRegex_Call) Run(run func(rootDir string, template Analyzer.Template)) *IFileHelper_SearchFilesByRegex_Call {
+func (_c *IFileHelper_SearchFilesByTemplates_Call) Run(run func(rootDir string, files []Analyzer.CheckoutFile, templates []Analyzer.Template)) *IFileHelper_SearchFilesByTemplates_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(Analyzer.Template))
+		run(args[0].(string), args[1].([]Analyzer.CheckoutFile), args[2].([]Analyzer.Template))
 	})
 	return _c
 }
 
-func (_c *IFileHelper_SearchFilesByRegex_Call) Return(result []Analyzer.Result) *IFileHelper_SearchFilesByRegex_Call {
-	_c.Call.Return(result)
+func (_c *IFileHelper_SearchFilesByTemplates_Call) Return(_a0 [][]Analyzer.Result) *IFileHelper_SearchFilesByTemplates_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *IFileHelper_SearchFilesByRegex_Call) RunAndReturn(run func(string, Analyzer.Template) []Analyzer.Result) *IFileHelper_SearchFilesByRegex_Call {
+func (_c *IFileHelper_SearchFilesByTemplates_Call) RunAndReturn(run func(string, []Analyzer.CheckoutFile, []Analyzer.Template) [][]Analyzer.Result) *IFileHelper_SearchFilesByTemplates_Call {
 	_c.Call.Return(run)
 	return _c
 }